@@ -25,6 +25,7 @@ import (
 	"github.com/zchee/nvim-go/pkg/buildctxt"
 	"github.com/zchee/nvim-go/pkg/command"
 	"github.com/zchee/nvim-go/pkg/config"
+	"github.com/zchee/nvim-go/pkg/daemon"
 	"github.com/zchee/nvim-go/pkg/logger"
 	"github.com/zchee/nvim-go/pkg/nctx"
 	"github.com/zchee/nvim-go/pkg/server"
@@ -36,6 +37,9 @@ var (
 	fVersion    = flag.Bool("version", false, "Show the version information.")
 	pluginHost  = flag.String("manifest", "", "Write plugin manifest for `host` to stdout")
 	vimFilePath = flag.String("location", "", "Manifest is automatically written to `.vim file`")
+	fDaemon     = flag.Bool("daemon", false, "Run as the daemon which serves multiple Neovim instances.")
+	fClient     = flag.Bool("client", false, "Run as the thin client which forwards the requests to the daemon. The daemon is spawned if not running.")
+	socketPath  = flag.String("socket", daemon.DefaultSocketPath(), "Unix socket `path` of the daemon.")
 )
 
 func init() {
@@ -57,12 +61,7 @@ func main() {
 		ctx = logger.NewContext(ctx, zap.NewNop()) // avoid nil panic on logger.FromContext
 
 		fn := func(p *plugin.Plugin) error {
-			return func(ctx context.Context, p *plugin.Plugin) error {
-				bctxt := buildctxt.NewContext()
-				c := command.Register(ctx, p, bctxt)
-				autocmd.Register(ctx, p, bctxt, c)
-				return nil
-			}(ctx, p)
+			return register(ctx, p, command.NewCache())
 		}
 		if err := Plugin(fn); err != nil {
 			logpkg.Fatal(err)
@@ -70,6 +69,14 @@ func main() {
 		return
 	}
 
+	if *fClient {
+		// forward the msgpack-rpc stream of stdio to the daemon
+		if err := daemon.Forward(ctx, *socketPath, os.Stdin, os.Stdout); err != nil {
+			logpkg.Fatal(err)
+		}
+		return
+	}
+
 	sigc := make(chan os.Signal, 1)
 	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL)
 	sighupFn := func() {}
@@ -82,6 +89,10 @@ func main() {
 	errc := make(chan error, 1)
 	go func() {
 		defer close(errc)
+		if *fDaemon {
+			errc <- startDaemon(ctx)
+			return
+		}
 		errc <- startServer(ctx)
 	}()
 
@@ -111,6 +122,41 @@ func signalHandler(ch <-chan os.Signal, sighupFn, sigintFn func()) {
 	}
 }
 
+// register registers the nvim-go commands and autocmds to p with the new client state.
+// The cache is shared with the other clients of the process.
+func register(ctx context.Context, p *plugin.Plugin, cache *command.Cache) error {
+	bctxt := buildctxt.NewContext()
+	cmd := command.Register(ctx, p, bctxt, cache)
+	autocmd.Register(ctx, p, bctxt, cmd)
+
+	return nil
+}
+
+func startDaemon(ctx context.Context) error {
+	env := config.Process()
+
+	var lv zapcore.Level
+	if err := lv.UnmarshalText([]byte(env.LogLevel)); err != nil {
+		return fmt.Errorf("failed to parse log level: %s, err: %v", env.LogLevel, err)
+	}
+	log, undo := logger.NewRedirectZapLogger(lv)
+	defer undo()
+	ctx = logger.NewContext(ctx, log)
+
+	// the caches are created once, and shared by all clients of the daemon
+	cache := command.NewCache()
+	d, err := daemon.Listen(*socketPath, func(ctx context.Context, p *plugin.Plugin) error {
+		return register(ctx, p, cache)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to listen daemon")
+	}
+	defer d.Close()
+
+	log.Info(fmt.Sprintf("starting %s daemon", nctx.AppName), zap.Object("env", env))
+	return d.Serve(ctx)
+}
+
 func startServer(ctx context.Context) (errs error) {
 	env := config.Process()
 
@@ -127,9 +173,7 @@ func startServer(ctx context.Context) (errs error) {
 			log := logger.FromContext(ctx).Named("main")
 			ctx = logger.NewContext(ctx, log)
 
-			bctxt := buildctxt.NewContext()
-			cmd := command.Register(ctx, p, bctxt)
-			autocmd.Register(ctx, p, bctxt, cmd)
+			register(ctx, p, command.NewCache())
 
 			// switch to unix socket rpc-connection
 			if n, err := server.Dial(ctx); err == nil {
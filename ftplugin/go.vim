@@ -20,4 +20,14 @@ setlocal noexpandtab
 
 compiler go
 
+" show the documentation of the identifier under the cursor in the floating window by K
+if get(g:, 'go#doc#keywordprg', 1)
+  nnoremap <buffer><silent> K :<C-u>GoDoc<CR>
+endif
+
+" complete the identifiers by the type information with CTRL-X CTRL-O
+if get(g:, 'go#complete#omnifunc', 1)
+  setlocal omnifunc=GoComplete
+endif
+
 " vim: sw=2 ts=2 et
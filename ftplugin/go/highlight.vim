@@ -10,3 +10,16 @@ highlight delveCallStackSign   guifg=#394d39  guibg=None
 highlight GoCoverMiss          guifg=#ff9999 guibg=None gui=None
 highlight GoCoverPartial       guifg=#fafd9b guibg=None gui=None
 highlight GoCoverHit           guifg=#acedab guibg=None gui=None
+
+highlight default link GoSemanticPackage    Include
+highlight default link GoSemanticType       Type
+highlight default link GoSemanticInterface  Structure
+highlight default link GoSemanticField      Identifier
+highlight default link GoSemanticMethod     Function
+highlight default link GoSemanticParameter  Special
+highlight default link GoSemanticConstant   Constant
+highlight default link GoSemanticBuiltin    Keyword
+highlight default link GoSemanticShadowed   WarningMsg
+highlight default link GoSemanticUnused     Comment
+
+highlight default link GoSignatureActiveParameter Search
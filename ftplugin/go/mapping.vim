@@ -1,6 +1,9 @@
 " GoBuild
 nnoremap <silent><Plug>(nvim-go-build)                 :<C-u>GoBuild<CR>
 
+" GoDoc
+nnoremap <silent><Plug>(nvim-go-doc)                   :<C-u>GoDoc<CR>
+
 " GoGenerate
 nnoremap <silent><Plug>(nvim-go-generatetest)          :<C-u>GoGenerateTest<CR>
 
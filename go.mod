@@ -5,6 +5,7 @@ go 1.15
 require (
 	cloud.google.com/go v0.72.0
 	contrib.go.opencensus.io/exporter/stackdriver v0.13.4
+	github.com/BurntSushi/toml v0.3.1
 	github.com/cweill/gotests v1.5.4-0.20200413045357-2435ae532b97
 	github.com/davecgh/go-spew v1.1.1
 	github.com/fatih/color v1.9.0 // indirect
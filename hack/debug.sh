@@ -5,8 +5,11 @@ if [ -z "$NVIM_GO_LOG_FILE" ]; then
 fi
 touch "$NVIM_GO_LOG_FILE"
 
-if [ -n "$NVIM_GO_RACE" ] && [ -f "$1/bin/nvim-go-race" ]; then
-  exec "$1/bin/nvim-go-race" 2>> "$NVIM_GO_LOG_FILE"
+root="$1"
+shift
+
+if [ -n "$NVIM_GO_RACE" ] && [ -f "$root/bin/nvim-go-race" ]; then
+  exec "$root/bin/nvim-go-race" "$@" 2>> "$NVIM_GO_LOG_FILE"
 else
-  exec "$1/bin/nvim-go" 2>> "$NVIM_GO_LOG_FILE"
+  exec "$root/bin/nvim-go" "$@" 2>> "$NVIM_GO_LOG_FILE"
 fi
@@ -6,13 +6,13 @@ package autocmd
 
 import (
 	"context"
-	"sync"
 
 	"go.uber.org/zap"
 
 	"github.com/zchee/nvim-go/pkg/config"
 	"github.com/zchee/nvim-go/pkg/logger"
 	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
 )
 
 // bufEnterEval represents the current buffer number, windows ID and buffer files directory.
@@ -20,25 +20,37 @@ type bufEnterEval struct {
 	BufNr int    `eval:"bufnr('%')"`
 	WinID int    `eval:"win_getid()"`
 	Dir   string `eval:"expand('%:p:h')"`
+	File  string `eval:"expand('%:p')"`
 
 	Cfg *config.Config
 }
 
-var configOnce sync.Once
-
 // BufEnter gets the current buffer number, windows ID and set context from the directory structure on BufEnter autocmd.
 func (a *Autocmd) BufEnter(pctx context.Context, eval *bufEnterEval) {
 	ctx, span := monitoring.StartSpan(pctx, "BufEnter")
 	defer span.End()
 
-	configOnce.Do(func() {
-		eval.Cfg.Global.ChannelID = a.Nvim.ChannelID()
-		config.Get(a.Nvim, eval.Cfg)
-		logger.FromContext(ctx).Debug("BufEnter", zap.Any("eval.Config", eval.Cfg))
-	})
-
 	a.getStatus(ctx, eval.BufNr, eval.WinID, eval.Dir)
 	if eval.Dir != "" && a.buildContext.PrevDir != eval.Dir {
-		a.buildContext.SetContext(eval.Dir)
+		// re-resolve the config per project before SetContext because the build context depends on it
+		cfg := a.resolveConfig(ctx, eval.Cfg, eval.Dir)
+		a.buildContext.SetContext(cfg, eval.Dir)
+	}
+
+	if config.FromContext(ctx).Highlight.Semantic && eval.File != "" {
+		if err := a.cmd.SemanticAttach(ctx, eval.BufNr, eval.File); err != nil {
+			nvimutil.ErrorWrap(ctx, a.Nvim, err)
+		}
 	}
 }
+
+// resolveConfig resolves the effective config of dir from the g: variables and the project config file.
+func (a *Autocmd) resolveConfig(ctx context.Context, gcfg *config.Config, dir string) *config.Config {
+	cfg, err := config.Reload(ctx, a.Nvim, gcfg, dir)
+	if err != nil {
+		nvimutil.ErrorWrap(ctx, a.Nvim, err)
+	}
+	logger.FromContext(ctx).Debug("BufEnter", zap.String("dir", dir), zap.Any("config", cfg))
+
+	return cfg
+}
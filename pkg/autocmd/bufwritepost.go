@@ -9,9 +9,11 @@ import (
 	"path/filepath"
 
 	"github.com/neovim/go-client/nvim"
+	"go.uber.org/zap"
 
 	"github.com/zchee/nvim-go/pkg/command"
 	"github.com/zchee/nvim-go/pkg/config"
+	"github.com/zchee/nvim-go/pkg/logger"
 	"github.com/zchee/nvim-go/pkg/monitoring"
 	"github.com/zchee/nvim-go/pkg/nvimutil"
 )
@@ -26,36 +28,45 @@ func (a *Autocmd) BufWritePost(pctx context.Context, eval *bufWritePostEval) err
 	ctx, span := monitoring.StartSpan(pctx, "BufWritePost")
 	defer span.End()
 
+	// resolve the config of the written file because the last entered buffer might belong to the other project
+	ctx, err := config.SnapshotFile(ctx, eval.File)
+	if err != nil {
+		logger.FromContext(ctx).Warn("could not resolve the project config", zap.String("file", eval.File), zap.Error(err))
+	}
+	cfg := config.FromContext(ctx)
+
 	dir := filepath.Dir(eval.File)
 
-	if config.FmtAutosave {
+	a.cmd.RefreshIndexes(ctx, eval.File)
+
+	if cfg.Fmt.Autosave {
 		err := <-a.bufWritePreChan
 		switch e := err.(type) {
 		case error:
-			return nvimutil.ErrorWrap(a.Nvim, e)
+			return nvimutil.ErrorWrap(ctx, a.Nvim, e)
 		case []*nvim.QuickfixError:
 			errlist := make(map[string][]*nvim.QuickfixError)
 			errlist["Fmt"] = e
-			return nvimutil.ErrorList(a.Nvim, errlist, true)
+			return nvimutil.ErrorList(ctx, a.Nvim, errlist, true)
 		}
 	}
 
-	if config.BuildAutosave {
-		err := a.cmd.Build(ctx, nil, config.BuildForce, &command.CmdBuildEval{
+	if cfg.Build.Autosave {
+		err := a.cmd.Build(ctx, nil, cfg.Build.Force, &command.CmdBuildEval{
 			Cwd:  eval.Cwd,
 			File: eval.File,
 		})
 		switch e := err.(type) {
 		case error:
-			return nvimutil.ErrorWrap(a.Nvim, e)
+			return nvimutil.ErrorWrap(ctx, a.Nvim, e)
 		case []*nvim.QuickfixError:
 			errlist := make(map[string][]*nvim.QuickfixError)
 			errlist["Build"] = e
-			return nvimutil.ErrorList(a.Nvim, errlist, true)
+			return nvimutil.ErrorList(ctx, a.Nvim, errlist, true)
 		}
 	}
 
-	if config.GolintAutosave {
+	if cfg.Lint.GolintAutosave {
 		a.wg.Add(1)
 		go func() {
 			defer a.wg.Done()
@@ -64,16 +75,16 @@ func (a *Autocmd) BufWritePost(pctx context.Context, eval *bufWritePostEval) err
 			err := a.cmd.Lint(ctx, nil, eval.File)
 			switch e := err.(type) {
 			case error:
-				nvimutil.ErrorWrap(a.Nvim, e)
+				nvimutil.ErrorWrap(ctx, a.Nvim, e)
 			case []*nvim.QuickfixError:
 				errlist := make(map[string][]*nvim.QuickfixError)
 				errlist["Lint"] = e
-				nvimutil.ErrorList(a.Nvim, errlist, true)
+				nvimutil.ErrorList(ctx, a.Nvim, errlist, true)
 			}
 		}()
 	}
 
-	if config.GoVetAutosave {
+	if cfg.Lint.GoVetAutosave {
 		a.wg.Add(1)
 		a.mu.Lock()
 		go func() {
@@ -89,14 +100,14 @@ func (a *Autocmd) BufWritePost(pctx context.Context, eval *bufWritePostEval) err
 			})
 			switch e := err.(type) {
 			case error:
-				nvimutil.ErrorWrap(a.Nvim, e)
+				nvimutil.ErrorWrap(ctx, a.Nvim, e)
 			case []*nvim.QuickfixError:
 				a.errs.Store("Vet", e)
 			}
 		}()
 	}
 
-	if config.MetalinterAutosave {
+	if cfg.Lint.MetalinterAutosave {
 		a.wg.Add(1)
 		go func() {
 			defer a.wg.Done()
@@ -105,13 +116,13 @@ func (a *Autocmd) BufWritePost(pctx context.Context, eval *bufWritePostEval) err
 			err := a.cmd.Metalinter(ctx, eval.Cwd)
 			switch e := err.(type) {
 			case error:
-				nvimutil.ErrorWrap(a.Nvim, e)
+				nvimutil.ErrorWrap(ctx, a.Nvim, e)
 			case nil:
 			}
 		}()
 	}
 
-	if config.TestAutosave {
+	if cfg.Test.Autosave {
 		a.wg.Add(1)
 		go func() {
 			defer a.wg.Done()
@@ -120,7 +131,7 @@ func (a *Autocmd) BufWritePost(pctx context.Context, eval *bufWritePostEval) err
 			err := a.cmd.Test(ctx, nil, dir)
 			switch e := err.(type) {
 			case error:
-				nvimutil.ErrorWrap(a.Nvim, e)
+				nvimutil.ErrorWrap(ctx, a.Nvim, e)
 			case nil:
 			}
 		}()
@@ -135,8 +146,8 @@ func (a *Autocmd) BufWritePost(pctx context.Context, eval *bufWritePostEval) err
 	})
 
 	if len(errlist) > 0 {
-		return nvimutil.ErrorList(a.Nvim, errlist, true)
+		return nvimutil.ErrorList(ctx, a.Nvim, errlist, true)
 	}
 
-	return nvimutil.ClearErrorlist(a.Nvim, true)
+	return nvimutil.ClearErrorlist(ctx, a.Nvim, true)
 }
@@ -8,7 +8,10 @@ import (
 	"context"
 	"path/filepath"
 
+	"go.uber.org/zap"
+
 	"github.com/zchee/nvim-go/pkg/config"
+	"github.com/zchee/nvim-go/pkg/logger"
 	"github.com/zchee/nvim-go/pkg/monitoring"
 )
 
@@ -22,18 +25,25 @@ func (a *Autocmd) BufWritePre(pctx context.Context, eval *bufWritePreEval) {
 	ctx, span := monitoring.StartSpan(pctx, "BufWritePre")
 	defer span.End()
 
+	// resolve the config of the written file because the last entered buffer might belong to the other project
+	ctx, err := config.SnapshotFile(ctx, eval.File)
+	if err != nil {
+		logger.FromContext(ctx).Warn("could not resolve the project config", zap.String("file", eval.File), zap.Error(err))
+	}
+	cfg := config.FromContext(ctx)
+
 	dir := filepath.Dir(eval.File)
 
 	// Iferr need execute before Fmt function because that function calls "noautocmd write"
 	// Also do not use goroutine.
-	if config.IferrAutosave {
-		err := a.cmd.Iferr(ctx, eval.File)
+	if cfg.Iferr.Autosave {
+		err := a.cmd.IferrAll(ctx, eval.File)
 		if err != nil {
 			return
 		}
 	}
 
-	if config.FmtAutosave {
+	if cfg.Fmt.Autosave {
 		go func() {
 			a.bufWritePreChan <- a.cmd.Fmt(ctx, dir)
 		}()
@@ -0,0 +1,28 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package autocmd
+
+import (
+	"context"
+
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// completeDoneEval represents the current buffer number and the user_data of the completed item.
+type completeDoneEval struct {
+	BufNr    int         `eval:"bufnr('%')"`
+	UserData interface{} `eval:"get(v:completed_item, 'user_data', '')"`
+}
+
+// CompleteDone adds the import of the completed package by the GoComplete on CompleteDone autocmd.
+func (a *Autocmd) CompleteDone(pctx context.Context, eval *completeDoneEval) {
+	ctx, span := monitoring.StartSpan(pctx, "CompleteDone")
+	defer span.End()
+
+	if err := a.cmd.CompleteDone(ctx, eval.BufNr, eval.UserData); err != nil {
+		nvimutil.ErrorWrap(ctx, a.Nvim, err)
+	}
+}
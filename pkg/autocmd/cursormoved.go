@@ -0,0 +1,28 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package autocmd
+
+import (
+	"context"
+
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// cursorMovedEval represents the current buffer number and the cursor line.
+type cursorMovedEval struct {
+	BufNr int `eval:"bufnr('%')"`
+	Line  int `eval:"line('.')"`
+}
+
+// CursorMoved highlights the declaration which encloses the cursor in the GoOutline on CursorMoved autocmd.
+func (a *Autocmd) CursorMoved(pctx context.Context, eval *cursorMovedEval) {
+	ctx, span := monitoring.StartSpan(pctx, "CursorMoved")
+	defer span.End()
+
+	if err := a.cmd.OutlineFollow(ctx, eval.BufNr, eval.Line); err != nil {
+		nvimutil.ErrorWrap(ctx, a.Nvim, err)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package autocmd
+
+import (
+	"context"
+
+	"github.com/zchee/nvim-go/pkg/config"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// cursorMovedIEval represents the current buffer number, its file name and the cursor position.
+type cursorMovedIEval struct {
+	BufNr int    `eval:"bufnr('%')"`
+	File  string `eval:"expand('%:p')"`
+	Line  int    `eval:"line('.')"`
+	Col   int    `eval:"col('.')"`
+}
+
+// CursorMovedI shows the signature help of the call at the cursor on CursorMovedI and TextChangedI autocmd.
+func (a *Autocmd) CursorMovedI(pctx context.Context, eval *cursorMovedIEval) {
+	ctx, span := monitoring.StartSpan(pctx, "CursorMovedI")
+	defer span.End()
+
+	if !config.FromContext(ctx).Signature.Enable {
+		return
+	}
+	if err := a.cmd.SignatureHelp(ctx, eval.BufNr, eval.File, eval.Line, eval.Col); err != nil {
+		nvimutil.ErrorWrap(ctx, a.Nvim, err)
+	}
+}
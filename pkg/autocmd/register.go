@@ -12,6 +12,7 @@ import (
 
 	"github.com/zchee/nvim-go/pkg/buildctxt"
 	"github.com/zchee/nvim-go/pkg/command"
+	"github.com/zchee/nvim-go/pkg/config"
 	"github.com/zchee/nvim-go/pkg/logger"
 )
 
@@ -57,13 +58,35 @@ func Register(ctx context.Context, p *plugin.Plugin, buildContext *buildctxt.Con
 	// Handle the before the write to file.
 	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "BufWritePre", Pattern: "*.go", Group: "nvim-go", Eval: "*"},
 		func(eval *bufWritePreEval) {
-			autocmd.BufWritePre(ctx, eval)
+			autocmd.BufWritePre(config.Snapshot(ctx), eval)
 		})
 
 	// Handle the after the write to file.
 	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "BufWritePost", Pattern: "*.go", Group: "nvim-go", Eval: "*"},
 		func(eval *bufWritePostEval) {
-			autocmd.BufWritePost(ctx, eval)
+			autocmd.BufWritePost(config.Snapshot(ctx), eval)
+		})
+
+	// Handle the change of the buffer text and the cursor, which updates the GoOutline buffer.
+	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "TextChanged,InsertLeave", Pattern: "*.go", Group: "nvim-go", Eval: "*"},
+		func(eval *textChangedEval) {
+			autocmd.TextChanged(ctx, eval)
+		})
+	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "CursorMoved", Pattern: "*.go", Group: "nvim-go", Eval: "*"},
+		func(eval *cursorMovedEval) {
+			autocmd.CursorMoved(ctx, eval)
+		})
+
+	// Handle the typing in the insert mode, which shows the signature help of the call.
+	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "CursorMovedI,TextChangedI", Pattern: "*.go", Group: "nvim-go", Eval: "*"},
+		func(eval *cursorMovedIEval) {
+			autocmd.CursorMovedI(config.Snapshot(ctx), eval)
+		})
+
+	// Handle the end of the GoComplete completion, which adds the import of the completed package.
+	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "CompleteDone", Pattern: "*.go", Group: "nvim-go", Eval: "*"},
+		func(eval *completeDoneEval) {
+			autocmd.CompleteDone(ctx, eval)
 		})
 
 	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "VimLeavePre", Pattern: "*.go", Group: "nvim-go"},
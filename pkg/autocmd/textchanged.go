@@ -0,0 +1,28 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package autocmd
+
+import (
+	"context"
+
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// textChangedEval represents the changed buffer number and its file name.
+type textChangedEval struct {
+	BufNr int    `eval:"bufnr('%')"`
+	File  string `eval:"expand('%:p')"`
+}
+
+// TextChanged refreshes the GoOutline of the changed buffer on TextChanged and InsertLeave autocmd.
+func (a *Autocmd) TextChanged(pctx context.Context, eval *textChangedEval) {
+	ctx, span := monitoring.StartSpan(pctx, "TextChanged")
+	defer span.End()
+
+	if err := a.cmd.OutlineRefresh(ctx, eval.BufNr, eval.File); err != nil {
+		nvimutil.ErrorWrap(ctx, a.Nvim, err)
+	}
+}
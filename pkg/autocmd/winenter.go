@@ -7,6 +7,7 @@ package autocmd
 import (
 	"context"
 
+	"github.com/zchee/nvim-go/pkg/config"
 	"github.com/zchee/nvim-go/pkg/monitoring"
 )
 
@@ -23,7 +24,7 @@ func (a *Autocmd) WinEnter(pctx context.Context, eval *winEnterEval) error {
 
 	a.getStatus(ctx, eval.BufNr, eval.WinID, eval.Dir)
 	if eval.Dir != "" && a.buildContext.PrevDir != eval.Dir {
-		a.buildContext.SetContext(eval.Dir)
+		a.buildContext.SetContext(config.FromContext(ctx), eval.Dir)
 	}
 
 	return nil
@@ -26,6 +26,9 @@ type Context struct {
 	PrevDir string // for cache
 	m       sync.Mutex
 
+	// ctxt the go/build context of the current project, which is owned by the client and never shared with the others.
+	ctxt build.Context
+
 	Buffer
 	Build
 }
@@ -54,11 +57,12 @@ type Build struct {
 func NewContext() *Context {
 	return &Context{
 		Errlist: make(map[string][]*nvim.QuickfixError),
+		ctxt:    build.Default,
 	}
 }
 
 // buildContext return the new build context estimated from the path p directory structure.
-func (ctx *Context) buildContext(dir string, defaultContext build.Context) (string, string, build.Context) {
+func (ctx *Context) buildContext(cfg *config.Config, dir string, defaultContext build.Context) (string, string, build.Context) {
 	// copy context
 	buildContext := defaultContext
 
@@ -67,7 +71,7 @@ func (ctx *Context) buildContext(dir string, defaultContext build.Context) (stri
 	// Assign package directory full path from dir
 	projectRoot, _ := fs.PackagePath(dir)
 
-	if config.BuildIsNotGb {
+	if cfg.Build.IsNotGb {
 		return tool, fs.FindVCSRoot(projectRoot), buildContext
 	}
 
@@ -77,7 +81,7 @@ func (ctx *Context) buildContext(dir string, defaultContext build.Context) (stri
 		tool = "gb"
 		projectRoot = gbpath
 		buildContext.GOPATH = gbpath + string(filepath.ListSeparator) + filepath.Join(gbpath, "vendor")
-		if config.BuildAppengine {
+		if cfg.Build.Appengine {
 			buildContext.GOROOT = goappEnv("GOROOT")
 		}
 	}
@@ -95,17 +99,48 @@ func goappEnv(env string) string {
 	return strings.TrimSpace(string(out))
 }
 
-// SetContext sets the Tool, ProjectRoot, go/build.Default and $GOPATH to buildContext.
-// This function initializes for functions that use go/build.Default.
-func (ctx *Context) SetContext(dir string) {
+// SetContext sets the Tool, ProjectRoot and the go/build context of the dir to ctx.
+// SetContext does not modify the go/build.Default and the environment variables, because they are shared with the other clients.
+// The cfg is the config snapshot of the dir.
+func (ctx *Context) SetContext(cfg *config.Config, dir string) {
 	ctx.m.Lock()
 	defer ctx.m.Unlock()
 
-	ctx.Build.Tool, ctx.Build.ProjectRoot, build.Default = ctx.buildContext(dir, build.Default)
+	ctx.Build.Tool, ctx.Build.ProjectRoot, ctx.ctxt = ctx.buildContext(cfg, dir, build.Default)
 	if ctx.Build.Tool == "gb" {
-		build.Default.JoinPath = ctx.Build.GbJoinPath
+		b := ctx.Build // JoinPath must not see the later SetContext
+		ctx.ctxt.JoinPath = b.GbJoinPath
 	}
 	ctx.PrevDir = dir
+}
+
+// BuildContext returns a copy of the go/build context of the current project.
+// The functions which load or walk the packages should use it instead of go/build.Default.
+func (ctx *Context) BuildContext() *build.Context {
+	ctx.m.Lock()
+	defer ctx.m.Unlock()
+
+	ctxt := ctx.ctxt
+	return &ctxt
+}
+
+// Env returns the environment variables in the form "key=value" which override the nvim-go environment
+// for the external commands of the current project.
+// The GOPATH and GOROOT are overridden only if the current project uses the other values than go/build.Default, such as gb.
+func (ctx *Context) Env() []string {
+	ctxt := ctx.BuildContext()
+
+	var env []string
+	if ctxt.GOPATH != build.Default.GOPATH {
+		env = append(env, "GOPATH="+ctxt.GOPATH)
+	}
+	if ctxt.GOROOT != build.Default.GOROOT {
+		env = append(env, "GOROOT="+ctxt.GOROOT)
+	}
+	return env
+}
 
-	os.Setenv("GOPATH", build.Default.GOPATH)
+// Environ returns the environment variables of the external commands which run in the current project.
+func (ctx *Context) Environ() []string {
+	return append(os.Environ(), ctx.Env()...)
 }
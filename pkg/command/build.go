@@ -43,7 +43,7 @@ func (c *Command) cmdBuild(ctx context.Context, args []string, bang bool, eval *
 	case err := <-errch:
 		switch e := err.(type) {
 		case error:
-			nvimutil.ErrorWrap(c.Nvim, e)
+			nvimutil.ErrorWrap(ctx, c.Nvim, e)
 		case []*nvim.QuickfixError:
 			c.errs.Store("Build", e)
 			errlist := make(map[string][]*nvim.QuickfixError)
@@ -52,7 +52,7 @@ func (c *Command) cmdBuild(ctx context.Context, args []string, bang bool, eval *
 				errlist[k] = append(errlist[k], v...)
 				return true
 			})
-			nvimutil.ErrorList(c.Nvim, errlist, true)
+			nvimutil.ErrorList(ctx, c.Nvim, errlist, true)
 		case nil:
 			// nothing to do
 		}
@@ -65,9 +65,11 @@ func (c *Command) Build(pctx context.Context, args []string, bang bool, eval *Cm
 	ctx, span := monitoring.StartSpan(pctx, "Build")
 	defer span.End()
 
+	cfg := config.FromContext(ctx)
+
 	log := logger.FromContext(ctx).With(zap.Strings("args", args), zap.Bool("bang", bang), zap.Any("CmdBuildEval", eval))
 	if !bang {
-		bang = config.BuildForce
+		bang = cfg.Build.Force
 	}
 
 	cmd, err := c.compileCmd(ctx, args, bang, eval.Cwd)
@@ -106,15 +108,17 @@ func (c *Command) compileCmd(pctx context.Context, args []string, bang bool, dir
 	ctx, span := monitoring.StartSpan(pctx, "compileCmd")
 	defer span.End()
 
+	cfg := config.FromContext(ctx)
+
 	bin, err := exec.LookPath(c.buildContext.Build.Tool)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 	cmd := exec.CommandContext(ctx, bin, "build")
-	cmd.Env = os.Environ()
+	cmd.Env = c.buildContext.Environ()
 
-	if len(config.BuildFlags) > 0 {
-		args = append(args, config.BuildFlags...)
+	if len(cfg.Build.Flags) > 0 {
+		args = append(args, cfg.Build.Flags...)
 	}
 	switch c.buildContext.Build.Tool {
 	case "go":
@@ -130,13 +134,13 @@ func (c *Command) compileCmd(pctx context.Context, args []string, bang bool, dir
 		}
 
 		// add "app" suffix to binary name if enable app-engine build
-		if config.BuildAppengine {
+		if cfg.Build.Appengine {
 			cmd.Args[0] += "app"
 		}
 	case "gb":
 		cmd.Dir = c.buildContext.Build.ProjectRoot
 
-		if config.BuildAppengine {
+		if cfg.Build.Appengine {
 			cmd.Args = append([]string{cmd.Args[0], "gae"}, cmd.Args[1:]...)
 			pkgs, err := fs.GbPackages(cmd.Dir)
 			if err != nil {
@@ -133,7 +133,7 @@ func TestCommand_Build(t *testing.T) {
 
 			ctx := tt.fields.ctx
 
-			c := NewCommand(ctx, tt.fields.Nvim, tt.fields.bctxt)
+			c := NewCommand(ctx, tt.fields.Nvim, tt.fields.bctxt, NewCache())
 			err := c.Build(ctx, tt.args.args, tt.args.bang, tt.args.eval)
 			switch e := err.(type) {
 			case error:
@@ -152,7 +152,7 @@ func TestCommand_Build(t *testing.T) {
 func BenchmarkBuildGo(b *testing.B) {
 	ctx := testutil.TestContext(b, context.Background())
 	bctxt := buildctxt.NewContext()
-	c := NewCommand(ctx, benchVim(b, astdumpMain), bctxt)
+	c := NewCommand(ctx, benchVim(b, astdumpMain), bctxt, NewCache())
 
 	for i := 0; i < b.N; i++ {
 		c.Build(ctx, nil, false, &CmdBuildEval{
@@ -168,7 +168,7 @@ func BenchmarkBuildGo(b *testing.B) {
 func BenchmarkBuildGb(b *testing.B) {
 	ctx := testutil.TestContext(b, context.Background())
 	bctxt := buildctxt.NewContext()
-	c := NewCommand(ctx, benchVim(b, gsftpMain), bctxt)
+	c := NewCommand(ctx, benchVim(b, gsftpMain), bctxt, NewCache())
 
 	for i := 0; i < b.N; i++ {
 		c.Build(ctx, nil, false, &CmdBuildEval{
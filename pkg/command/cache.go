@@ -0,0 +1,126 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/zchee/nvim-go/pkg/fs"
+	"github.com/zchee/nvim-go/pkg/internal/pkgindex"
+)
+
+// Cache represents the caches which are shared by the Commands of all Neovim clients in the process.
+// The daemon creates one Cache and hands it to the Command of each client, so that the clients share the warm caches.
+type Cache struct {
+	checker typeChecker // the type checker of the buffer contents which caches the imported packages
+
+	symbols sync.Map // map[symbolsKey]*symbolsIndex: the symbols of the GoSymbols workspace
+	modDeps sync.Map // map[string]moduleDeps: the dependency modules for each module root
+
+	pkgIndex        *pkgindex.Index // the exported symbols index of the packages in GOROOT, GOPATH and the module cache
+	pkgIndexLoad    sync.Once       // loads the pkgIndex from the disk once per process
+	pkgIndexRefresh refreshPolicy   // decides when the roots of the pkgIndex are refreshed
+}
+
+// NewCache returns the new Cache.
+func NewCache() *Cache {
+	return &Cache{
+		pkgIndex: pkgindex.New(pkgindex.DefaultFile()),
+	}
+}
+
+// moduleDeps represents the dependency modules of a Go module at the moduleStamp of it.
+type moduleDeps struct {
+	stamp string
+	mods  []fs.Module
+}
+
+// moduleDependencies returns the dependency modules of the Go module root.
+// The modules are listed by the go command, so they are cached until the go.mod or go.sum file is changed.
+func (c *Cache) moduleDependencies(root string) []fs.Module {
+	stamp := moduleStamp(root)
+	if v, ok := c.modDeps.Load(root); ok && v.(moduleDeps).stamp == stamp {
+		return v.(moduleDeps).mods
+	}
+
+	mods, _ := fs.ModuleDependencies(root)
+	c.modDeps.Store(root, moduleDeps{stamp: stamp, mods: mods})
+	return mods
+}
+
+// refreshInterval is the minimum interval of refreshing the same root directory of the indexes
+// unless the root is invalidated.
+const refreshInterval = time.Minute
+
+// refreshPolicy decides when the root directories of an index are refreshed.
+// A root is refreshed if it has never been refreshed, a Go file under it has been saved,
+// the go.mod or go.sum file of the current module has changed, or refreshInterval has passed since the last refresh.
+type refreshPolicy struct {
+	mu   sync.Mutex
+	last map[refreshKey]refreshStamp // the last refresh of the root directory for each Go module
+}
+
+// refreshKey represents the root directory of an index which is refreshed for the Go module.
+type refreshKey struct {
+	root    string
+	modRoot string
+}
+
+// refreshStamp represents the time and the module state of the last refresh.
+type refreshStamp struct {
+	time   time.Time
+	module string
+}
+
+// stale reports whether the root must be refreshed for the Go module modRoot, and records the refresh if so.
+// modRoot is empty if not in a Go module.
+func (p *refreshPolicy) stale(root, modRoot string) bool {
+	now := time.Now()
+	stamp := refreshStamp{time: now, module: moduleStamp(modRoot)}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := refreshKey{root: root, modRoot: modRoot}
+	if last, ok := p.last[key]; ok && last.module == stamp.module && now.Sub(last.time) < refreshInterval {
+		return false
+	}
+	if p.last == nil {
+		p.last = make(map[refreshKey]refreshStamp)
+	}
+	p.last[key] = stamp
+	return true
+}
+
+// invalidate invalidates the roots which contain the file.
+func (p *refreshPolicy) invalidate(file string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key := range p.last {
+		if isInDir(key.root, file) {
+			delete(p.last, key)
+		}
+	}
+}
+
+// moduleStamp returns the modification times of the go.mod and go.sum files of the Go module root,
+// which changes when the dependencies are changed.
+func moduleStamp(root string) string {
+	if root == "" {
+		return ""
+	}
+	var stamp string
+	for _, name := range []string{"go.mod", "go.sum"} {
+		var modTime int64
+		if fi, err := os.Stat(filepath.Join(root, name)); err == nil {
+			modTime = fi.ModTime().UnixNano()
+		}
+		stamp += fmt.Sprintf("%s:%d ", name, modTime)
+	}
+	return stamp
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/zchee/nvim-go/pkg/fs"
+	"github.com/zchee/nvim-go/pkg/internal/testutil"
+)
+
+func TestRefreshPolicy(t *testing.T) {
+	modRoot := testutil.TempModule(t, "example.com/foo", nil)
+	gomod := filepath.Join(modRoot, "go.mod")
+
+	var p refreshPolicy
+	if !p.stale(modRoot, modRoot) {
+		t.Fatal("stale() = false, want true for the first refresh")
+	}
+	if p.stale(modRoot, modRoot) {
+		t.Fatal("stale() = true, want false right after the refresh")
+	}
+
+	// the saved file invalidates the root which contains it
+	p.invalidate(filepath.Join(modRoot, "foo", "foo.go"))
+	if !p.stale(modRoot, modRoot) {
+		t.Fatal("stale() = false, want true after the root is invalidated")
+	}
+	p.invalidate(filepath.Join(filepath.Dir(modRoot), "bar", "bar.go"))
+	if p.stale(modRoot, modRoot) {
+		t.Fatal("stale() = true, want false after the other root is invalidated")
+	}
+
+	// the changed dependencies invalidate the root
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(gomod, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if !p.stale(modRoot, modRoot) {
+		t.Fatal("stale() = false, want true after go.mod is changed")
+	}
+
+	// the root expires after refreshInterval
+	key := refreshKey{root: modRoot, modRoot: modRoot}
+	last := p.last[key]
+	last.time = last.time.Add(-refreshInterval)
+	p.last[key] = last
+	if !p.stale(modRoot, modRoot) {
+		t.Fatal("stale() = false, want true after refreshInterval")
+	}
+}
+
+func TestCache_moduleDependencies(t *testing.T) {
+	root := testutil.TempModule(t, "example.com/foo", nil)
+	gomod := filepath.Join(root, "go.mod")
+
+	c := NewCache()
+	cached := []fs.Module{{Path: "example.com/bar", Dir: "/gopath/pkg/mod/example.com/bar@v1.0.0"}}
+	c.modDeps.Store(root, moduleDeps{stamp: moduleStamp(root), mods: cached})
+	if diff := cmp.Diff(cached, c.moduleDependencies(root)); diff != "" {
+		t.Fatalf("cached modules: (-want +got)\n%s", diff)
+	}
+
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(gomod, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if mods := c.moduleDependencies(root); len(mods) != 0 {
+		t.Fatalf("moduleDependencies() = %v after go.mod is changed, want no modules", mods)
+	}
+}
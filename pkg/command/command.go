@@ -11,6 +11,7 @@ import (
 	"github.com/neovim/go-client/nvim"
 
 	"github.com/zchee/nvim-go/pkg/buildctxt"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
 )
 
 // Command represents a nvim-go plugins commands.
@@ -19,13 +20,31 @@ type Command struct {
 	buildContext *buildctxt.Context
 	errs         *sync.Map
 	namespaceID  int
+
+	mu            sync.Mutex
+	renamePreview *renamePreview     // pending renaming of the GoRename preview
+	outline       *outline           // the opened GoOutline buffer
+	doc           *docFloat          // the opened GoDoc floating window
+	signature     *signatureFloat    // the opened floating window of the signature help
+	picker        *picker            // the opened picker buffer of GoSymbols, GoImplementations and GoInterfaces
+	runTerm       *nvimutil.Terminal // the terminal of GoRun
+	runLastArgs   []string           // the last arguments of GoRun
+	testTerm      *nvimutil.Terminal // the terminal of GoTest
+
+	semantic *semantic // the semantic highlighting of the attached buffers
+	docPages sync.Map  // map[string]*docBrowse: the documentation of each GoDocBrowse buffer name
+
+	*Cache // the caches shared with the Commands of the other clients
 }
 
 // NewCommand return the new Command type with initialize some variables.
-func NewCommand(ctx context.Context, v *nvim.Nvim, bctxt *buildctxt.Context) *Command {
+// The cache is shared with the other Commands of the process.
+func NewCommand(ctx context.Context, v *nvim.Nvim, bctxt *buildctxt.Context, cache *Cache) *Command {
 	return &Command{
 		Nvim:         v,
 		buildContext: bctxt,
 		errs:         new(sync.Map),
+		semantic:     newSemantic(),
+		Cache:        cache,
 	}
 }
@@ -0,0 +1,301 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"golang.org/x/lint"
+
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+func (c *Command) cmdComment(ctx context.Context, file string) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.Comment(ctx, file)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// Comment inserts the doc comment of the declaration under the cursor.
+func (c *Command) Comment(pctx context.Context, file string) error {
+	ctx, span := monitoring.StartSpan(pctx, "Comment")
+	defer span.End()
+
+	b := nvim.Buffer(c.buildContext.BufNr)
+	w := nvim.Window(c.buildContext.WinID)
+
+	buflines, err := c.Nvim.BufferLines(b, 0, -1, true)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	cursor, err := c.Nvim.WindowCursor(w)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nvimutil.ToByteSlice(buflines), parser.ParseComments)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	target, ok := cursorDocTarget(fset, docTargets(f), cursor[0])
+	if !ok {
+		err := errors.New("GoComment: no declaration at the cursor")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeNotFound, Message: err.Error()})
+		return err
+	}
+	if target.doc != nil {
+		err := errors.Errorf("GoComment: %s already has the doc comment", target.name)
+		span.SetStatus(trace.Status{Code: trace.StatusCodeAlreadyExists, Message: err.Error()})
+		return err
+	}
+
+	return minUpdate(ctx, c.Nvim, b, buflines, insertDocComments(fset, buflines, []docTarget{target}))
+}
+
+func (c *Command) cmdCommentMissing(ctx context.Context, file string) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.CommentMissing(ctx, file)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// CommentMissing inserts the doc comments of all exported declarations which golint reports the missing comment.
+func (c *Command) CommentMissing(pctx context.Context, file string) error {
+	ctx, span := monitoring.StartSpan(pctx, "CommentMissing")
+	defer span.End()
+
+	b := nvim.Buffer(c.buildContext.BufNr)
+	buflines, err := c.Nvim.BufferLines(b, 0, -1, true)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	out, err := commentMissingLines(file, buflines)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoCommentMissing")
+	}
+
+	return minUpdate(ctx, c.Nvim, b, buflines, out)
+}
+
+// commentMissingLines returns the buffer lines which inserted the doc comments of the declarations
+// which golint reports the missing comment.
+func commentMissingLines(file string, buflines [][]byte) ([][]byte, error) {
+	src := nvimutil.ToByteSlice(buflines)
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.ParseComments)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ps, err := new(lint.Linter).Lint(file, src)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	missing := make(map[int]bool)
+	for _, p := range ps {
+		if p.Category == "comments" && strings.Contains(p.Text, "should have") && strings.Contains(p.Text, "comment") {
+			missing[p.Position.Line] = true
+		}
+	}
+	var targets []docTarget
+	for _, target := range docTargets(f) {
+		if target.doc == nil && missing[fset.Position(target.node.Pos()).Line] {
+			targets = append(targets, target)
+		}
+	}
+
+	return insertDocComments(fset, buflines, targets), nil
+}
+
+// docTarget represents a declaration which can have the doc comment.
+type docTarget struct {
+	node ast.Node          // *ast.File, *ast.FuncDecl, *ast.TypeSpec or *ast.ValueSpec
+	name string            // the declared name
+	kind ast.ObjKind       // the kind of the declared name
+	doc  *ast.CommentGroup // the existing doc comment
+	pos  token.Pos         // the position where the doc comment is inserted
+	end  token.Pos         // the end of the declaration
+}
+
+// docTargets returns the declarations of the file which can have the doc comment.
+func docTargets(f *ast.File) []docTarget {
+	targets := []docTarget{{node: f, name: f.Name.Name, kind: ast.Pkg, doc: f.Doc, pos: f.Package, end: f.Name.End()}}
+
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			targets = append(targets, docTarget{node: decl, name: decl.Name.Name, kind: ast.Fun, doc: decl.Doc, pos: decl.Pos(), end: decl.End()})
+
+		case *ast.GenDecl:
+			if decl.Tok == token.IMPORT {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				target := docTarget{node: spec, pos: spec.Pos(), end: spec.End()}
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					target.name, target.kind, target.doc = spec.Name.Name, ast.Typ, spec.Doc
+				case *ast.ValueSpec:
+					target.name, target.kind, target.doc = spec.Names[0].Name, ast.Var, spec.Doc
+					if decl.Tok == token.CONST {
+						target.kind = ast.Con
+					}
+				}
+				if !decl.Lparen.IsValid() {
+					target.doc, target.pos, target.end = decl.Doc, decl.Pos(), decl.End()
+				}
+				targets = append(targets, target)
+			}
+		}
+	}
+
+	return targets
+}
+
+// cursorDocTarget returns the innermost declaration which contains the cursor line.
+func cursorDocTarget(fset *token.FileSet, targets []docTarget, line int) (docTarget, bool) {
+	var found docTarget
+	ok := false
+	for _, target := range targets {
+		start, end := fset.Position(target.pos).Line, fset.Position(target.end).Line
+		if start <= line && line <= end && (!ok || end-start < fset.Position(found.end).Line-fset.Position(found.pos).Line) {
+			found, ok = target, true
+		}
+	}
+	return found, ok
+}
+
+// docMethods is the method signatures of the well-known interfaces.
+var docMethods = map[string]struct{ iface, sig string }{
+	"Close":         {"io.Closer", "func() error"},
+	"Error":         {"error", "func() string"},
+	"Format":        {"fmt.Formatter", "func(fmt.State, rune)"},
+	"GoString":      {"fmt.GoStringer", "func() string"},
+	"Len":           {"sort.Interface", "func() int"},
+	"Less":          {"sort.Interface", "func(int, int) bool"},
+	"MarshalJSON":   {"json.Marshaler", "func() ([]byte, error)"},
+	"MarshalText":   {"encoding.TextMarshaler", "func() ([]byte, error)"},
+	"Read":          {"io.Reader", "func([]byte) (int, error)"},
+	"ReadFrom":      {"io.ReaderFrom", "func(io.Reader) (int64, error)"},
+	"ServeHTTP":     {"http.Handler", "func(http.ResponseWriter, *http.Request)"},
+	"String":        {"fmt.Stringer", "func() string"},
+	"Swap":          {"sort.Interface", "func(int, int)"},
+	"UnmarshalJSON": {"json.Unmarshaler", "func([]byte) error"},
+	"UnmarshalText": {"encoding.TextUnmarshaler", "func([]byte) error"},
+	"Write":         {"io.Writer", "func([]byte) (int, error)"},
+	"WriteTo":       {"io.WriterTo", "func(io.Writer) (int64, error)"},
+}
+
+// docText returns the typical doc comment text of the declaration.
+func docText(target docTarget) string {
+	switch target.kind {
+	case ast.Pkg:
+		return fmt.Sprintf("Package %s implements ...", target.name)
+	case ast.Con, ast.Var:
+		return fmt.Sprintf("%s is the ...", target.name)
+	case ast.Typ:
+		return fmt.Sprintf("%s represents a ...", target.name)
+	case ast.Fun:
+		fd := target.node.(*ast.FuncDecl)
+		if m, ok := docMethods[target.name]; ok && fd.Recv != nil && funcSignature(fd.Type) == m.sig {
+			return fmt.Sprintf("%s implements the %s interface.", target.name, m.iface)
+		}
+		return fmt.Sprintf("%s returns the ...", target.name)
+	}
+	return target.name + " ..."
+}
+
+// funcSignature returns the function type without the parameter names such as "func(int) (int, error)".
+func funcSignature(ft *ast.FuncType) string {
+	typeList := func(fl *ast.FieldList) []string {
+		var list []string
+		if fl == nil {
+			return list
+		}
+		for _, field := range fl.List {
+			n := len(field.Names)
+			if n == 0 {
+				n = 1
+			}
+			for i := 0; i < n; i++ {
+				list = append(list, types.ExprString(field.Type))
+			}
+		}
+		return list
+	}
+
+	sig := "func(" + strings.Join(typeList(ft.Params), ", ") + ")"
+	switch results := typeList(ft.Results); len(results) {
+	case 0:
+	case 1:
+		sig += " " + results[0]
+	default:
+		sig += " (" + strings.Join(results, ", ") + ")"
+	}
+	return sig
+}
+
+// insertDocComments returns the buffer lines which inserted the doc comments of the targets.
+func insertDocComments(fset *token.FileSet, buflines [][]byte, targets []docTarget) [][]byte {
+	sort.Slice(targets, func(i, j int) bool { return targets[i].pos < targets[j].pos })
+
+	out := make([][]byte, 0, len(buflines)+len(targets))
+	last, prev := 0, -1
+	for _, target := range targets {
+		line := fset.Position(target.pos).Line - 1
+		if line <= prev {
+			continue // the declarations in the same line
+		}
+		prev = line
+		out = append(out, buflines[last:line]...)
+
+		indent := buflines[line][:len(buflines[line])-len(bytes.TrimLeft(buflines[line], " \t"))]
+		out = append(out, []byte(string(indent)+"// "+docText(target)))
+		last = line
+	}
+	out = append(out, buflines[last:]...)
+
+	return out
+}
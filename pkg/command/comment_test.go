@@ -0,0 +1,192 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+const commentSrc = `package p
+
+import "fmt"
+
+const Max = 10
+
+var (
+	// Debug enables the debug log.
+	Debug bool
+	Verbose  bool
+)
+
+type T struct {
+	n int
+}
+
+func NewT() *T { return &T{} }
+
+func (t *T) String() string {
+	return fmt.Sprint(t.n)
+}
+
+func (t *T) Read(p []byte) (n int, err error) { return 0, nil }
+
+func (t *T) Len() int64 { return 0 }
+
+func unexported() {}
+`
+
+func TestCursorDocTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    int
+		want    string
+		wantOK  bool
+		wantDoc bool
+	}{
+		{
+			name:   "Package",
+			line:   1,
+			want:   "// Package p implements ...",
+			wantOK: true,
+		},
+		{
+			name:   "Const",
+			line:   5,
+			want:   "// Max is the ...",
+			wantOK: true,
+		},
+		{
+			name:   "GroupedVar",
+			line:   10,
+			want:   "\t// Verbose is the ...",
+			wantOK: true,
+		},
+		{
+			name:    "Documented",
+			line:    9,
+			want:    "\t// Debug is the ...",
+			wantOK:  true,
+			wantDoc: true,
+		},
+		{
+			name:   "TypeBody",
+			line:   14,
+			want:   "// T represents a ...",
+			wantOK: true,
+		},
+		{
+			name:   "Func",
+			line:   17,
+			want:   "// NewT returns the ...",
+			wantOK: true,
+		},
+		{
+			name:   "Stringer",
+			line:   20,
+			want:   "// String implements the fmt.Stringer interface.",
+			wantOK: true,
+		},
+		{
+			name:   "Reader",
+			line:   23,
+			want:   "// Read implements the io.Reader interface.",
+			wantOK: true,
+		},
+		{
+			name:   "NotInterface",
+			line:   25,
+			want:   "// Len returns the ...",
+			wantOK: true,
+		},
+		{
+			name: "Import",
+			line: 3,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "p.go", commentSrc, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			target, ok := cursorDocTarget(fset, docTargets(f), tt.line)
+			if ok != tt.wantOK {
+				t.Fatalf("cursorDocTarget() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if (target.doc != nil) != tt.wantDoc {
+				t.Errorf("cursorDocTarget() doc = %v, wantDoc %v", target.doc, tt.wantDoc)
+			}
+
+			buflines := nvimutil.ToBufferLines([]byte(commentSrc))
+			got := insertDocComments(fset, buflines, []docTarget{target})
+			at := fset.Position(target.pos).Line - 1
+			if diff := cmp.Diff(tt.want, string(got[at])); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+			if diff := cmp.Diff(buflines[at], got[at+1]); diff != "" {
+				t.Errorf("%s: the declaration line is changed: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
+
+func TestCommentMissingLines(t *testing.T) {
+	// golint does not report the well-known methods such as String and Read
+	got, err := commentMissingLines("p.go", nvimutil.ToBufferLines([]byte(commentSrc)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `// Package p implements ...
+package p
+
+import "fmt"
+
+// Max is the ...
+const Max = 10
+
+var (
+	// Debug enables the debug log.
+	Debug bool
+	// Verbose is the ...
+	Verbose  bool
+)
+
+// T represents a ...
+type T struct {
+	n int
+}
+
+// NewT returns the ...
+func NewT() *T { return &T{} }
+
+func (t *T) String() string {
+	return fmt.Sprint(t.n)
+}
+
+func (t *T) Read(p []byte) (n int, err error) { return 0, nil }
+
+// Len returns the ...
+func (t *T) Len() int64 { return 0 }
+
+func unexported() {}
+`
+	if diff := cmp.Diff(want, string(nvimutil.ToByteSlice(got))); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
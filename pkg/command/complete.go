@@ -0,0 +1,312 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"golang.org/x/tools/go/ast/astutil"
+
+	"github.com/zchee/nvim-go/pkg/fs"
+	"github.com/zchee/nvim-go/pkg/internal/complete"
+	"github.com/zchee/nvim-go/pkg/internal/gopathwalk"
+	"github.com/zchee/nvim-go/pkg/internal/pkgindex"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// completeImportPrefix is the prefix of the user_data of the complete-items which adds the import of the package
+// after the completion is done.
+const completeImportPrefix = "nvim-go:import:"
+
+// funcCompleteArgs represents the arguments of the 'omnifunc'.
+type funcCompleteArgs struct {
+	FindStart int `msgpack:",array"`
+	Base      string
+}
+
+// funcCompleteEval represents the current buffer and the cursor.
+type funcCompleteEval struct {
+	BufNr int `msgpack:",array"`
+	File  string
+	Cwd   string
+	Line  int
+	Col   int
+	Text  string // the cursor line
+}
+
+func (c *Command) funcComplete(ctx context.Context, args *funcCompleteArgs, eval *funcCompleteEval) (interface{}, error) {
+	if args.FindStart != 0 {
+		return completeStart(eval.Text, eval.Col-1), nil
+	}
+	return c.Complete(ctx, args.Base, eval)
+}
+
+// completeStart returns the 0-based byte column of the start of the identifier before col of line.
+func completeStart(line string, col int) int {
+	if col > len(line) {
+		col = len(line)
+	}
+	start := col
+	for start > 0 {
+		ch := line[start-1]
+		if !(ch == '_' || 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9' || ch >= 0x80) {
+			break
+		}
+		start--
+	}
+	return start
+}
+
+// Complete returns the completion candidates of the identifier base at the cursor for the 'omnifunc'.
+// The candidates are the members of the selector, the identifiers in the scope, the keywords and the packages
+// which are not imported yet. The import of the unimported package is added by CompleteDone.
+//
+// Note that the base is removed from the buffer while the 'omnifunc' is called, and the cursor is at the start of it.
+func (c *Command) Complete(pctx context.Context, base string, eval *funcCompleteEval) ([]nvimutil.CompleteItem, error) {
+	ctx, span := monitoring.StartSpan(pctx, "Complete")
+	defer span.End()
+
+	completeItems := []nvimutil.CompleteItem{} // the empty list instead of v:null
+	if eval.File == "" {
+		return completeItems, nil
+	}
+
+	buflines, err := c.Nvim.BufferLines(nvim.Buffer(eval.BufNr), 0, -1, true)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return nil, errors.WithStack(err)
+	}
+	if eval.Line < 1 || eval.Line > len(buflines) {
+		return completeItems, nil
+	}
+	line := buflines[eval.Line-1]
+	col := eval.Col - 1
+	if col > len(line) {
+		col = len(line)
+	}
+	buflines[eval.Line-1] = append(append(append([]byte(nil), line[:col]...), base...), line[col:]...)
+	offset := bufferOffset(buflines, eval.Line, col+1)
+
+	f, ok := c.checker.check(ctx, eval.File, nvimutil.ToByteSlice(buflines))
+	if !ok {
+		return completeItems, nil
+	}
+	pos := f.Fset.File(f.File.Pos()).Pos(offset)
+	cctx, ok := complete.NewContext(f.File, f.Info, pos, base)
+	if !ok {
+		return completeItems, nil
+	}
+	items := cctx.Items(f.Pkg, f.Info, pos)
+
+	// pick up the packages which are added or upgraded since the last refresh for the next completion
+	go c.refreshPackageIndex(ctx, filepath.Dir(eval.File))
+
+	switch {
+	case cctx.Unresolved != "":
+		// the members of the package which is not imported yet, such as "json." without the import of "encoding/json"
+		for _, pkg := range c.completePackages(ctx, eval.Cwd, eval.File) {
+			if c.packageName(pkg) != cctx.Unresolved {
+				continue
+			}
+			indexed, err := c.pkgIndex.Lookup(pkg.path, pkg.dir)
+			if err != nil || indexed.Name != cctx.Unresolved {
+				continue
+			}
+			items = append(items, indexedMembers(indexed, base)...)
+		}
+
+	case cctx.Selector == nil && base != "":
+		imported := make(map[string]bool)
+		for _, spec := range f.File.Imports {
+			p, _ := strconv.Unquote(spec.Path.Value)
+			imported[p] = true
+		}
+		for _, pkg := range c.completePackages(ctx, eval.Cwd, eval.File) {
+			name := c.packageName(pkg)
+			if imported[pkg.path] || !strings.HasPrefix(name, base) {
+				continue
+			}
+			items = append(items, complete.Item{Word: name, Kind: complete.Package, Detail: pkg.path, Import: pkg.path})
+		}
+	}
+
+	for _, item := range items {
+		ci := nvimutil.CompleteItem{Word: item.Word, Kind: item.Kind.Letter(), Menu: item.Detail}
+		if item.Import != "" {
+			ci.Info = fmt.Sprintf("import %q", item.Import)
+			ci.UserData = completeImportPrefix + item.Import
+			ci.Dup = 1 // the packages which have the same name
+		}
+		completeItems = append(completeItems, ci)
+	}
+
+	return completeItems, nil
+}
+
+// completePackages returns the packages which can be imported from the file except the package of the file itself.
+func (c *Command) completePackages(ctx context.Context, cwd, file string) []packageDir {
+	var modPath string
+	if root, ok := fs.FindModuleRoot(file); ok {
+		modPath, _ = fs.ModulePath(root)
+	}
+
+	pkgs := c.packageIndex(ctx, cwd)
+	if modPath == "" {
+		// the packages of GOPATH are found by the package index
+		for _, root := range gopathwalk.SrcDirsRoots(c.buildContext.BuildContext()) {
+			if root.Type != gopathwalk.RootGOPATH {
+				continue
+			}
+			for _, pkg := range c.pkgIndex.Packages() {
+				if strings.HasPrefix(pkg.Dir, root.Path+string(filepath.Separator)) {
+					pkgs = append(pkgs, packageDir{path: pkg.ImportPath, dir: pkg.Dir})
+				}
+			}
+		}
+	}
+
+	var importable []packageDir
+	for _, pkg := range pkgs {
+		if pkg.dir != filepath.Dir(file) && canImport(modPath, pkg.path) {
+			importable = append(importable, pkg)
+		}
+	}
+	return importable
+}
+
+// packageName returns the package name of pkg from the package index, or assumes it from the import path
+// if not indexed yet.
+func (c *Command) packageName(pkg packageDir) string {
+	if indexed, ok := c.pkgIndex.Dir(pkg.dir); ok {
+		return indexed.Name
+	}
+	return complete.ImportPathToName(pkg.path)
+}
+
+// indexedMembers returns the completion candidates of the exported symbols of the indexed package which have the prefix.
+func indexedMembers(pkg *pkgindex.Package, prefix string) []complete.Item {
+	kinds := map[pkgindex.Kind]complete.Kind{
+		pkgindex.Func:  complete.Func,
+		pkgindex.Type:  complete.Type,
+		pkgindex.Var:   complete.Var,
+		pkgindex.Const: complete.Const,
+	}
+
+	var items []complete.Item
+	for _, sym := range pkg.Symbols {
+		if strings.HasPrefix(sym.Name, prefix) {
+			items = append(items, complete.Item{Word: sym.Name, Kind: kinds[sym.Kind], Detail: sym.Detail, Import: pkg.ImportPath})
+		}
+	}
+	return items
+}
+
+// canImport reports whether the package of importPath can be imported from the Go module modPath
+// by the rule of the internal packages.
+func canImport(modPath, importPath string) bool {
+	elems := strings.Split(importPath, "/")
+	for i, elem := range elems {
+		if elem != "internal" {
+			continue
+		}
+		parent := strings.Join(elems[:i], "/")
+		if parent == "" || modPath == "" || (modPath != parent && !strings.HasPrefix(modPath, parent+"/")) {
+			return false
+		}
+	}
+	return true
+}
+
+// CompleteDone adds the import of the package of the completed item to the buffer bufnr
+// if the item is the unimported package or its member. userData is the user_data of v:completed_item.
+func (c *Command) CompleteDone(pctx context.Context, bufnr int, userData interface{}) error {
+	_, span := monitoring.StartSpan(pctx, "CompleteDone")
+	defer span.End()
+
+	s, ok := userData.(string)
+	if !ok || !strings.HasPrefix(s, completeImportPrefix) {
+		return nil // completed by the other completion
+	}
+	importPath := strings.TrimPrefix(s, completeImportPrefix)
+
+	if err := c.addBufferImport(nvim.Buffer(bufnr), importPath); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoComplete")
+	}
+
+	return nil
+}
+
+// addBufferImport adds the import of importPath to the buffer b.
+// addBufferImport replaces only the changed lines so as not to move the cursor of the insert mode.
+func (c *Command) addBufferImport(b nvim.Buffer, importPath string) error {
+	buflines, err := c.Nvim.BufferLines(b, 0, -1, true)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	out, err := addImport(nvimutil.ToByteSlice(buflines), importPath)
+	if err != nil {
+		return err
+	}
+
+	start, end, repl, ok := nvimutil.DiffLines(buflines, bytes.Split(out, []byte{'\n'}))
+	if !ok {
+		return nil
+	}
+	return errors.WithStack(c.Nvim.SetBufferLines(b, start, end, true, repl))
+}
+
+// addImport returns src which adds the import of importPath, or src itself if already imported.
+// Only the package clause and the import declarations of src are parsed and rewritten,
+// because the rest of src can be incomplete during editing.
+func addImport(src []byte, importPath string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// the end of the line of the last import declaration, which includes its trailing comment
+	end := fset.Position(f.Name.End()).Offset
+	for _, decl := range f.Decls {
+		end = fset.Position(decl.End()).Offset
+	}
+	if i := bytes.IndexByte(src[end:], '\n'); i >= 0 {
+		end += i
+	} else {
+		end = len(src)
+	}
+	endPos := fset.File(f.Pos()).Pos(end)
+	var comments []*ast.CommentGroup
+	for _, cg := range f.Comments {
+		if cg.End() <= endPos {
+			comments = append(comments, cg)
+		}
+	}
+	f.Comments = comments
+
+	if !astutil.AddImport(fset, f, importPath) {
+		return src, nil
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, f); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return append(bytes.TrimRight(buf.Bytes(), "\n"), src[end:]...), nil
+}
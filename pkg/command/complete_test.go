@@ -0,0 +1,95 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAddImport(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		path string
+		want string
+	}{
+		{
+			name: "NoImport",
+			src:  "// Package p.\npackage p\n\nfunc f() { json.\n",
+			path: "encoding/json",
+			want: "// Package p.\npackage p\n\nimport \"encoding/json\"\n\nfunc f() { json.\n",
+		},
+		{
+			name: "Single",
+			src:  "package p\n\nimport \"os\" // for Exit\n\n// f exits.\nfunc f() { os.Exit(json.\n",
+			path: "encoding/json",
+			want: "package p\n\nimport (\n\t\"encoding/json\"\n\t\"os\" // for Exit\n)\n\n// f exits.\nfunc f() { os.Exit(json.\n",
+		},
+		{
+			name: "Group",
+			src:  "package p\n\nimport (\n\t\"io\"\n\t\"os\"\n)\n\nvar _ = strings.\n",
+			path: "strings",
+			want: "package p\n\nimport (\n\t\"io\"\n\t\"os\"\n\t\"strings\"\n)\n\nvar _ = strings.\n",
+		},
+		{
+			name: "Imported",
+			src:  "package p\n\nimport \"os\"\n\nvar _ = os.\n",
+			path: "os",
+			want: "package p\n\nimport \"os\"\n\nvar _ = os.\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := addImport([]byte(tt.src), tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
+
+func TestCanImport(t *testing.T) {
+	tests := []struct {
+		modPath string
+		path    string
+		want    bool
+	}{
+		{modPath: "example.com/m", path: "encoding/json", want: true},
+		{modPath: "example.com/m", path: "internal/poll", want: false},
+		{modPath: "example.com/m", path: "example.com/m/internal/x", want: true},
+		{modPath: "example.com/m/sub", path: "example.com/m/internal/x", want: true},
+		{modPath: "example.com/other", path: "example.com/m/internal/x", want: false},
+	}
+	for _, tt := range tests {
+		if got := canImport(tt.modPath, tt.path); got != tt.want {
+			t.Errorf("canImport(%q, %q) = %t, want %t", tt.modPath, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCompleteStart(t *testing.T) {
+	tests := []struct {
+		line string
+		col  int
+		want int
+	}{
+		{line: "\tfmt.Pri", col: 8, want: 5},
+		{line: "\tfmt.", col: 5, want: 5},
+		{line: "\tx := ", col: 6, want: 6},
+	}
+	for _, tt := range tests {
+		if got := completeStart(tt.line, tt.col); got != tt.want {
+			t.Errorf("completeStart(%q, %d) = %d, want %d", tt.line, tt.col, got, tt.want)
+		}
+	}
+}
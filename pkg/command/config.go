@@ -0,0 +1,162 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"go.uber.org/zap"
+
+	"github.com/zchee/nvim-go/pkg/config"
+	"github.com/zchee/nvim-go/pkg/logger"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// cmdConfigEval struct type for Eval of GoConfigReload command.
+type cmdConfigEval struct {
+	Dir string `eval:"expand('%:p:h')"`
+
+	Cfg *config.Config
+}
+
+func (c *Command) cmdConfigReload(ctx context.Context, eval *cmdConfigEval) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.ConfigReload(ctx, eval)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// ConfigReload re-evaluates the g: variables and the project config file, and swaps the current config.
+//
+// Running commands keep the config snapshot that at the time of started.
+func (c *Command) ConfigReload(pctx context.Context, eval *cmdConfigEval) error {
+	ctx, span := monitoring.StartSpan(pctx, "ConfigReload")
+	defer span.End()
+
+	// prefer the last Go buffer directory because the current buffer might not be the Go file
+	dir := c.buildContext.PrevDir
+	if dir == "" {
+		dir = eval.Dir
+	}
+
+	cfg, reloadErr := config.Reload(ctx, c.Nvim, eval.Cfg, dir)
+	logger.FromContext(ctx).Debug("ConfigReload", zap.String("dir", dir), zap.Any("config", cfg))
+
+	// the build context depends on the config
+	if dir != "" {
+		c.buildContext.SetContext(cfg, dir)
+	}
+
+	if reloadErr != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: reloadErr.Error()})
+		return errors.WithStack(reloadErr)
+	}
+
+	return nvimutil.EchoSuccess(c.Nvim, "GoConfigReload", "")
+}
+
+// cmdConfigShowEval struct type for Eval of GoConfig command.
+type cmdConfigShowEval struct {
+	Vars map[string]interface{} `eval:"filter(copy(g:), {k -> k =~# '^go#'})"`
+}
+
+func (c *Command) cmdConfigShow(ctx context.Context, eval *cmdConfigShowEval) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.ConfigShow(ctx, eval)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// configBufferName buffer name of the GoConfig command.
+const configBufferName = "__GO_CONFIG__"
+
+// ConfigShow opens the scratch buffer which lists the effective settings and its source.
+func (c *Command) ConfigShow(pctx context.Context, eval *cmdConfigShowEval) error {
+	ctx, span := monitoring.StartSpan(pctx, "ConfigShow")
+	defer span.End()
+
+	var buf bytes.Buffer
+
+	var project *config.Project
+	if path, ok := config.FindProjectFile(c.buildContext.PrevDir); ok {
+		p, err := config.LoadProject(path)
+		if err != nil {
+			fmt.Fprintf(&buf, "\" Warning: %v\n", err)
+		} else {
+			project = p
+			fmt.Fprintf(&buf, "\" Project: %s\n", p.Path)
+		}
+	}
+	for _, name := range config.UnknownVars(eval.Vars) {
+		fmt.Fprintf(&buf, "\" Warning: unknown variable %s\n", name)
+	}
+
+	tw := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	writeSettings := func(title string, settings []config.Setting) {
+		fmt.Fprintf(tw, "\n\" %s\n", title)
+		for _, s := range settings {
+			value, err := json.Marshal(s.Value)
+			if err != nil {
+				value = []byte(fmt.Sprint(s.Value))
+			}
+			fmt.Fprintf(tw, "%s\t%s\t[%s]\n", s.Name, value, s.Source)
+		}
+	}
+	writeSettings("Config", config.Settings(config.FromContext(ctx), eval.Vars, project))
+	writeSettings("Environment", config.EnvSettings(config.Process()))
+	if err := tw.Flush(); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	b := nvimutil.NewBuffer(c.Nvim)
+	option := map[nvimutil.NvimOption]map[string]interface{}{
+		nvimutil.BufferOption: {
+			nvimutil.BufOptionBufhidden: nvimutil.BufhiddenWipe,
+			nvimutil.BufOptionBuflisted: false,
+			nvimutil.BufOptionBuftype:   nvimutil.BuftypeNofile,
+			nvimutil.BufOptionFiletype:  nvimutil.FiletypeGoConfig,
+			nvimutil.BufOptionSwapfile:  false,
+		},
+	}
+	if err := b.Create(configBufferName, nvimutil.FiletypeGoConfig, "belowright new", option); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	c.Nvim.SetBufferOption(b.Buffer(), nvimutil.BufOptionModifiable, true)
+	if err := b.SetBufferLines(0, -1, true, bytes.TrimPrefix(buf.Bytes(), []byte("\n"))); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	c.Nvim.SetBufferOption(b.Buffer(), nvimutil.BufOptionModifiable, false)
+
+	return b.SetLocalMapping("nnoremap", map[string]string{"q": ":<C-u>quit<CR>"})
+}
@@ -44,7 +44,7 @@ func (c *Command) cmdCover(ctx context.Context, args []string, eval *cmdCoverEva
 	case err := <-errch:
 		switch e := err.(type) {
 		case error:
-			nvimutil.ErrorWrap(c.Nvim, e)
+			nvimutil.ErrorWrap(ctx, c.Nvim, e)
 		case []*nvim.QuickfixError:
 			c.errs.Store("Cover", e)
 			errlist := make(map[string][]*nvim.QuickfixError)
@@ -53,7 +53,7 @@ func (c *Command) cmdCover(ctx context.Context, args []string, eval *cmdCoverEva
 				errlist[k] = append(errlist[k], v...)
 				return true
 			})
-			nvimutil.ErrorList(c.Nvim, errlist, true)
+			nvimutil.ErrorList(ctx, c.Nvim, errlist, true)
 		case nil:
 			// nothing to do
 		}
@@ -66,6 +66,8 @@ func (c *Command) cover(pctx context.Context, args []string, eval *cmdCoverEval)
 	ctx, span := monitoring.StartSpan(pctx, "Cover")
 	defer span.End()
 
+	cfg := config.FromContext(ctx)
+
 	coverFile, err := ioutil.TempFile(os.TempDir(), "nvim-go-cover")
 	if err != nil {
 		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
@@ -74,13 +76,14 @@ func (c *Command) cover(pctx context.Context, args []string, eval *cmdCoverEval)
 	defer os.Remove(coverFile.Name())
 
 	cmd := exec.CommandContext(ctx, "go", strings.Fields(fmt.Sprintf("test -cover -covermode=atomic -coverpkg=./... -coverprofile=%s .", coverFile.Name()))...)
-	if len(config.CoverFlags) > 0 {
-		cmd.Args = append(cmd.Args, config.CoverFlags...)
+	if len(cfg.Cover.Flags) > 0 {
+		cmd.Args = append(cmd.Args, cfg.Cover.Flags...)
 	}
 	if len(args) > 0 {
 		cmd.Args = append(cmd.Args, args...)
 	}
 	cmd.Dir = filepath.Dir(eval.File)
+	cmd.Env = c.buildContext.Environ()
 	logger.FromContext(ctx).Debug("cover", zap.Any("cmd", cmd))
 
 	var stdout bytes.Buffer
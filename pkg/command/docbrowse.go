@@ -0,0 +1,318 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/zchee/nvim-go/pkg/fs"
+	"github.com/zchee/nvim-go/pkg/internal/godoc"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// docBrowseBufferPrefix is the buffer name prefix of the GoDocBrowse, which followed by the import path.
+const docBrowseBufferPrefix = "godoc://"
+
+// docBrowse represents the rendered documentation of the GoDocBrowse buffer.
+type docBrowse struct {
+	page    *godoc.Page
+	cwd     string
+	imports map[string]string // the import paths of the package names which imported by the package
+}
+
+type cmdDocBrowseEval struct {
+	Cwd  string `msgpack:",array"`
+	File string
+}
+
+func (c *Command) cmdDocBrowse(ctx context.Context, args []string, eval *cmdDocBrowseEval) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.DocBrowse(ctx, args, eval)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// DocBrowse opens the documentation buffer of the package of the import path args[0], or the package of the current buffer.
+// The packages are searched from the standard library, the Go module of the current directory and its dependencies
+// without the network access.
+func (c *Command) DocBrowse(pctx context.Context, args []string, eval *cmdDocBrowseEval) error {
+	ctx, span := monitoring.StartSpan(pctx, "DocBrowse")
+	defer span.End()
+
+	var importPath, dir string
+	if len(args) > 0 {
+		importPath = args[0]
+	} else {
+		dir = filepath.Dir(eval.File)
+	}
+	for _, pkg := range c.packageIndex(ctx, eval.Cwd) {
+		if pkg.path == importPath || pkg.dir == dir {
+			importPath, dir = pkg.path, pkg.dir
+			break
+		}
+	}
+	if importPath == "" && dir != "" {
+		// the commands are not in the package index
+		if root, ok := fs.FindModuleRoot(dir); ok {
+			if modPath, ok := fs.ModulePath(root); ok {
+				if rel, err := filepath.Rel(root, dir); err == nil {
+					importPath = path.Join(modPath, filepath.ToSlash(rel))
+				}
+			}
+		}
+	}
+	if importPath == "" || dir == "" {
+		err := errors.Errorf("GoDocBrowse: cannot find the package %s", importPath+dir)
+		span.SetStatus(trace.Status{Code: trace.StatusCodeNotFound, Message: err.Error()})
+		return err
+	}
+
+	return c.openDocBrowse(ctx, importPath, dir, eval.Cwd, "")
+}
+
+// openDocBrowse renders the documentation of the package in dir to the GoDocBrowse buffer,
+// and moves the cursor to the section of the declaration anchor if not empty.
+func (c *Command) openDocBrowse(pctx context.Context, importPath, dir, cwd, anchor string) error {
+	_, span := monitoring.StartSpan(pctx, "openDocBrowse")
+	defer span.End()
+
+	page, imports, err := docBrowsePage(importPath, dir)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoDocBrowse")
+	}
+	name := docBrowseBufferPrefix + importPath
+	c.docPages.Store(name, &docBrowse{page: page, cwd: cwd, imports: imports})
+
+	b, err := c.docBrowseBuffer(name)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return err
+	}
+
+	defer nvimutil.Modifiable(c.Nvim, b)()
+	if err := c.Nvim.SetBufferLines(b, 0, -1, true, docBufferLines(page.Lines)); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	line := 1
+	if l, ok := page.Anchors[anchor]; ok {
+		line = l + 1
+	}
+	if err := c.Nvim.SetWindowCursor(0, [2]int{line, 0}); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// docBrowseBuffer shows the GoDocBrowse buffer of name in the current window, or creates it if not exists.
+func (c *Command) docBrowseBuffer(name string) (nvim.Buffer, error) {
+	bufs, err := c.Nvim.Buffers()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	for _, b := range bufs {
+		if bufname, err := c.Nvim.BufferName(b); err != nil || bufname != name {
+			continue
+		}
+		var winID int
+		if err := c.Nvim.Call("bufwinid", &winID, int(b)); err != nil {
+			return 0, errors.WithStack(err)
+		}
+		if winID > 0 {
+			return b, errors.WithStack(c.Nvim.SetCurrentWindow(nvim.Window(winID)))
+		}
+		return b, errors.WithStack(c.Nvim.Command("silent belowright sbuffer " + strconv.Itoa(int(b))))
+	}
+
+	buf := nvimutil.NewBuffer(c.Nvim)
+	option := map[nvimutil.NvimOption]map[string]interface{}{
+		nvimutil.BufferOption: {
+			nvimutil.BufOptionBufhidden:  nvimutil.BufhiddenHide,
+			nvimutil.BufOptionBuflisted:  false,
+			nvimutil.BufOptionBuftype:    nvimutil.BuftypeNofile,
+			nvimutil.BufOptionModifiable: false,
+			nvimutil.BufOptionSwapfile:   false,
+		},
+		nvimutil.WindowOption: {
+			nvimutil.WinOptionList:   false,
+			nvimutil.WinOptionNumber: false,
+		},
+	}
+	if err := buf.Create(name, nvimutil.FiletypeMarkdown, "belowright new", option); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if err := buf.SetLocalMapping(nvimutil.NoremapNormal, map[string]string{
+		"<CR>": ":<C-u>GoDocBrowseJump<CR>",
+		"q":    ":<C-u>quit<CR>",
+	}); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return buf.Buffer(), nil
+}
+
+// cmdDocBrowseJumpEval represents the cursor of the GoDocBrowse buffer.
+type cmdDocBrowseJumpEval struct {
+	Name string `msgpack:",array"`
+	Line string
+	Lnum int
+	Col  int
+}
+
+func (c *Command) cmdDocBrowseJump(ctx context.Context, eval *cmdDocBrowseJumpEval) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.DocBrowseJump(ctx, eval)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// DocBrowseJump jumps to the section of the index line or the identifier under the cursor of the GoDocBrowse buffer.
+// The qualified identifier of the imported package such as "io.Reader" opens the documentation of the package.
+func (c *Command) DocBrowseJump(pctx context.Context, eval *cmdDocBrowseJumpEval) error {
+	ctx, span := monitoring.StartSpan(pctx, "DocBrowseJump")
+	defer span.End()
+
+	v, ok := c.docPages.Load(eval.Name)
+	if !ok {
+		return errors.New("GoDocBrowseJump: not the GoDocBrowse buffer")
+	}
+	db := v.(*docBrowse)
+
+	name, ok := db.page.Index[eval.Lnum-1]
+	if !ok {
+		name = docLinkAt(eval.Line, eval.Col-1)
+	}
+	if line, ok := db.page.Anchors[name]; ok {
+		return errors.WithStack(c.Nvim.SetWindowCursor(0, [2]int{line + 1, 0}))
+	}
+
+	parts := strings.SplitN(name, ".", 2)
+	importPath, ok := db.imports[parts[0]]
+	if !ok {
+		return nil
+	}
+	anchor := ""
+	if len(parts) > 1 {
+		anchor = parts[1]
+	}
+	for _, pkg := range c.packageIndex(ctx, db.cwd) {
+		if pkg.path == importPath {
+			return c.openDocBrowse(ctx, pkg.path, pkg.dir, db.cwd, anchor)
+		}
+	}
+
+	return errors.Errorf("GoDocBrowseJump: cannot find the package %s", importPath)
+}
+
+func (c *Command) cmdDocBrowseComplete(ctx context.Context, a *nvim.CommandCompletionArgs, cwd string) ([]string, error) {
+	var items []string
+	for _, pkg := range c.packageIndex(ctx, cwd) {
+		if strings.HasPrefix(pkg.path, a.ArgLead) {
+			items = append(items, pkg.path)
+		}
+	}
+
+	return items, nil
+}
+
+// docBrowsePage renders the documentation of the package in dir including the examples of the test files,
+// and returns the import paths of the package names imported by the package.
+func docBrowsePage(importPath, dir string) (*godoc.Page, map[string]string, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	fset := token.NewFileSet()
+	var pkgName string
+	var files, testFiles []*ast.File
+	for _, fi := range fis {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		if strings.HasSuffix(name, "_test.go") {
+			testFiles = append(testFiles, f)
+			continue
+		}
+		if pkgName == "" {
+			pkgName = f.Name.Name
+		}
+		if f.Name.Name == pkgName {
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return nil, nil, errors.Errorf("no Go files in %s", dir)
+	}
+
+	imports := make(map[string]string)
+	for _, f := range files {
+		for _, spec := range f.Imports {
+			p, _ := strconv.Unquote(spec.Path.Value)
+			name := path.Base(p)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imports[name] = p
+		}
+	}
+	for _, f := range testFiles {
+		if f.Name.Name == pkgName || f.Name.Name == pkgName+"_test" {
+			files = append(files, f)
+		}
+	}
+
+	pkg, err := doc.NewFromFiles(fset, files, importPath)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	return godoc.Render(fset, pkg), imports, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/zchee/nvim-go/pkg/internal/testutil"
+)
+
+func TestDocBrowsePage(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"p.go": `// Package p is the example.
+package p
+
+import (
+	"io"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Copy copies r to w.
+func Copy(w io.Writer, r io.Reader) { log.Info("copy") }
+`,
+		"p_test.go": `package p_test
+
+func ExampleCopy() {}
+`,
+		"main.go": `// +build ignore
+
+package main
+`,
+	}
+	testutil.WriteFiles(t, dir, files)
+
+	page, imports, err := docBrowsePage("example.com/p", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]string{"io": "io", "log": "github.com/sirupsen/logrus"}, imports); diff != "" {
+		t.Errorf("imports: (-want +got)\n%s", diff)
+	}
+	for _, anchor := range []string{"Copy", "ExampleCopy"} {
+		if _, ok := page.Anchors[anchor]; !ok {
+			t.Errorf("the anchor %s is not found", anchor)
+		}
+	}
+
+	if _, _, err := docBrowsePage("example.com/empty", t.TempDir()); err == nil {
+		t.Error("docBrowsePage() of the empty directory: want the error")
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"unicode/utf8"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/zchee/nvim-go/pkg/internal/refactor"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// cmdExtractEval represents the eval of the GoExtractFunc and GoExtractVar commands.
+// Start and End are the results of getpos("'<") and getpos("'>").
+type cmdExtractEval struct {
+	File  string `msgpack:",array"`
+	Start [4]int
+	End   [4]int
+}
+
+func (c *Command) cmdExtractFunc(ctx context.Context, args []string, ranges [2]int, eval *cmdExtractEval) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.ExtractFunc(ctx, args, ranges, eval)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// ExtractFunc extracts the statements in the range to the new function args[0],
+// and replaces the statements with the call of the function.
+func (c *Command) ExtractFunc(pctx context.Context, args []string, ranges [2]int, eval *cmdExtractEval) error {
+	ctx, span := monitoring.StartSpan(pctx, "ExtractFunc")
+	defer span.End()
+
+	if err := c.extract(ctx, refactor.ExtractFunc, args[0], ranges, eval); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoExtractFunc")
+	}
+
+	return nil
+}
+
+func (c *Command) cmdExtractVar(ctx context.Context, args []string, ranges [2]int, eval *cmdExtractEval) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.ExtractVar(ctx, args, ranges, eval)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// ExtractVar extracts the expression in the range to the new local variable args[0],
+// and replaces the expression with the variable.
+func (c *Command) ExtractVar(pctx context.Context, args []string, ranges [2]int, eval *cmdExtractEval) error {
+	ctx, span := monitoring.StartSpan(pctx, "ExtractVar")
+	defer span.End()
+
+	if err := c.extract(ctx, refactor.ExtractVar, args[0], ranges, eval); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoExtractVar")
+	}
+
+	return nil
+}
+
+// extract applies the extract refactoring to the selection of the current buffer.
+func (c *Command) extract(ctx context.Context, fn func(*refactor.File, int, int, string) ([]byte, error), name string, ranges [2]int, eval *cmdExtractEval) error {
+	b := nvim.Buffer(c.buildContext.BufNr)
+	buflines, err := c.Nvim.BufferLines(b, 0, -1, true)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	src := nvimutil.ToByteSlice(buflines)
+
+	f, err := loadRefactorFile(c.buildContext.BuildContext(), eval.File, src)
+	if err != nil {
+		return err
+	}
+
+	start, end := selectionOffsets(buflines, ranges, eval.Start, eval.End)
+	out, err := fn(f, start, end, name)
+	if err != nil {
+		return err
+	}
+
+	return minUpdate(ctx, c.Nvim, b, buflines, nvimutil.ToBufferLines(bytes.TrimSuffix(out, []byte{'\n'})))
+}
+
+// selectionOffsets returns the byte range [start, end) of the selection in the buffer lines.
+//
+// The visual marks are used if they match the line range, otherwise the whole lines of the range are selected.
+// The column of the end mark points to the first byte of the last selected character, or is huge in linewise mode.
+func selectionOffsets(lines [][]byte, ranges [2]int, vstart, vend [4]int) (int, int) {
+	lineOffset := func(lnum int) int {
+		offset := 0
+		for _, line := range lines[:lnum-1] {
+			offset += len(line) + 1
+		}
+		return offset
+	}
+	clamp := func(lnum int) int {
+		switch {
+		case lnum < 1:
+			return 1
+		case lnum > len(lines):
+			return len(lines)
+		}
+		return lnum
+	}
+	first, last := clamp(ranges[0]), clamp(ranges[1])
+
+	if vstart[1] != first || vend[1] != last || vstart[2] < 1 || vend[2] < 1 {
+		return lineOffset(first), lineOffset(last) + len(lines[last-1])
+	}
+
+	scol := vstart[2] - 1
+	if scol > len(lines[first-1]) {
+		scol = len(lines[first-1])
+	}
+	line := lines[last-1]
+	ecol := vend[2] - 1
+	if ecol >= len(line) {
+		ecol = len(line)
+	} else {
+		_, size := utf8.DecodeRune(line[ecol:])
+		ecol += size
+	}
+
+	return lineOffset(first) + scol, lineOffset(last) + ecol
+}
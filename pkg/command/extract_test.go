@@ -0,0 +1,39 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"testing"
+)
+
+func TestSelectionOffsets(t *testing.T) {
+	lines := [][]byte{
+		[]byte("func f() {"),
+		[]byte("\tx := \"αβ\""),
+		[]byte("}"),
+	}
+	tests := []struct {
+		name               string
+		ranges             [2]int
+		vstart, vend       [4]int
+		wantStart, wantEnd int
+	}{
+		{name: "Lines", ranges: [2]int{2, 2}, wantStart: 11, wantEnd: 23},
+		{name: "Charwise", ranges: [2]int{2, 2}, vstart: [4]int{0, 2, 7, 0}, vend: [4]int{0, 2, 12, 0}, wantStart: 17, wantEnd: 23},
+		{name: "Multibyte", ranges: [2]int{2, 2}, vstart: [4]int{0, 2, 8, 0}, vend: [4]int{0, 2, 10, 0}, wantStart: 18, wantEnd: 22},
+		{name: "Linewise", ranges: [2]int{1, 3}, vstart: [4]int{0, 1, 1, 0}, vend: [4]int{0, 3, 2147483647, 0}, wantStart: 0, wantEnd: 25},
+		{name: "OtherRange", ranges: [2]int{3, 3}, vstart: [4]int{0, 2, 7, 0}, vend: [4]int{0, 2, 12, 0}, wantStart: 24, wantEnd: 25},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			start, end := selectionOffsets(lines, tt.ranges, tt.vstart, tt.vend)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("selectionOffsets() = (%d, %d), want (%d, %d)", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
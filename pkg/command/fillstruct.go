@@ -0,0 +1,68 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/zchee/nvim-go/pkg/config"
+	"github.com/zchee/nvim-go/pkg/internal/refactor"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+func (c *Command) cmdFillStruct(ctx context.Context, file string) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.FillStruct(ctx, file)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// FillStruct expands the struct composite literal under the cursor into the keyed form with the zero values.
+func (c *Command) FillStruct(pctx context.Context, file string) error {
+	ctx, span := monitoring.StartSpan(pctx, "FillStruct")
+	defer span.End()
+
+	b := nvim.Buffer(c.buildContext.BufNr)
+	w := nvim.Window(c.buildContext.WinID)
+
+	buflines, err := c.Nvim.BufferLines(b, 0, -1, true)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	offset, err := nvimutil.ByteOffset(c.Nvim, b, w)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	f, err := loadRefactorFile(c.buildContext.BuildContext(), file, nvimutil.ToByteSlice(buflines))
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return err
+	}
+	out, err := refactor.FillStruct(f, offset, config.FromContext(ctx).FillStruct.Recursive)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoFillStruct")
+	}
+
+	return minUpdate(ctx, c.Nvim, b, buflines, nvimutil.ToBufferLines(bytes.TrimSuffix(out, []byte{'\n'})))
+}
@@ -41,7 +41,7 @@ func (c *Command) cmdFmt(ctx context.Context, dir string) {
 	case err := <-errch:
 		switch e := err.(type) {
 		case error:
-			nvimutil.ErrorWrap(c.Nvim, e)
+			nvimutil.ErrorWrap(ctx, c.Nvim, e)
 		case []*nvim.QuickfixError:
 			c.errs.Store("Fmt", e)
 			errlist := make(map[string][]*nvim.QuickfixError)
@@ -50,7 +50,7 @@ func (c *Command) cmdFmt(ctx context.Context, dir string) {
 				errlist[k] = append(errlist[k], v...)
 				return true
 			})
-			nvimutil.ErrorList(c.Nvim, errlist, true)
+			nvimutil.ErrorList(ctx, c.Nvim, errlist, true)
 		case nil:
 			// nothing to do
 		}
@@ -62,28 +62,39 @@ func (c *Command) Fmt(pctx context.Context, dir string) interface{} {
 	ctx, span := monitoring.StartSpan(pctx, "Fmt")
 	defer span.End()
 
+	cfg := config.FromContext(ctx)
+
 	b := nvim.Buffer(c.buildContext.BufNr)
 	data, err := c.Nvim.BufferLines(b, 0, -1, true)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	switch config.FmtMode {
+	opt := importsOptions // copy the default options
+	switch cfg.Fmt.Mode {
 	case "fmt":
-		importsOptions.FormatOnly = true
+		opt.FormatOnly = true
 	case "goimports":
-		if locals := config.FmtGoImportsLocal; len(locals) > 0 {
+		if locals := cfg.Fmt.GoImportsLocal; len(locals) > 0 {
 			imports.LocalPrefix = strings.Join(locals, ",")
 		}
 	default:
 		return errors.WithStack(errors.New("invalid value of go#fmt#mode option"))
 	}
 	logger.FromContext(ctx).Debug("Fmt",
-		zap.Any("importsOptions", importsOptions),
+		zap.Any("importsOptions", opt),
 		zap.String("imports.LocalPrefix", imports.LocalPrefix),
 	)
 
-	buf, formatErr := imports.Process("", nvimutil.ToByteSlice(data), &importsOptions)
+	src := nvimutil.ToByteSlice(data)
+	if !opt.FormatOnly {
+		// resolve the imports by the package index before goimports which scans GOPATH and the module cache
+		if bufName, err := c.Nvim.BufferName(b); err == nil {
+			src = c.resolveImports(ctx, dir, bufName, src)
+		}
+	}
+
+	buf, formatErr := imports.Process("", src, &opt)
 	if formatErr != nil {
 		bufName, err := c.Nvim.BufferName(b)
 		if err != nil {
@@ -130,36 +141,10 @@ func minUpdate(ctx context.Context, v *nvim.Nvim, b nvim.Buffer, in [][]byte, ou
 	ctx, span = monitoring.StartSpan(ctx, "minUpdate")
 	defer span.End()
 
-	// Find matching head lines.
-	n := len(out)
-	if len(in) < len(out) {
-		n = len(in)
-	}
-	head := 0
-	for ; head < n; head++ {
-		if !bytes.Equal(in[head], out[head]) {
-			break
-		}
-	}
-
-	// Nothing to do?
-	if head == len(in) && head == len(out) {
+	start, end, repl, ok := nvimutil.DiffLines(in, out)
+	if !ok {
 		return nil
 	}
 
-	// Find matching tail lines.
-	n -= head
-	tail := 0
-	for ; tail < n; tail++ {
-		if !bytes.Equal(in[len(in)-tail-1], out[len(out)-tail-1]) {
-			break
-		}
-	}
-
-	// Update the buffer.
-	start := head
-	end := len(in) - tail
-	repl := out[head : len(out)-tail]
-
 	return v.SetBufferLines(b, start, end, true, repl)
 }
@@ -19,8 +19,9 @@ import (
 )
 
 func TestCommand_Fmt(t *testing.T) {
-	config.FmtMode = "goimports"
-	ctx := testutil.TestContext(t, context.Background())
+	cfg := config.Default().Clone()
+	cfg.Fmt.Mode = "goimports"
+	ctx := config.NewContext(testutil.TestContext(t, context.Background()), cfg)
 
 	type fields struct {
 		ctx   context.Context
@@ -95,7 +96,7 @@ func TestCommand_Fmt(t *testing.T) {
 
 			ctx := tt.fields.ctx
 
-			c := NewCommand(ctx, tt.fields.Nvim, tt.fields.bctxt)
+			c := NewCommand(ctx, tt.fields.Nvim, tt.fields.bctxt, NewCache())
 			err := c.Fmt(ctx, tt.args.dir)
 			switch e := err.(type) {
 			case error:
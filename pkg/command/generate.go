@@ -38,7 +38,7 @@ func (c *Command) cmdGenerateTest(ctx context.Context, args []string, ranges [2]
 	case err := <-errch:
 		switch e := err.(type) {
 		case error:
-			nvimutil.ErrorWrap(c.Nvim, e)
+			nvimutil.ErrorWrap(ctx, c.Nvim, e)
 		case nil:
 			// nothing to do
 		}
@@ -52,25 +52,27 @@ func (c *Command) GenerateTest(ctx context.Context, args []string, ranges [2]int
 	ctx, span = trace.StartSpan(ctx, path.Join(nctx.PkgName, "GenerateTest"))
 	defer span.End()
 
+	cfg := config.FromContext(ctx)
+
 	b := nvim.Buffer(c.buildContext.BufNr)
 	if len(args) == 0 {
 		f, err := c.Nvim.BufferName(b)
 		if err != nil {
-			return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
+			return nvimutil.ErrorWrap(ctx, c.Nvim, errors.WithStack(err))
 		}
 		args = []string{f}
 	}
 
 	opt := &process.Options{
-		ExclFuncs:          config.GenerateTestExclFuncs,
-		ExportedFuncs:      config.GenerateTestExportedFuncs,
-		AllFuncs:           config.GenerateTestAllFuncs,
+		ExclFuncs:          cfg.Generate.TestExclFuncs,
+		ExportedFuncs:      cfg.Generate.TestExportedFuncs,
+		AllFuncs:           cfg.Generate.TestAllFuncs,
 		PrintInputs:        true,
-		Subtests:           config.GenerateTestSubTest,
-		Parallel:           config.GenerateTestParallel,
+		Subtests:           cfg.Generate.TestSubTest,
+		Parallel:           cfg.Generate.TestParallel,
 		WriteOutput:        true,
-		TemplateDir:        config.GenerateTestTemplateDir,
-		TemplateParamsPath: config.GenerateTestTemplateParamsPath,
+		TemplateDir:        cfg.Generate.TestTemplateDir,
+		TemplateParamsPath: cfg.Generate.TemplateParamsPath,
 	}
 
 	// Check users used range. range return variable: (1,$)
@@ -80,7 +82,7 @@ func (c *Command) GenerateTest(ctx context.Context, args []string, ranges [2]int
 		lines, err := c.Nvim.BufferLineCount(b)
 		if err != nil {
 			span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
-			return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
+			return nvimutil.ErrorWrap(ctx, c.Nvim, errors.WithStack(err))
 		}
 
 		if ranges[1] != lines {
@@ -90,7 +92,7 @@ func (c *Command) GenerateTest(ctx context.Context, args []string, ranges [2]int
 			blines, err := c.Nvim.BufferLines(b, start-1, end, true)
 			if err != nil {
 				span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
-				return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
+				return nvimutil.ErrorWrap(ctx, c.Nvim, errors.WithStack(err))
 			}
 			// Convert to 1D byte slice
 			buf := nvimutil.ToByteSlice(blines)
@@ -139,7 +141,7 @@ func (c *Command) GenerateTest(ctx context.Context, args []string, ranges [2]int
 		var answer interface{}
 		if err := c.Nvim.Call("input", &answer, ask); err != nil {
 			span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
-			return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
+			return nvimutil.ErrorWrap(ctx, c.Nvim, errors.WithStack(err))
 		}
 		// TODO(zchee): Support open the ftests[0] file only.
 		// If passes multiple files for 'edit' commands, occur 'E172: Only one file name allowed' errror.
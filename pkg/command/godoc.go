@@ -0,0 +1,349 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/loader"
+
+	"github.com/zchee/nvim-go/pkg/internal/godoc"
+	"github.com/zchee/nvim-go/pkg/internal/refactor"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// docFloat represents the GoDoc floating window, and the program which resolves the identifiers of the documentation.
+type docFloat struct {
+	float *nvimutil.Float
+	prog  *loader.Program
+	pkg   *types.Package // the package of the shown documentation
+}
+
+func (c *Command) cmdDoc(ctx context.Context, args []string, file string) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.Doc(ctx, args, file)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// Doc shows the signature and the documentation of the identifier under the cursor in the floating window.
+// If args has the identifier such as "io.Reader", Doc shows the documentation of it instead of the cursor.
+// Doc focuses the floating window if it is already opened.
+func (c *Command) Doc(pctx context.Context, args []string, file string) error {
+	_, span := monitoring.StartSpan(pctx, "Doc")
+	defer span.End()
+
+	c.mu.Lock()
+	df := c.doc
+	c.mu.Unlock()
+	if df != nil && len(args) == 0 && nvimutil.IsWindowValid(c.Nvim, df.float.Window) {
+		return errors.WithStack(c.Nvim.SetCurrentWindow(df.float.Window))
+	}
+
+	b := nvim.Buffer(c.buildContext.BufNr)
+	w := nvim.Window(c.buildContext.WinID)
+
+	buflines, err := c.Nvim.BufferLines(b, 0, -1, true)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	rf, prog, err := loadRefactorProgram(c.buildContext.BuildContext(), file, nvimutil.ToByteSlice(buflines))
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoDoc")
+	}
+
+	var obj types.Object
+	if len(args) > 0 {
+		obj, err = docLookup(rf.Pkg, args[0])
+	} else {
+		var offset int
+		offset, err = nvimutil.ByteOffset(c.Nvim, b, w)
+		if err == nil {
+			obj, err = docCursorObject(rf, offset)
+		}
+	}
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeNotFound, Message: err.Error()})
+		return errors.Wrap(err, "GoDoc")
+	}
+
+	lines, err := docLines(prog, obj)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoDoc")
+	}
+
+	float, err := nvimutil.OpenFloat(c.Nvim, docBufferLines(lines), nvimutil.FiletypeMarkdown, "CursorMoved", "CursorMovedI", "InsertEnter")
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	batch := c.Nvim.NewBatch()
+	for _, lhs := range []string{"<CR>", "K"} {
+		batch.SetBufferKeyMap(float.Buffer, "n", lhs, ":<C-u>GoDocFollow<CR>", map[string]bool{"noremap": true, "silent": true})
+	}
+	batch.SetBufferKeyMap(float.Buffer, "n", "q", ":<C-u>close<CR>", map[string]bool{"noremap": true, "silent": true})
+	if err := batch.Execute(); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	c.mu.Lock()
+	c.doc = &docFloat{float: float, prog: prog, pkg: docPackage(obj)}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// cmdDocFollowEval represents the cursor line and column of the GoDoc floating window.
+type cmdDocFollowEval struct {
+	Line string `msgpack:",array"`
+	Col  int
+}
+
+func (c *Command) cmdDocFollow(ctx context.Context, eval *cmdDocFollowEval) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.DocFollow(ctx, eval)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// DocFollow shows the documentation of the identifier under the cursor of the GoDoc floating window
+// in the same window.
+func (c *Command) DocFollow(pctx context.Context, eval *cmdDocFollowEval) error {
+	_, span := monitoring.StartSpan(pctx, "DocFollow")
+	defer span.End()
+
+	c.mu.Lock()
+	df := c.doc
+	c.mu.Unlock()
+	if df == nil || !nvimutil.IsWindowValid(c.Nvim, df.float.Window) {
+		return errors.New("GoDocFollow: the documentation is not opened")
+	}
+
+	name := docLinkAt(eval.Line, eval.Col-1)
+	if name == "" {
+		return nil
+	}
+	obj, err := docLookup(df.pkg, name)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeNotFound, Message: err.Error()})
+		return errors.Wrap(err, "GoDocFollow")
+	}
+	lines, err := docLines(df.prog, obj)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoDocFollow")
+	}
+	if err := df.float.SetLines(c.Nvim, docBufferLines(lines)); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	c.mu.Lock()
+	if pkg := docPackage(obj); pkg != nil {
+		df.pkg = pkg
+	}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// docCursorObject returns the object of the identifier or the import path at the byte offset of f.
+func docCursorObject(f *refactor.File, offset int) (types.Object, error) {
+	tokFile := f.Fset.File(f.File.Pos())
+	if offset < 0 || offset > tokFile.Size() {
+		return nil, errors.Errorf("invalid offset %d", offset)
+	}
+	pos := tokFile.Pos(offset)
+
+	path, _ := astutil.PathEnclosingInterval(f.File, pos, pos)
+	for _, node := range path {
+		switch node := node.(type) {
+		case *ast.Ident:
+			if node == f.File.Name {
+				return types.NewPkgName(node.Pos(), f.Pkg, f.Pkg.Name(), f.Pkg), nil
+			}
+			if obj := f.Info.ObjectOf(node); obj != nil {
+				return obj, nil
+			}
+		case *ast.ImportSpec:
+			if node.Name != nil {
+				if obj := f.Info.Defs[node.Name]; obj != nil {
+					return obj, nil
+				}
+			}
+			if obj := f.Info.Implicits[node]; obj != nil {
+				return obj, nil
+			}
+		}
+	}
+
+	return nil, errors.New("no identifier at the cursor")
+}
+
+// docLookup resolves the qualified identifier name such as "Reader", "io.Reader" or "io.Reader.Read" in pkg.
+func docLookup(pkg *types.Package, name string) (types.Object, error) {
+	parts := strings.Split(name, ".")
+
+	obj := pkg.Scope().Lookup(parts[0])
+	if obj == nil {
+		for _, imp := range pkg.Imports() {
+			if imp.Name() != parts[0] {
+				continue
+			}
+			if len(parts) == 1 {
+				return types.NewPkgName(token.NoPos, pkg, imp.Name(), imp), nil
+			}
+			obj, parts = imp.Scope().Lookup(parts[1]), parts[1:]
+			if obj == nil {
+				return nil, errors.Errorf("%s is not found", name)
+			}
+			break
+		}
+	}
+	if obj == nil {
+		obj = types.Universe.Lookup(parts[0])
+	}
+	if obj == nil {
+		return nil, errors.Errorf("%s is not found", name)
+	}
+
+	for _, sel := range parts[1:] {
+		if obj, _, _ = types.LookupFieldOrMethod(obj.Type(), true, obj.Pkg(), sel); obj == nil {
+			return nil, errors.Errorf("%s is not found", name)
+		}
+	}
+
+	return obj, nil
+}
+
+// docPackage returns the package which resolves the identifiers in the documentation of obj.
+func docPackage(obj types.Object) *types.Package {
+	if pkgName, ok := obj.(*types.PkgName); ok {
+		return pkgName.Imported()
+	}
+	return obj.Pkg()
+}
+
+// docIdentRe matches the qualified identifier in the documentation.
+var docIdentRe = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*`)
+
+// docLinkAt returns the qualified identifier at the byte column col of line, or empty if not found.
+func docLinkAt(line string, col int) string {
+	for _, loc := range docIdentRe.FindAllStringIndex(line, -1) {
+		if loc[0] <= col && col < loc[1] {
+			return line[loc[0]:loc[1]]
+		}
+	}
+	return ""
+}
+
+// docLines returns the markdown lines of the declaration and the documentation of obj.
+// The documentation of the standard library is read from $GOROOT/src.
+func docLines(prog *loader.Program, obj types.Object) ([]string, error) {
+	var decl, doc string
+	switch obj := obj.(type) {
+	case *types.PkgName:
+		pkg := obj.Imported()
+		decl = fmt.Sprintf("package %s // import %q", pkg.Name(), pkg.Path())
+		for _, created := range prog.Created {
+			if created.Pkg == pkg {
+				decl = "package " + pkg.Name() // the package of the buffer has no import path
+			}
+		}
+		if info := prog.AllPackages[pkg]; info != nil {
+			doc = godoc.PackageDoc(info.Files)
+		}
+
+	default:
+		ok := false
+		if obj.Pkg() == nil {
+			decl, doc, ok = builtinDoc(obj.Name())
+		} else if info := prog.AllPackages[obj.Pkg()]; info != nil && !isLocalObject(obj) {
+			for _, f := range info.Files {
+				if f.Pos() <= obj.Pos() && obj.Pos() <= f.End() {
+					decl, doc, ok = godoc.Decl(prog.Fset, f, obj.Pos())
+					break
+				}
+			}
+		}
+		if !ok {
+			decl = types.ObjectString(obj, types.RelativeTo(obj.Pkg()))
+		}
+	}
+
+	lines := []string{"```go"}
+	lines = append(lines, strings.Split(decl, "\n")...)
+	lines = append(lines, "```")
+	if md := godoc.Markdown(doc); len(md) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, md...)
+	}
+
+	return lines, nil
+}
+
+// isLocalObject reports whether obj is declared in the function.
+func isLocalObject(obj types.Object) bool {
+	return obj.Parent() != nil && obj.Parent() != obj.Pkg().Scope()
+}
+
+// builtinDoc returns the declaration and the documentation of the predeclared identifier from $GOROOT/src/builtin.
+func builtinDoc(name string) (decl, doc string, ok bool) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filepath.Join(build.Default.GOROOT, "src", "builtin", "builtin.go"), nil, parser.ParseComments)
+	if err != nil {
+		return "", "", false
+	}
+	obj := f.Scope.Lookup(name)
+	if obj == nil {
+		return "", "", false
+	}
+	return godoc.Decl(fset, f, obj.Pos())
+}
+
+// docBufferLines converts the lines to the buffer lines.
+func docBufferLines(lines []string) [][]byte {
+	buflines := make([][]byte, len(lines))
+	for i, line := range lines {
+		buflines[i] = []byte(line)
+	}
+	return buflines
+}
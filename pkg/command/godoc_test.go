@@ -0,0 +1,125 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"go/build"
+	"go/types"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const godocSrc = `// Package p is the test package.
+package p
+
+import "io"
+
+// T wraps the reader.
+type T struct {
+	r io.Reader // the underlying reader
+}
+
+func (t *T) read(p []byte) int {
+	n, _ := t.r.Read(p)
+	return len(p[:n])
+}
+`
+
+func TestDocLines(t *testing.T) {
+	rf, prog, err := loadRefactorProgram(&build.Default, filepath.Join(t.TempDir(), "p.go"), []byte(godocSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		at    string // the source which starts at the cursor
+		query string // the GoDoc argument instead of the cursor
+		want  []string
+	}{
+		{
+			name: "Package",
+			at:   "p\n\nimport",
+			want: []string{"```go", "package p", "```", "", "Package p is the test package."},
+		},
+		{
+			name: "Type",
+			at:   "T) read",
+			want: []string{"```go", "type T struct {", "\tr io.Reader // the underlying reader", "}", "```", "", "T wraps the reader."},
+		},
+		{
+			name: "Field",
+			at:   "r.Read",
+			want: []string{"```go", "r io.Reader", "```", "", "the underlying reader"},
+		},
+		{
+			name: "Local",
+			at:   "n, _",
+			want: []string{"```go", "var n int", "```"},
+		},
+		{
+			name: "Builtin",
+			at:   "len(",
+			want: []string{"```go", "func len(v Type) int"},
+		},
+		{
+			name: "ImportPath",
+			at:   `"io"`,
+			want: []string{"```go", `package io // import "io"`, "```", "", "Package io provides basic interfaces to I/O primitives."},
+		},
+		{
+			name:  "Query",
+			query: "io.Reader.Read",
+			want:  []string{"```go", "Read(p []byte) (n int, err error)", "```"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var obj types.Object
+			var err error
+			if tt.query != "" {
+				obj, err = docLookup(rf.Pkg, tt.query)
+			} else {
+				obj, err = docCursorObject(rf, strings.Index(godocSrc, tt.at))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := docLines(prog, obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) > len(tt.want) {
+				got = got[:len(tt.want)]
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
+
+func TestDocLinkAt(t *testing.T) {
+	tests := []struct {
+		line string
+		col  int
+		want string
+	}{
+		{line: "Read implements io.Reader.", col: 20, want: "io.Reader"},
+		{line: "Read implements io.Reader.", col: 1, want: "Read"},
+		{line: "Read implements io.Reader.", col: 4, want: ""},
+	}
+	for _, tt := range tests {
+		if got := docLinkAt(tt.line, tt.col); got != tt.want {
+			t.Errorf("docLinkAt(%q, %d) = %q, want %q", tt.line, tt.col, got, tt.want)
+		}
+	}
+}
@@ -12,7 +12,6 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"go/build"
 	"go/token"
 	"os"
 	"path/filepath"
@@ -54,7 +53,7 @@ func (c *Command) funcGuru(ctx context.Context, args []string, eval *funcGuruEva
 	case err := <-errch:
 		switch e := err.(type) {
 		case error:
-			nvimutil.ErrorWrap(c.Nvim, e)
+			nvimutil.ErrorWrap(ctx, c.Nvim, e)
 		case []*nvim.QuickfixError:
 			c.errs.Store("Guru", e)
 			errlist := make(map[string][]*nvim.QuickfixError)
@@ -63,7 +62,7 @@ func (c *Command) funcGuru(ctx context.Context, args []string, eval *funcGuruEva
 				errlist[k] = append(errlist[k], v...)
 				return true
 			})
-			nvimutil.ErrorList(c.Nvim, errlist, true)
+			nvimutil.ErrorList(ctx, c.Nvim, errlist, true)
 		case nil:
 			// nothing to do
 		}
@@ -75,6 +74,8 @@ func (c *Command) Guru(pctx context.Context, args []string, eval *funcGuruEval)
 	ctx, span := monitoring.StartSpan(pctx, "Guru")
 	defer span.End()
 
+	cfg := config.FromContext(ctx)
+
 	log := logger.FromContext(ctx).Named("Guru").With(zap.Any("funcGuruEval", eval))
 
 	defer func() (err error) {
@@ -95,35 +96,18 @@ func (c *Command) Guru(pctx context.Context, args []string, eval *funcGuruEval)
 	w := nvim.Window(c.buildContext.WinID)
 	batch := c.Nvim.NewBatch()
 
-	guruContext := &build.Default
-
-	// https://github.com/golang/tools/blob/master/cmd/guru/main.go
-	if eval.Modified != 0 {
-		overlay := make(map[string][]byte)
-		var buf [][]byte
-
-		batch.BufferLines(b, 0, -1, true, &buf)
-		if err := batch.Execute(); err != nil {
-			span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
-			return errors.WithStack(err)
-		}
-
-		overlay[eval.File] = bytes.Join(buf, []byte{'\n'})
-		guruContext = buildutil.OverlayContext(guruContext, overlay)
-	}
-
 	var loclist []*nvim.QuickfixError
-	query := guru.Query{
-		Pos:        fmt.Sprintf("%s:#%d", eval.File, eval.Offset),
-		Build:      guruContext,
-		Reflection: config.GuruReflection,
+	query, err := c.guruQuery(b, eval, cfg.Guru.Reflection)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return err
 	}
 	log.Info("", zap.String("query.Pos", query.Pos), zap.Bool("query.Reflection", query.Reflection))
 
 	mode := args[0]
 
 	if mode == "definition" {
-		obj, err := Definition(&query)
+		obj, err := Definition(query)
 		if err != nil {
 			span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
 			return errors.WithStack(err)
@@ -163,30 +147,13 @@ func (c *Command) Guru(pctx context.Context, args []string, eval *funcGuruEval)
 		return c.Nvim.Command(`lclose | normal! zz`)
 	}
 
-	var scopes []string
-	switch c.buildContext.Build.Tool {
-	case "go":
-		root := fs.FindVCSRoot(eval.File)
-		root, _ = filepath.Abs(root)
-		scopes = []string{fs.ToWildcard(fs.TrimGoPath(root))}
-		if vendorDir := filepath.Join(root, "vendor"); fs.IsDirExist(vendorDir) {
-			scopes = append(scopes, "-"+fs.TrimGoPath(vendorDir))
-		}
+	scopes, err := c.guruScope(eval.File)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	if c.buildContext.Build.Tool == "go" {
 		os.Unsetenv("GO111MODULE")
-	case "gb":
-		root := c.buildContext.Build.ProjectRoot
-		var err error
-		scopes, err = fs.GbPackages(root)
-		if err != nil {
-			span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
-			return errors.Wrap(err, "could not get gb packages")
-		}
-		for i, pkg := range scopes {
-			scopes[i] = fs.ToWildcard(pkg)
-		}
-		if vendorDir := filepath.Join(root, "vendor"); fs.IsDirExist(vendorDir) {
-			scopes = append(scopes, "-"+fs.ToWildcard(vendorDir))
-		}
 	}
 	query.Scope = append(query.Scope, scopes...)
 	log.Info("",
@@ -196,7 +163,6 @@ func (c *Command) Guru(pctx context.Context, args []string, eval *funcGuruEval)
 		zap.Strings("query.Scope", query.Scope))
 
 	var outputMu sync.Mutex
-	var err error
 	output := func(fset *token.FileSet, qr guru.QueryResult) {
 		var err error
 		outputMu.Lock()
@@ -215,7 +181,7 @@ func (c *Command) Guru(pctx context.Context, args []string, eval *funcGuruEval)
 	query.Output = output
 
 	nvimutil.EchoProgress(c.Nvim, "Guru", fmt.Sprintf("analysing %s", mode))
-	if err := guru.Run(mode, &query); err != nil {
+	if err := guru.Run(mode, query); err != nil {
 		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
 		return errors.WithStack(err)
 	}
@@ -232,19 +198,44 @@ func (c *Command) Guru(pctx context.Context, args []string, eval *funcGuruEval)
 	}
 
 	// jumpfirst or definition mode
-	if config.GuruJumpFirst {
+	if cfg.Guru.JumpFirst {
 		batch.Command(`silent ll 1`)
 		batch.Command(`normal! zz`)
 		return batch.Execute()
 	}
 
 	var keepCursor bool
-	if config.GuruKeepCursor[mode] {
+	if cfg.Guru.KeepCursor[mode] {
 		keepCursor = true
 	}
 	return nvimutil.OpenLoclist(c.Nvim, w, loclist, keepCursor)
 }
 
+// guruQuery returns the guru query of the cursor position of the buffer b.
+// The buffer contents are used instead of the file if the buffer is modified.
+func (c *Command) guruQuery(b nvim.Buffer, eval *funcGuruEval, reflection bool) (*guru.Query, error) {
+	guruContext := c.buildContext.BuildContext()
+
+	// https://github.com/golang/tools/blob/master/cmd/guru/main.go
+	if eval.Modified != 0 {
+		overlay := make(map[string][]byte)
+
+		buf, err := c.Nvim.BufferLines(b, 0, -1, true)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		overlay[eval.File] = bytes.Join(buf, []byte{'\n'})
+		guruContext = buildutil.OverlayContext(guruContext, overlay)
+	}
+
+	return &guru.Query{
+		Pos:        fmt.Sprintf("%s:#%d", eval.File, eval.Offset),
+		Build:      guruContext,
+		Reflection: reflection,
+	}, nil
+}
+
 var errTypeAssertion = errors.New("type assertion error")
 
 func (c *Command) parseResult(ctx context.Context, mode string, res interface{}, cwd string) ([]*nvim.QuickfixError, error) {
@@ -357,17 +348,17 @@ func (c *Command) parseResult(ctx context.Context, mode string, res interface{},
 		if !ok {
 			return loclist, errTypeAssertion
 		}
-		for _, tt := range [][]serial.ImplementsType{v.AssignableTo, v.AssignableFromPtr, v.AssignableFrom} {
-			for _, t := range tt {
-				fname, line, col := nvimutil.SplitPos(t.Pos, cwd)
-				text := t.Kind + " " + t.Name
-				loclist = append(loclist, &nvim.QuickfixError{
-					FileName: fname,
-					LNum:     line,
-					Col:      col,
-					Text:     text,
-				})
+		for _, loc := range append(implementations(v), interfaces(v)...) {
+			if strings.Count(loc.pos, ":") < 2 {
+				continue // the built-in error type
 			}
+			fname, line, col := nvimutil.SplitPos(loc.pos, cwd)
+			loclist = append(loclist, &nvim.QuickfixError{
+				FileName: fname,
+				LNum:     line,
+				Col:      col,
+				Text:     loc.text,
+			})
 		}
 
 	case "peers":
@@ -519,3 +510,32 @@ func guruHelp(v *nvim.Nvim, mode string) error {
 		return nvimutil.Echoerr(v, "Invalid arguments")
 	}
 }
+
+// guruScope returns the analysis scope patterns of the project which contains file.
+func (c *Command) guruScope(file string) ([]string, error) {
+	var scopes []string
+	switch c.buildContext.Build.Tool {
+	case "go":
+		root := fs.FindVCSRoot(file)
+		root, _ = filepath.Abs(root)
+		scopes = []string{fs.ToWildcard(fs.TrimGoPath(root))}
+		if vendorDir := filepath.Join(root, "vendor"); fs.IsDirExist(vendorDir) {
+			scopes = append(scopes, "-"+fs.TrimGoPath(vendorDir))
+		}
+	case "gb":
+		root := c.buildContext.Build.ProjectRoot
+		var err error
+		scopes, err = fs.GbPackages(root)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get gb packages")
+		}
+		for i, pkg := range scopes {
+			scopes[i] = fs.ToWildcard(pkg)
+		}
+		if vendorDir := filepath.Join(root, "vendor"); fs.IsDirExist(vendorDir) {
+			scopes = append(scopes, "-"+fs.ToWildcard(vendorDir))
+		}
+	}
+
+	return scopes, nil
+}
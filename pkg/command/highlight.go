@@ -0,0 +1,304 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/zchee/nvim-go/pkg/internal/highlight"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// semanticDebounce is the delay of the re-highlighting after the last change of the buffer.
+const semanticDebounce = 200 * time.Millisecond
+
+// semantic represents the semantic highlighting state of the attached buffers.
+type semantic struct {
+	mu      sync.Mutex
+	nsID    int // the namespace of the extmarks, or 0 if not created yet
+	buffers map[nvim.Buffer]*semanticBuffer
+}
+
+// semanticBuffer represents the attached buffer of the semantic highlighting.
+type semanticBuffer struct {
+	mu    sync.Mutex
+	file  string
+	lines [][]byte                      // the current buffer lines which are tracked by nvim_buf_lines_event
+	tick  int                           // incremented on each change of lines
+	hls   map[int][]highlight.Highlight // the highlights which set to each line
+	dirty map[int]bool                  // the lines which extmarks are need to reset
+	timer *time.Timer
+}
+
+func (c *Command) cmdHighlight(ctx context.Context, file string) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.Highlight(ctx, file)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// Highlight toggles the semantic highlighting of the current buffer.
+func (c *Command) Highlight(pctx context.Context, file string) error {
+	ctx, span := monitoring.StartSpan(pctx, "Highlight")
+	defer span.End()
+
+	b := nvim.Buffer(c.buildContext.BufNr)
+
+	c.semantic.mu.Lock()
+	_, attached := c.semantic.buffers[b]
+	c.semantic.mu.Unlock()
+	if !attached {
+		return c.SemanticAttach(ctx, int(b), file)
+	}
+
+	if _, err := c.Nvim.DetachBuffer(b); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	c.semanticDetach(b)
+
+	return nil
+}
+
+// SemanticAttach attaches the buffer bufnr to highlight the identifiers by the semantic role.
+// The highlights are updated incrementally on nvim_buf_lines_event after attached.
+func (c *Command) SemanticAttach(pctx context.Context, bufnr int, file string) error {
+	_, span := monitoring.StartSpan(pctx, "SemanticAttach")
+	defer span.End()
+
+	b := nvim.Buffer(bufnr)
+
+	// CreateNamespace returns the same namespace for the same name
+	nsID, err := c.Nvim.CreateNamespace("nvim-go-semantic")
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	s := c.semantic
+	s.mu.Lock()
+	_, attached := s.buffers[b]
+	if !attached {
+		s.nsID = nsID
+		s.buffers[b] = &semanticBuffer{
+			file:  file,
+			hls:   make(map[int][]highlight.Highlight),
+			dirty: make(map[int]bool),
+		}
+	}
+	s.mu.Unlock()
+	if attached {
+		return nil
+	}
+
+	// the first nvim_buf_lines_event sends the whole buffer lines
+	if _, err := c.Nvim.AttachBuffer(b, true, map[string]interface{}{}); err != nil {
+		s.remove(b)
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// semanticDetach forgets the buffer b and clears its highlights.
+func (c *Command) semanticDetach(b nvim.Buffer) {
+	if c.semantic.remove(b) {
+		c.Nvim.ClearBufferNamespace(b, c.semantic.namespace(), 0, -1)
+	}
+}
+
+// SemanticLinesEvent handles the nvim_buf_lines_event notification of the attached buffer.
+// The lines [first, last) of the buffer are replaced by data, and last is -1 if the whole buffer is sent.
+func (c *Command) SemanticLinesEvent(ctx context.Context, b nvim.Buffer, first, last int, data []string) {
+	c.semantic.mu.Lock()
+	sb, ok := c.semantic.buffers[b]
+	c.semantic.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	if last < 0 || last > len(sb.lines) {
+		last = len(sb.lines)
+	}
+	if first > last {
+		first = last
+	}
+	lines := make([][]byte, len(data))
+	for i, line := range data {
+		lines[i] = []byte(line)
+	}
+	sb.lines = append(sb.lines[:first:first], append(lines, sb.lines[last:]...)...)
+	sb.tick++
+
+	// nvim moves the extmarks of the following lines, so shifts the highlights of them the same way
+	delta := len(data) - (last - first)
+	hls := make(map[int][]highlight.Highlight, len(sb.hls))
+	for line, lineHls := range sb.hls {
+		switch {
+		case line < first:
+			hls[line] = lineHls
+		case line >= last:
+			hls[line+delta] = lineHls
+		}
+	}
+	sb.hls = hls
+	dirty := make(map[int]bool, len(sb.dirty))
+	for line := range sb.dirty {
+		switch {
+		case line < first:
+			dirty[line] = true
+		case line >= last:
+			dirty[line+delta] = true
+		}
+	}
+	// the extmarks of the deleted lines are collapsed into the first line
+	for line := first; line < first+len(data) || line == first; line++ {
+		dirty[line] = true
+	}
+	sb.dirty = dirty
+
+	if sb.timer != nil {
+		sb.timer.Stop()
+	}
+	sb.timer = time.AfterFunc(semanticDebounce, func() {
+		if err := c.semanticUpdate(ctx, b, sb); err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	})
+}
+
+// SemanticDetachEvent handles the nvim_buf_detach_event notification such as the buffer is unloaded.
+func (c *Command) SemanticDetachEvent(b nvim.Buffer) {
+	c.semantic.remove(b)
+}
+
+// semanticUpdate re-highlights the lines of sb whose highlights are changed.
+func (c *Command) semanticUpdate(pctx context.Context, b nvim.Buffer, sb *semanticBuffer) error {
+	ctx, span := monitoring.StartSpan(pctx, "semanticUpdate")
+	defer span.End()
+
+	sb.mu.Lock()
+	file, tick := sb.file, sb.tick
+	src := nvimutil.ToByteSlice(sb.lines)
+	sb.mu.Unlock()
+
+	// the highlights are computed from the partial AST and types even if the source has the errors during editing
+	lineHls := make(map[int][]highlight.Highlight)
+	if f, ok := c.checker.check(ctx, file, src); ok {
+		for _, hl := range highlight.Highlights(f.Fset, f.File, f.Info) {
+			lineHls[hl.Line] = append(lineHls[hl.Line], hl)
+		}
+	}
+
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	if sb.tick != tick {
+		return nil // the buffer is changed during the type checking, and the next update is pending
+	}
+
+	changed := make(map[int]bool, len(sb.dirty))
+	for line := range sb.dirty {
+		changed[line] = true
+	}
+	for line, old := range sb.hls {
+		if !equalHighlights(old, lineHls[line]) {
+			changed[line] = true
+		}
+	}
+	for line, hls := range lineHls {
+		if !equalHighlights(sb.hls[line], hls) {
+			changed[line] = true
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	nsID := c.semantic.namespace()
+	var id int
+	batch := c.Nvim.NewBatch()
+	for line := range changed {
+		if line >= len(sb.lines) {
+			continue
+		}
+		batch.ClearBufferNamespace(b, nsID, line, line+1)
+		for _, hl := range lineHls[line] {
+			batch.SetBufferExtmark(b, nsID, hl.Line, hl.StartCol, map[string]interface{}{
+				"end_col":  hl.EndCol,
+				"hl_group": hl.Kind.Group(),
+			}, &id)
+		}
+	}
+	if err := batch.Execute(); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	sb.hls, sb.dirty = lineHls, make(map[int]bool)
+
+	return nil
+}
+
+// equalHighlights reports whether the highlights of a line are same.
+func equalHighlights(a, b []highlight.Highlight) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// newSemantic returns the new semantic.
+func newSemantic() *semantic {
+	return &semantic{buffers: make(map[nvim.Buffer]*semanticBuffer)}
+}
+
+// remove forgets the buffer b and stops its pending update, and reports whether b was attached.
+func (s *semantic) remove(b nvim.Buffer) bool {
+	s.mu.Lock()
+	sb, ok := s.buffers[b]
+	delete(s.buffers, b)
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	sb.mu.Lock()
+	if sb.timer != nil {
+		sb.timer.Stop()
+	}
+	sb.mu.Unlock()
+	return true
+}
+
+// namespace returns the namespace of the extmarks.
+func (s *semantic) namespace() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nsID
+}
@@ -9,21 +9,22 @@ import (
 	"context"
 	"fmt"
 	"go/ast"
-	"go/build"
 	"go/format"
 	"go/parser"
 	"go/token"
 	"go/types"
 	"log"
-	"path/filepath"
+	"text/template"
 
 	astmanip "github.com/motemen/go-astmanip"
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
 	"go.opencensus.io/trace"
 	"golang.org/x/tools/go/ast/astutil"
-	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/imports"
 
+	"github.com/zchee/nvim-go/pkg/config"
+	"github.com/zchee/nvim-go/pkg/internal/refactor"
 	"github.com/zchee/nvim-go/pkg/monitoring"
 	"github.com/zchee/nvim-go/pkg/nvimutil"
 )
@@ -40,7 +41,7 @@ func (c *Command) cmdIferr(ctx context.Context, file string) {
 	case err := <-errch:
 		switch e := err.(type) {
 		case error:
-			nvimutil.ErrorWrap(c.Nvim, e)
+			nvimutil.ErrorWrap(ctx, c.Nvim, e)
 		case []*nvim.QuickfixError:
 			c.errs.Store("Iferr", e)
 			errlist := make(map[string][]*nvim.QuickfixError)
@@ -49,72 +50,215 @@ func (c *Command) cmdIferr(ctx context.Context, file string) {
 				errlist[k] = append(errlist[k], v...)
 				return true
 			})
-			nvimutil.ErrorList(c.Nvim, errlist, true)
+			nvimutil.ErrorList(ctx, c.Nvim, errlist, true)
 		case nil:
 			// nothing to do
 		}
 	}
 }
 
-// Iferr automatically insert 'if err' Go idiom by parse the current buffer's Go abstract syntax tree(AST).
-func (c *Command) Iferr(ctx context.Context, file string) error {
-	var span *trace.Span
-	ctx, span = monitoring.StartSpan(ctx, "Iferr")
+// Iferr inserts 'if err' Go idiom after the error assignment statement which encloses the cursor.
+//
+// Iferr inserts only the lines of the 'if err' block to the buffer, so that the marks, folds and undo history
+// are kept and it can be used from the insert-mode mapping.
+func (c *Command) Iferr(pctx context.Context, file string) error {
+	ctx, span := monitoring.StartSpan(pctx, "Iferr")
 	defer span.End()
 
 	b := nvim.Buffer(c.buildContext.BufNr)
+	w := nvim.Window(c.buildContext.WinID)
+
 	buflines, err := c.Nvim.BufferLines(b, 0, -1, true)
 	if err != nil {
 		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
-		return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
+		return errors.WithStack(err)
+	}
+	cursor, err := c.Nvim.WindowCursor(w)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
 	}
 
-	conf := loader.Config{
-		ParserMode:  parser.ParseComments,
-		TypeChecker: types.Config{FakeImportC: true, DisableUnusedImportCheck: true},
-		Build:       &build.Default,
-		Cwd:         filepath.Dir(file),
-		AllowErrors: true,
+	tmpl, err := iferrTemplate(ctx)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return err
 	}
 
-	var src bytes.Buffer
-	src.Write(nvimutil.ToByteSlice(buflines))
+	f, err := loadRefactorFile(c.buildContext.BuildContext(), file, nvimutil.ToByteSlice(buflines))
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return err
+	}
 
-	f, err := conf.ParseFile(file, src.Bytes())
+	at, lines, needImports, err := iferrLines(f, tmpl, buflines, cursor[0])
 	if err != nil {
 		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
-		return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
+		return errors.Wrap(err, "GoIferr")
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	if err := c.Nvim.SetBufferLines(b, at, at, true, lines); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	if needImports {
+		if err := c.iferrImports(ctx, b, file); err != nil {
+			span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+			return err
+		}
 	}
 
-	conf.CreateFromFiles(file, f)
-	prog, err := conf.Load()
+	return nil
+}
+
+// IferrAll inserts 'if err' Go idiom after all of the unchecked error assignment statements in the current buffer.
+func (c *Command) IferrAll(pctx context.Context, file string) error {
+	ctx, span := monitoring.StartSpan(pctx, "IferrAll")
+	defer span.End()
+
+	b := nvim.Buffer(c.buildContext.BufNr)
+	buflines, err := c.Nvim.BufferLines(b, 0, -1, true)
 	if err != nil {
 		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
-		return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
+		return errors.WithStack(err)
 	}
 
-	// Reuse src variable
-	src.Reset()
+	tmpl, err := iferrTemplate(ctx)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return err
+	}
+
+	f, err := loadRefactorFile(c.buildContext.BuildContext(), file, nvimutil.ToByteSlice(buflines))
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return err
+	}
+
+	needImports, err := RewriteFile(f.Fset, f.File, *f.Info, tmpl)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return err
+	}
 
-	for _, pkg := range prog.InitialPackages() {
-		for _, f := range pkg.Files {
-			RewriteFile(prog.Fset, f, pkg.Info)
-			format.Node(&src, prog.Fset, f)
+	var src bytes.Buffer
+	if err := format.Node(&src, f.Fset, f.File); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	out := src.Bytes()
+	if needImports {
+		// add the imports of the packages which used by go#iferr#template
+		if out, err = imports.Process(file, out, &importsOptions); err != nil {
+			span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+			return errors.WithStack(err)
 		}
 	}
 
 	// format.Node() will added pointless newline
-	buf := bytes.TrimSuffix(src.Bytes(), []byte{'\n'})
-	return c.Nvim.SetBufferLines(b, 0, -1, true, nvimutil.ToBufferLines(buf))
+	return minUpdate(ctx, c.Nvim, b, buflines, nvimutil.ToBufferLines(bytes.TrimSuffix(out, []byte{'\n'})))
+}
+
+// iferrTemplate parses the go#iferr#template config, or returns nil if it is empty.
+func iferrTemplate(ctx context.Context) (*template.Template, error) {
+	t := config.FromContext(ctx).Iferr.Template
+	if t == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("iferr").Parse(t)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid go#iferr#template")
+	}
+	return tmpl, nil
+}
+
+// iferrImports adds the imports of the packages which used by go#iferr#template to the buffer.
+func (c *Command) iferrImports(ctx context.Context, b nvim.Buffer, file string) error {
+	buflines, err := c.Nvim.BufferLines(b, 0, -1, true)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	out, err := imports.Process(file, nvimutil.ToByteSlice(buflines), &importsOptions)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return minUpdate(ctx, c.Nvim, b, buflines, nvimutil.ToBufferLines(bytes.TrimSuffix(out, []byte{'\n'})))
+}
+
+// iferrLines returns the lines of the 'if err' block for the error assignment statement which encloses the line,
+// and the zero-based line index to insert them. iferrLines returns no lines if the error is already checked.
+func iferrLines(f *refactor.File, tmpl *template.Template, buflines [][]byte, line int) (at int, lines [][]byte, needImports bool, err error) {
+	assign, ok := cursorErrorAssign(f.Fset, f.File, *f.Info, line)
+	if !ok {
+		return 0, nil, false, errors.New("no error assignment at the cursor")
+	}
+	if isErrorChecked(f.File, assign) {
+		return 0, nil, false, nil
+	}
+
+	handle, needImports, err := makeErrorHandleStatement(assign, *f.Info, tmpl)
+	if err != nil {
+		return 0, nil, false, err
+	}
+	catch := makeErrorCatchStatement(assign.ident, handle)
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), catch); err != nil {
+		return 0, nil, false, errors.WithStack(err)
+	}
+
+	// indent the lines with the indent of the assignment
+	start, end := f.Fset.Position(assign.stmt.Pos()).Line, f.Fset.Position(assign.stmt.End()).Line
+	l := buflines[start-1]
+	indent := l[:len(l)-len(bytes.TrimLeft(l, " \t"))]
+	lines = nvimutil.ToBufferLines(buf.Bytes())
+	for i, l := range lines {
+		lines[i] = append(append([]byte{}, indent...), l...)
+	}
+
+	return end, lines, needImports, nil
+}
+
+// cursorErrorAssign returns the innermost error assignment statement which encloses the line.
+func cursorErrorAssign(fset *token.FileSet, f *ast.File, info types.Info, line int) (errorAssign, bool) {
+	var assign errorAssign
+	found := false
+	span := 0
+	for _, ea := range findErrorAssigns(fset, f, info) {
+		start, end := fset.Position(ea.stmt.Pos()).Line, fset.Position(ea.stmt.End()).Line
+		if start <= line && line <= end && (!found || end-start < span) {
+			assign, found, span = ea, true, end-start
+		}
+	}
+	return assign, found
+}
+
+// isErrorChecked reports whether the next statement of assign is the if statement which checks the error.
+func isErrorChecked(f *ast.File, assign errorAssign) bool {
+	next, ok := astmanip.NextSibling(f, assign.stmt).(*ast.IfStmt)
+	if !ok || next.Cond == nil {
+		return false
+	}
+	checked := false
+	ast.Inspect(next.Cond, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name == assign.ident.Name {
+			checked = true
+		}
+		return !checked
+	})
+	return checked
 }
 
 // The below code is copied from
 // https://github.com/motemen/go-iferr/blob/master/api.go
 
 var (
-	panicCode    = "panic(err.Error())"
-	logFatalCode = "log.Fatal(err)"
-	tFatalCode   = "t.Fatal(err)"
+	panicCode    = "panic(%s.Error())"
+	logFatalCode = "log.Fatal(%s)"
+	tFatalCode   = "t.Fatal(%s)"
 )
 
 var errorType types.Type
@@ -125,13 +269,50 @@ func init() {
 
 // errorAssign is an assign statement which involves an error-typed variable.
 type errorAssign struct {
-	outerFunc *ast.FuncDecl
-	stmt      *ast.AssignStmt
-	ident     *ast.Ident
+	funcName string
+	funcType *ast.FuncType
+	funcBody *ast.BlockStmt
+	stmt     *ast.AssignStmt
+	ident    *ast.Ident
+}
+
+// iferrTemplateData represents the data of the g:go#iferr#template template.
+type iferrTemplateData struct {
+	// Err is the name of the error variable.
+	Err string
+	// Func is the name of the enclosing function, or "func" if it is the function literal.
+	Func string
+	// Call is the called function of the assignment such as "os.Open", or the right-hand side expression if it is not a call.
+	Call string
 }
 
 // RewriteFile rewrites f with 'if err' Go idiom.
-func RewriteFile(fset *token.FileSet, f *ast.File, info types.Info) {
+//
+// If the enclosing function returns the error as the last result, the 'if err' block returns the zero values
+// of the other results and the error expression which generated from tmpl, or the error variable as is if tmpl is nil.
+// RewriteFile reports whether the generated code refers the packages which are not imported by f.
+func RewriteFile(fset *token.FileSet, f *ast.File, info types.Info, tmpl *template.Template) (needImports bool, err error) {
+	errAssigns := findErrorAssigns(fset, f, info)
+
+	for _, assign := range errAssigns {
+		assignLine := fset.Position(assign.stmt.Pos()).Line
+		next := astmanip.NextSibling(f, assign.stmt)
+		if next == nil || fset.Position(next.Pos()).Line-assignLine > 1 {
+			handle, missing, err := makeErrorHandleStatement(assign, info, tmpl)
+			if err != nil {
+				return false, err
+			}
+			needImports = needImports || missing
+			catch := makeErrorCatchStatement(assign.ident, handle)
+			astmanip.InsertStmtAfter(assign.funcBody, catch, assign.stmt)
+		}
+	}
+
+	return needImports, nil
+}
+
+// findErrorAssigns returns the assign statements which involve an error-typed variable in the functions.
+func findErrorAssigns(fset *token.FileSet, f *ast.File, info types.Info) []errorAssign {
 	errAssigns := []errorAssign{}
 
 	ast.Inspect(f, func(node ast.Node) bool {
@@ -148,70 +329,68 @@ func RewriteFile(fset *token.FileSet, f *ast.File, info types.Info) {
 					continue
 				}
 				if types.Identical(t, errorType) {
-					var funcDecl *ast.FuncDecl
+					// the innermost function, which may be the function literal
 					path, _ := astutil.PathEnclosingInterval(f, assign.Pos(), assign.End())
 					for _, p := range path {
-						var ok bool
-						funcDecl, ok = p.(*ast.FuncDecl)
-						if ok {
-							break
+						ea := errorAssign{stmt: assign, ident: ident}
+						switch p := p.(type) {
+						case *ast.FuncDecl:
+							ea.funcName, ea.funcType, ea.funcBody = p.Name.Name, p.Type, p.Body
+						case *ast.FuncLit:
+							ea.funcName, ea.funcType, ea.funcBody = "func", p.Type, p.Body
+						default:
+							continue
 						}
-					}
-					if funcDecl != nil {
-						errAssigns = append(errAssigns, errorAssign{
-							outerFunc: funcDecl,
-							stmt:      assign,
-							ident:     ident,
-						})
+						if ea.funcBody != nil {
+							errAssigns = append(errAssigns, ea)
+						}
+						break
 					}
 					break
 				}
 			}
 		}
 
-		return false
+		return true
 	})
 
-	for _, assign := range errAssigns {
-		assignLine := fset.Position(assign.stmt.Pos()).Line
-		next := astmanip.NextSibling(f, assign.stmt)
-		if next == nil || fset.Position(next.Pos()).Line-assignLine > 1 {
-			catch := makeErrorCatchStatement(
-				assign.ident, makeErrorHandleStatement(assign, info),
-			)
-			astmanip.InsertStmtAfter(assign.outerFunc.Body, catch, assign.stmt)
-		}
-	}
+	return errAssigns
 }
 
-func makeErrorHandleStatement(assign errorAssign, info types.Info) ast.Stmt {
-	if funcResults := assign.outerFunc.Type.Results; funcResults != nil {
-		errorPosInReturnTypes := -1
-		for i, rt := range funcResults.List {
-			if types.Identical(info.TypeOf(rt.Type), errorType) {
-				errorPosInReturnTypes = i
-				break
-			}
-		}
-		if errorPosInReturnTypes != -1 {
-			returnValues := make([]ast.Expr, len(funcResults.List))
-			for i, rt := range funcResults.List {
-				if i == errorPosInReturnTypes {
-					// return ..., err, ...
-					returnValues[i] = ast.NewIdent(assign.ident.Name)
-				} else {
-					// return ..., zv, ...
-					zv := makeZeroValue(rt.Type, info.TypeOf(rt.Type))
-					returnValues[i] = zv
+// makeErrorHandleStatement returns the statement which handles the error of assign, and reports whether
+// the statement refers the packages which are not imported.
+func makeErrorHandleStatement(assign errorAssign, info types.Info, tmpl *template.Template) (ast.Stmt, bool, error) {
+	funcScope := info.Scopes[assign.funcType]
+
+	if results := assign.funcType.Results; results != nil && len(results.List) > 0 {
+		last := results.List[len(results.List)-1]
+		if types.Identical(info.TypeOf(last.Type), errorType) {
+			// return zv..., err
+			var returnValues []ast.Expr
+			for _, rt := range results.List {
+				n := len(rt.Names)
+				if n == 0 {
+					n = 1
+				}
+				for i := 0; i < n; i++ {
+					// copy the type expression, since makeErrorCatchStatement resets the positions of the nodes
+					typ := astmanip.CopyNode(rt.Type).(ast.Expr)
+					returnValues = append(returnValues, makeZeroValue(typ, info.TypeOf(rt.Type)))
 				}
 			}
-			return &ast.ReturnStmt{Results: returnValues}
+
+			errExpr, missing, err := makeErrorExpr(assign, funcScope, tmpl)
+			if err != nil {
+				return nil, false, err
+			}
+			returnValues[len(returnValues)-1] = errExpr
+
+			return &ast.ReturnStmt{Results: returnValues}, missing, nil
 		}
 	}
 
 	var code string
 
-	funcScope := info.Scopes[assign.outerFunc.Type]
 	if tVar, ok := funcScope.Lookup("t").(*types.Var); ok {
 		if tVarType, ok := tVar.Type().(*types.Pointer); ok {
 			if tVarType, ok := tVarType.Elem().(*types.Named); ok {
@@ -223,7 +402,7 @@ func makeErrorHandleStatement(assign errorAssign, info types.Info) ast.Stmt {
 		}
 	}
 	if code == "" {
-		_, logObj := info.Scopes[assign.outerFunc.Type].LookupParent("log", token.NoPos)
+		_, logObj := funcScope.LookupParent("log", token.NoPos)
 		if logPkg, ok := logObj.(*types.PkgName); ok && logPkg.Imported().Path() == "log" {
 			code = logFatalCode
 		}
@@ -231,13 +410,57 @@ func makeErrorHandleStatement(assign errorAssign, info types.Info) ast.Stmt {
 	if code == "" {
 		code = panicCode
 	}
+	code = fmt.Sprintf(code, assign.ident.Name)
 
 	expr, err := parser.ParseExpr(code)
 	if err != nil {
 		panic(fmt.Sprintf("must not fail: %s while parsing %q", err, code))
 	}
 
-	return &ast.ExprStmt{X: expr}
+	return &ast.ExprStmt{X: expr}, false, nil
+}
+
+// makeErrorExpr returns the error expression which generated from tmpl, and reports whether
+// the expression refers the packages which are not visible in scope.
+func makeErrorExpr(assign errorAssign, scope *types.Scope, tmpl *template.Template) (ast.Expr, bool, error) {
+	if tmpl == nil {
+		return ast.NewIdent(assign.ident.Name), false, nil
+	}
+
+	data := iferrTemplateData{
+		Err:  assign.ident.Name,
+		Func: assign.funcName,
+	}
+	if len(assign.stmt.Rhs) > 0 {
+		rhs := assign.stmt.Rhs[0]
+		if call, ok := rhs.(*ast.CallExpr); ok {
+			rhs = call.Fun
+		}
+		data.Call = types.ExprString(rhs)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, false, errors.Wrap(err, "could not execute the go#iferr#template")
+	}
+	expr, err := parser.ParseExpr(buf.String())
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "go#iferr#template generated the invalid expression %q", buf.String())
+	}
+
+	missing := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Name != assign.ident.Name {
+				if _, obj := scope.LookupParent(id.Name, assign.stmt.Pos()); obj == nil {
+					missing = true
+				}
+			}
+		}
+		return !missing
+	})
+
+	return expr, missing, nil
 }
 
 var ifTemplate = `package _; func _() { if err != nil {} }`
@@ -251,6 +474,7 @@ func makeErrorCatchStatement(errName *ast.Ident, stmt ast.Stmt) *ast.IfStmt {
 
 	// must not fail
 	ifStmt := f.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.IfStmt)
+	ifStmt.Cond.(*ast.BinaryExpr).X = ast.NewIdent(errName.Name)
 	ifStmt.Body.List = []ast.Stmt{stmt}
 
 	astmanip.NormalizePos(ifStmt)
@@ -258,6 +482,7 @@ func makeErrorCatchStatement(errName *ast.Ident, stmt ast.Stmt) *ast.IfStmt {
 	return ifStmt
 }
 
+// makeZeroValue returns the zero value expression of the type t which written as e.
 func makeZeroValue(e ast.Expr, t types.Type) ast.Expr {
 	switch t := t.(type) {
 	case *types.Basic:
@@ -278,7 +503,8 @@ func makeZeroValue(e ast.Expr, t types.Type) ast.Expr {
 			return ast.NewIdent("false")
 		}
 
-		panic(fmt.Sprintf("makeZeroValue: unexpected basic type: %v", t))
+		// unsafe.Pointer, or the invalid type of the broken source
+		return ast.NewIdent("nil")
 
 	case *types.Tuple:
 		panic("makeZeroValue: unexpected *types.Tuple")
@@ -290,20 +516,8 @@ func makeZeroValue(e ast.Expr, t types.Type) ast.Expr {
 		return &ast.CompositeLit{Type: e}
 	case *types.Struct:
 		return &ast.CompositeLit{Type: e}
-
-	case *types.Map:
-		return ast.NewIdent("nil")
-	case *types.Signature:
-		return ast.NewIdent("nil")
-	case *types.Interface:
-		return ast.NewIdent("nil")
-	case *types.Pointer:
-		return ast.NewIdent("nil")
-	case *types.Slice:
-		return ast.NewIdent("nil")
-	case *types.Chan:
-		return ast.NewIdent("nil")
 	}
 
-	panic(fmt.Sprintf("makeZeroValue: unexpected type: %v", t))
+	// map, signature, interface, pointer, slice and chan
+	return ast.NewIdent("nil")
 }
@@ -0,0 +1,302 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"testing"
+	"text/template"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+func TestRewriteFile(t *testing.T) {
+	tests := []struct {
+		name            string
+		src             string
+		tmpl            string
+		want            string
+		wantNeedImports bool
+	}{
+		{
+			name: "ZeroValues",
+			src: `package p
+
+import "os"
+
+type T struct{}
+
+func f(name string) (a, b int, s string, t T, p *T, err error) {
+	fi, err := os.Stat(name)
+
+	_ = fi
+	return
+}
+`,
+			want: `package p
+
+import "os"
+
+type T struct{}
+
+func f(name string) (a, b int, s string, t T, p *T, err error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return 0, 0, "", T{}, nil, err
+	}
+
+	_ = fi
+	return
+}
+`,
+		},
+		{
+			name: "Template",
+			src: `package p
+
+import (
+	"fmt"
+	"os"
+)
+
+func open(name string) (*os.File, error) {
+	f, ferr := os.Open(name)
+
+	return f, nil
+}
+
+var _ = fmt.Sprint
+`,
+			tmpl: `fmt.Errorf("{{.Func}}: {{.Call}}: %w", {{.Err}})`,
+			want: `package p
+
+import (
+	"fmt"
+	"os"
+)
+
+func open(name string) (*os.File, error) {
+	f, ferr := os.Open(name)
+	if ferr != nil {
+		return nil, fmt.Errorf("open: os.Open: %w", ferr)
+	}
+
+	return f, nil
+}
+
+var _ = fmt.Sprint
+`,
+		},
+		{
+			name: "TemplateNeedImports",
+			src: `package p
+
+import "os"
+
+func remove(name string) error {
+	err := os.Remove(name)
+
+	return nil
+}
+`,
+			tmpl: `errors.Wrap({{.Err}}, "{{.Call}}")`,
+			want: `package p
+
+import "os"
+
+func remove(name string) error {
+	err := os.Remove(name)
+	if err != nil {
+		return errors.Wrap(err, "os.Remove")
+	}
+
+	return nil
+}
+`,
+			wantNeedImports: true,
+		},
+		{
+			name: "FuncLit",
+			src: `package p
+
+import "os"
+
+func f() {
+	_ = func() (int, error) {
+		err := os.Remove("")
+
+		return 0, nil
+	}
+}
+`,
+			want: `package p
+
+import "os"
+
+func f() {
+	_ = func() (int, error) {
+		err := os.Remove("")
+		if err != nil {
+			return 0, err
+		}
+
+		return 0, nil
+	}
+}
+`,
+		},
+		{
+			name: "NoErrorResult",
+			src: `package p
+
+import "os"
+
+func f() {
+	err := os.Remove("")
+
+	_ = 0
+}
+`,
+			want: `package p
+
+import "os"
+
+func f() {
+	err := os.Remove("")
+	if err != nil {
+		panic(err.Error())
+	}
+
+	_ = 0
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "p.go", tt.src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			info := types.Info{
+				Types:  make(map[ast.Expr]types.TypeAndValue),
+				Defs:   make(map[*ast.Ident]types.Object),
+				Uses:   make(map[*ast.Ident]types.Object),
+				Scopes: make(map[ast.Node]*types.Scope),
+			}
+			// ignore the "declared but not used" errors
+			conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil), Error: func(error) {}}
+			conf.Check("p", fset, []*ast.File{f}, &info)
+
+			var tmpl *template.Template
+			if tt.tmpl != "" {
+				tmpl = template.Must(template.New("iferr").Parse(tt.tmpl))
+			}
+			needImports, err := RewriteFile(fset, f, info, tmpl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if needImports != tt.wantNeedImports {
+				t.Errorf("needImports = %v, want %v", needImports, tt.wantNeedImports)
+			}
+
+			var buf bytes.Buffer
+			if err := format.Node(&buf, fset, f); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
+
+func TestIferrLines(t *testing.T) {
+	const src = `package p
+
+func g() (int, error) { return 0, nil }
+
+func f() (string, error) {
+	for {
+		n, err := g()
+		_ = n
+	}
+}
+
+func h() error {
+	_, err := g()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+`
+	tests := []struct {
+		name    string
+		line    int
+		wantAt  int
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "Insert",
+			line:   7,
+			wantAt: 7,
+			want: []string{
+				"\t\tif err != nil {",
+				"\t\t\treturn \"\", err",
+				"\t\t}",
+			},
+		},
+		{
+			name: "Checked",
+			line: 13,
+		},
+		{
+			name:    "NoAssign",
+			line:    8,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := loadRefactorFile(&build.Default, filepath.Join(t.TempDir(), "p.go"), []byte(src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			at, lines, _, err := iferrLines(f, nil, nvimutil.ToBufferLines([]byte(src)), tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("iferrLines() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if at != tt.wantAt {
+				t.Errorf("iferrLines() at = %d, want %d", at, tt.wantAt)
+			}
+			var got []string
+			for _, l := range lines {
+				got = append(got, string(l))
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/zchee/nvim-go/pkg/internal/pkgindex"
+	"github.com/zchee/nvim-go/pkg/internal/refactor"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+type cmdImplEval struct {
+	Cwd  string `msgpack:",array"`
+	File string
+}
+
+func (c *Command) cmdImpl(ctx context.Context, args []string, eval *cmdImplEval) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.Impl(ctx, args, eval)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// Impl generates the stub methods of the interface args[len(args)-1] for the receiver args[:len(args)-1],
+// such as ":GoImpl r *Reader io.Reader", and inserts them after the declaration of the receiver type.
+func (c *Command) Impl(pctx context.Context, args []string, eval *cmdImplEval) error {
+	ctx, span := monitoring.StartSpan(pctx, "Impl")
+	defer span.End()
+
+	if len(args) < 2 {
+		err := errors.New("GoImpl: usage: GoImpl {receiver} {interface}")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return err
+	}
+	recv, iface := strings.Join(args[:len(args)-1], " "), args[len(args)-1]
+
+	b := nvim.Buffer(c.buildContext.BufNr)
+	buflines, err := c.Nvim.BufferLines(b, 0, -1, true)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	src := nvimutil.ToByteSlice(buflines)
+
+	pkgPath, name := resolveInterface(eval.File, src, iface)
+	var paths []string
+	if pkgPath != "" {
+		paths = append(paths, pkgPath)
+	}
+	f, prog, err := loadRefactorProgram(c.buildContext.BuildContext(), eval.File, src, paths...)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoImpl")
+	}
+
+	pkg := f.Pkg
+	if pkgPath != "" {
+		info := prog.Package(pkgPath)
+		if info == nil {
+			err := errors.Errorf("GoImpl: could not load package %s", pkgPath)
+			span.SetStatus(trace.Status{Code: trace.StatusCodeNotFound, Message: err.Error()})
+			return err
+		}
+		pkg = info.Pkg
+	}
+	tn, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok || !types.IsInterface(tn.Type()) {
+		err := errors.Errorf("GoImpl: %s is not an interface type", iface)
+		span.SetStatus(trace.Status{Code: trace.StatusCodeNotFound, Message: err.Error()})
+		return err
+	}
+
+	out, err := refactor.Impl(f, recv, tn)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoImpl")
+	}
+
+	return minUpdate(ctx, c.Nvim, b, buflines, nvimutil.ToBufferLines(bytes.TrimSuffix(out, []byte{'\n'})))
+}
+
+// resolveInterface returns the import path and the name of the interface such as "io.Reader" or "net/http.Handler".
+// The package name which imported by the file is resolved to its import path, and the import path is empty
+// if the interface is declared in the current package.
+func resolveInterface(file string, src []byte, iface string) (pkgPath, name string) {
+	i := strings.LastIndex(iface, ".")
+	if i < 0 {
+		return "", iface
+	}
+	pkgPath, name = iface[:i], iface[i+1:]
+
+	if !strings.Contains(pkgPath, "/") {
+		if f, err := parser.ParseFile(token.NewFileSet(), file, src, parser.ImportsOnly); err == nil {
+			for _, imp := range f.Imports {
+				p, err := strconv.Unquote(imp.Path.Value)
+				if err != nil {
+					continue
+				}
+				if (imp.Name != nil && imp.Name.Name == pkgPath) || (imp.Name == nil && path.Base(p) == pkgPath) {
+					return p, name
+				}
+			}
+		}
+	}
+
+	return pkgPath, name
+}
+
+func (c *Command) cmdImplComplete(ctx context.Context, a *nvim.CommandCompletionArgs, cwd string) ([]string, error) {
+	var items []string
+	for _, iface := range c.interfaceIndex(ctx, cwd) {
+		if strings.HasPrefix(iface, a.ArgLead) {
+			items = append(items, iface)
+		}
+	}
+
+	return items, nil
+}
+
+// interfaceIndex returns the exported interface types such as "io.Reader" in the standard library
+// and the Go module of dir. The interfaces are read from the package index, which is refreshed by the refresh policy.
+func (c *Command) interfaceIndex(ctx context.Context, dir string) []string {
+	roots := c.packageRoots(dir, false)
+	c.refreshPackageRoots(ctx, dir, roots)
+
+	var ifaces []string
+	for _, root := range roots {
+		for _, pkg := range c.pkgIndex.Under(root.Path) {
+			if isInternalPath(pkg.ImportPath) {
+				continue
+			}
+			for _, sym := range pkg.Symbols {
+				if sym.Kind == pkgindex.Type && sym.Detail == "interface" {
+					ifaces = append(ifaces, pkg.ImportPath+"."+sym.Name)
+				}
+			}
+		}
+	}
+	sort.Strings(ifaces)
+
+	return ifaces
+}
+
+// isInternalPath reports whether the import path has the "internal" or "vendor" element.
+func isInternalPath(pkgPath string) bool {
+	for _, elem := range strings.Split(pkgPath, "/") {
+		if elem == "internal" || elem == "vendor" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"go/build"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+
+	"github.com/zchee/nvim-go/pkg/internal/pkgindex"
+	"github.com/zchee/nvim-go/pkg/internal/testutil"
+	"github.com/zchee/nvim-go/pkg/logger"
+)
+
+func TestCommand_interfaceIndex(t *testing.T) {
+	root := testutil.TempModule(t, "example.com/foo", map[string]string{
+		"foo.go": "package foo\n\ntype Foo interface{ Foo() }\n\ntype T struct{}\n",
+	})
+
+	ctx := logger.NewContext(context.Background(), zap.NewNop())
+	c := &Command{Cache: &Cache{pkgIndex: pkgindex.New(filepath.Join(t.TempDir(), "pkgindex.json"))}}
+	// skip indexing the standard library
+	c.pkgIndexRefresh.stale(filepath.Join(build.Default.GOROOT, "src"), root)
+
+	if diff := cmp.Diff([]string{"example.com/foo.Foo"}, c.interfaceIndex(ctx, root)); diff != "" {
+		t.Fatalf("interfaceIndex: (-want +got)\n%s", diff)
+	}
+
+	// the interface which is added after indexed is found after the file is saved
+	testutil.WriteFile(t, filepath.Join(root, "bar.go"), "package foo\n\ntype Bar interface{ Bar() }\n")
+	c.pkgIndexRefresh.invalidate(filepath.Join(root, "bar.go"))
+	if diff := cmp.Diff([]string{"example.com/foo.Bar", "example.com/foo.Foo"}, c.interfaceIndex(ctx, root)); diff != "" {
+		t.Fatalf("interfaceIndex after saved: (-want +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"golang.org/x/tools/cmd/guru/serial"
+
+	"github.com/zchee/nvim-go/pkg/config"
+	"github.com/zchee/nvim-go/pkg/internal/guru"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// implementsBufferName buffer name of the GoImplementations and GoInterfaces picker.
+const implementsBufferName = "__GO_IMPLEMENTS__"
+
+// implementsLocation represents a type or a method of the implements relation.
+type implementsLocation struct {
+	text string
+	pos  string // the position of the declaration, in the form of "file:line:col"
+}
+
+// implementations returns the types which implement the queried interface type, or the methods of them
+// if the query is the interface method.
+func implementations(v *serial.Implements) []implementsLocation {
+	if v.Method != nil {
+		return methodLocations(v.AssignableToMethod)
+	}
+	return typeLocations(v.AssignableTo)
+}
+
+// interfaces returns the interfaces which the queried type implements, or the methods of them
+// if the query is the concrete method.
+func interfaces(v *serial.Implements) []implementsLocation {
+	if v.Method != nil {
+		return append(methodLocations(v.AssignableFromPtrMethod), methodLocations(v.AssignableFromMethod)...)
+	}
+	return append(typeLocations(v.AssignableFromPtr), typeLocations(v.AssignableFrom)...)
+}
+
+// typeLocations returns the locations of the types.
+func typeLocations(types []serial.ImplementsType) []implementsLocation {
+	var locs []implementsLocation
+	for _, t := range types {
+		locs = append(locs, implementsLocation{text: t.Kind + " " + t.Name, pos: t.Pos})
+	}
+	return locs
+}
+
+// methodLocations returns the locations of the methods, except the blank methods of the types which lack the method.
+func methodLocations(methods []serial.DescribeMethod) []implementsLocation {
+	var locs []implementsLocation
+	for _, m := range methods {
+		if m.Pos == "" {
+			continue
+		}
+		locs = append(locs, implementsLocation{text: m.Name, pos: m.Pos})
+	}
+	return locs
+}
+
+// implementsItems returns the picker items of the locations. The locations which have no position,
+// such as the built-in error type, are excluded.
+func implementsItems(locs []implementsLocation, cwd string) []pickerItem {
+	var items []pickerItem
+	for _, loc := range locs {
+		// the position is "file:line:col", and the file can contain ':'
+		i := strings.LastIndexByte(loc.pos, ':')
+		if i < 0 {
+			continue
+		}
+		j := strings.LastIndexByte(loc.pos[:i], ':')
+		if j < 0 {
+			continue
+		}
+		line, err1 := strconv.Atoi(loc.pos[j+1 : i])
+		col, err2 := strconv.Atoi(loc.pos[i+1:])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		file, name := loc.pos[:j], loc.pos[:j]
+		if rel, err := filepath.Rel(cwd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+		items = append(items, pickerItem{
+			text: fmt.Sprintf("%s  %s:%d", loc.text, name, line),
+			file: file,
+			line: line,
+			col:  col,
+		})
+	}
+	return items
+}
+
+func (c *Command) cmdImplementations(ctx context.Context, eval *funcGuruEval) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.Implementations(ctx, eval)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// Implementations jumps to the type which implements the interface type at the cursor,
+// or to the method which implements the interface method at the cursor.
+// If there are several implementations, Implementations opens the picker of them.
+func (c *Command) Implementations(pctx context.Context, eval *funcGuruEval) error {
+	ctx, span := monitoring.StartSpan(pctx, "Implementations")
+	defer span.End()
+
+	v, err := c.guruImplements(ctx, eval)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoImplementations")
+	}
+	if err := c.jumpOrPick(implementsItems(implementations(v), eval.Cwd)); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoImplementations")
+	}
+
+	return nil
+}
+
+func (c *Command) cmdInterfaces(ctx context.Context, eval *funcGuruEval) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.Interfaces(ctx, eval)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// Interfaces jumps to the interface which the type at the cursor implements,
+// or to the interface method which the method at the cursor implements.
+// If there are several interfaces, Interfaces opens the picker of them.
+func (c *Command) Interfaces(pctx context.Context, eval *funcGuruEval) error {
+	ctx, span := monitoring.StartSpan(pctx, "Interfaces")
+	defer span.End()
+
+	v, err := c.guruImplements(ctx, eval)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoInterfaces")
+	}
+	if err := c.jumpOrPick(implementsItems(interfaces(v), eval.Cwd)); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoInterfaces")
+	}
+
+	return nil
+}
+
+// guruImplements runs the guru implements query of the cursor position.
+func (c *Command) guruImplements(ctx context.Context, eval *funcGuruEval) (*serial.Implements, error) {
+	cfg := config.FromContext(ctx)
+
+	query, err := c.guruQuery(nvim.Buffer(c.buildContext.BufNr), eval, cfg.Guru.Reflection)
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := c.guruScope(eval.File)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if c.buildContext.Build.Tool == "go" {
+		os.Unsetenv("GO111MODULE")
+	}
+	query.Scope = append(query.Scope, scopes...)
+
+	var res *serial.Implements
+	query.Output = func(fset *token.FileSet, qr guru.QueryResult) {
+		res, _ = qr.Result(fset).(*serial.Implements)
+	}
+
+	nvimutil.EchoProgress(c.Nvim, "Guru", "analysing implements")
+	defer nvimutil.ClearMsg(c.Nvim)
+	if err := guru.Run("implements", query); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if res == nil {
+		return nil, errTypeAssertion
+	}
+
+	return res, nil
+}
+
+// jumpOrPick jumps to the item if only one, or opens the picker of the items.
+func (c *Command) jumpOrPick(items []pickerItem) error {
+	switch len(items) {
+	case 0:
+		return errors.New("not found")
+	case 1:
+		return c.jumpTo(items[0])
+	default:
+		return c.openPicker(implementsBufferName, items)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/cmd/guru/serial"
+)
+
+func TestImplementsItems(t *testing.T) {
+	const cwd = "/src/example.com/m"
+
+	tests := []struct {
+		name string
+		res  *serial.Implements
+		want []pickerItem
+		impl bool
+	}{
+		{
+			name: "Implementations",
+			res: &serial.Implements{
+				T: serial.ImplementsType{Name: "m.Reader", Pos: cwd + "/reader.go:3:6", Kind: "interface"},
+				AssignableTo: []serial.ImplementsType{
+					{Name: "*m.file", Pos: cwd + "/file.go:5:6", Kind: "pointer"},
+				},
+			},
+			impl: true,
+			want: []pickerItem{
+				{text: "pointer *m.file  file.go:5", file: cwd + "/file.go", line: 5, col: 6},
+			},
+		},
+		{
+			name: "InterfaceMethodImplementations",
+			res: &serial.Implements{
+				Method:       &serial.DescribeMethod{Name: "method (m.Reader) Read()", Pos: cwd + "/reader.go:4:2"},
+				AssignableTo: []serial.ImplementsType{{Name: "*m.file"}, {Name: "m.other"}},
+				AssignableToMethod: []serial.DescribeMethod{
+					{Name: "method (*m.file) Read()", Pos: cwd + "/file.go:7:17"},
+					{}, // m.other lacks the method
+				},
+			},
+			impl: true,
+			want: []pickerItem{
+				{text: "method (*m.file) Read()  file.go:7", file: cwd + "/file.go", line: 7, col: 17},
+			},
+		},
+		{
+			name: "Interfaces",
+			res: &serial.Implements{
+				AssignableFromPtr: []serial.ImplementsType{{Name: "io.Reader", Pos: "/go/src/io/io.go:83:6", Kind: "interface"}},
+				AssignableFrom:    []serial.ImplementsType{{Name: "error", Pos: "-", Kind: "interface"}},
+			},
+			want: []pickerItem{
+				{text: "interface io.Reader  /go/src/io/io.go:83", file: "/go/src/io/io.go", line: 83, col: 6},
+			},
+		},
+		{
+			name: "ConcreteMethodInterfaces",
+			res: &serial.Implements{
+				Method:                  &serial.DescribeMethod{Name: "method (*m.file) Read()", Pos: cwd + "/file.go:7:17"},
+				AssignableFromPtr:       []serial.ImplementsType{{Name: "io.Reader"}, {Name: "m.Reader"}},
+				AssignableFromPtrMethod: []serial.DescribeMethod{{Name: "method (io.Reader) Read()", Pos: "/go/src/io/io.go:84:2"}, {Name: "method (m.Reader) Read()", Pos: cwd + "/reader.go:4:2"}},
+			},
+			want: []pickerItem{
+				{text: "method (io.Reader) Read()  /go/src/io/io.go:84", file: "/go/src/io/io.go", line: 84, col: 2},
+				{text: "method (m.Reader) Read()  reader.go:4", file: cwd + "/reader.go", line: 4, col: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			locs := interfaces(tt.res)
+			if tt.impl {
+				locs = implementations(tt.res)
+			}
+			got := implementsItems(locs, cwd)
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(pickerItem{})); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"go.uber.org/zap"
+
+	"github.com/zchee/nvim-go/pkg/fs"
+	"github.com/zchee/nvim-go/pkg/internal/complete"
+	"github.com/zchee/nvim-go/pkg/internal/pkgindex"
+	"github.com/zchee/nvim-go/pkg/logger"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// refreshPackageIndex refreshes the roots of GOROOT, GOPATH and the module cache in the package index
+// which are stale for the Go module of dir. dir is empty if not in a Go module.
+func (c *Command) refreshPackageIndex(ctx context.Context, dir string) {
+	c.refreshPackageRoots(ctx, dir, pkgindex.DefaultRoots())
+}
+
+// refreshPackageRoots loads the package index from the disk once per process, and refreshes the roots of it
+// which are stale for the Go module of dir.
+// The package index is shared with the other clients, so the roots are refreshed by the refresh policy of the Cache.
+func (c *Command) refreshPackageRoots(pctx context.Context, dir string, roots []pkgindex.Root) {
+	ctx, span := monitoring.StartSpan(pctx, "refreshPackageIndex")
+	defer span.End()
+
+	log := logger.FromContext(ctx)
+	c.pkgIndexLoad.Do(func() {
+		if err := c.pkgIndex.Load(); err != nil {
+			log.Error("refreshPackageIndex", zap.Error(err))
+		}
+	})
+
+	var modRoot string
+	if dir != "" {
+		modRoot, _ = fs.FindModuleRoot(dir)
+	}
+	var stale []pkgindex.Root
+	for _, root := range roots {
+		if c.pkgIndexRefresh.stale(root.Path, modRoot) {
+			stale = append(stale, root)
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
+	if err := c.pkgIndex.Refresh(stale); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		log.Error("refreshPackageIndex", zap.Error(err))
+	}
+}
+
+// RefreshIndexes invalidates the indexes which contain the saved file, and refreshes the package index in background.
+func (c *Command) RefreshIndexes(ctx context.Context, file string) {
+	c.pkgIndexRefresh.invalidate(file)
+	c.symbols.Range(func(_, v interface{}) bool {
+		v.(*symbolsIndex).refresh.invalidate(file)
+		return true
+	})
+	go c.refreshPackageIndex(ctx, filepath.Dir(file))
+}
+
+type cmdImportEval struct {
+	Cwd  string `msgpack:",array"`
+	File string
+}
+
+func (c *Command) cmdImport(ctx context.Context, args []string, eval *cmdImportEval) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.Import(ctx, args, eval)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// Import adds the import of the import path args[0] to the current buffer.
+func (c *Command) Import(pctx context.Context, args []string, eval *cmdImportEval) error {
+	_, span := monitoring.StartSpan(pctx, "Import")
+	defer span.End()
+
+	if err := c.addBufferImport(nvim.Buffer(c.buildContext.BufNr), args[0]); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoImport")
+	}
+
+	return nil
+}
+
+func (c *Command) cmdImportComplete(ctx context.Context, a *nvim.CommandCompletionArgs, eval *cmdImportEval) ([]string, error) {
+	var items []string
+	for _, pkg := range c.completePackages(ctx, eval.Cwd, eval.File) {
+		if strings.HasPrefix(pkg.path, a.ArgLead) {
+			items = append(items, pkg.path)
+		}
+	}
+	sort.Strings(items)
+
+	return items, nil
+}
+
+// resolveImports adds the imports of the unresolved package names of src which is the file in dir.
+// The package is resolved by the package index if only one importable package exports all the selected names,
+// and the rest of the unresolved package names are left to goimports.
+func (c *Command) resolveImports(ctx context.Context, dir, file string, src []byte) []byte {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, 0)
+	if err != nil {
+		return src
+	}
+	unresolved := unresolvedSelectors(f)
+	for _, sibling := range packageSiblings(fset, file, f.Name.Name) {
+		for name := range sibling.Scope.Objects {
+			delete(unresolved, name) // declared in the other file
+		}
+	}
+	if len(unresolved) == 0 {
+		return src
+	}
+
+	importable := make(map[string]packageDir)
+	for _, pkg := range c.completePackages(ctx, dir, file) {
+		importable[pkg.path] = pkg
+	}
+	var modPath string
+	if root, ok := fs.FindModuleRoot(dir); ok {
+		modPath, _ = fs.ModulePath(root)
+	}
+
+	for name, sels := range unresolved {
+		seen := make(map[string]bool)
+		var candidates []*pkgindex.Package
+		add := func(pkg *pkgindex.Package) {
+			if !seen[pkg.ImportPath] && pkg.Name == name && exportsAll(pkg, sels) {
+				seen[pkg.ImportPath] = true
+				candidates = append(candidates, pkg)
+			}
+		}
+		for _, pkg := range c.pkgIndex.Exports(sels[0]) {
+			if _, ok := importable[pkg.ImportPath]; ok {
+				add(pkg)
+			}
+		}
+		// the packages of the current module are not indexed by the background refresh
+		for _, pkg := range importable {
+			if modPath != "" && strings.HasPrefix(pkg.path+"/", modPath+"/") && c.packageName(pkg) == name {
+				if indexed, err := c.pkgIndex.Lookup(pkg.path, pkg.dir); err == nil {
+					add(indexed)
+				}
+			}
+		}
+
+		pkgindex.SortPackages(candidates)
+		if len(candidates) == 0 || (len(candidates) > 1 && candidates[0].Std == candidates[1].Std) {
+			continue // ambiguous
+		}
+		if out, err := addImport(src, candidates[0].ImportPath); err == nil {
+			src = out
+		}
+	}
+
+	return src
+}
+
+// unresolvedSelectors returns the selected names of each unresolved identifier of the selector expressions in f,
+// such as "Marshal" of "json.Marshal" without the import of "encoding/json".
+func unresolvedSelectors(f *ast.File) map[string][]string {
+	// the parser does not resolve the package names of the imports
+	imported := make(map[string]bool, len(f.Imports))
+	for _, spec := range f.Imports {
+		if spec.Name != nil {
+			imported[spec.Name.Name] = true
+			continue
+		}
+		p, _ := strconv.Unquote(spec.Path.Value)
+		imported[complete.ImportPathToName(p)] = true
+	}
+
+	unresolved := make(map[*ast.Ident]bool, len(f.Unresolved))
+	for _, id := range f.Unresolved {
+		if !imported[id.Name] {
+			unresolved[id] = true
+		}
+	}
+
+	sels := make(map[string][]string)
+	ast.Inspect(f, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if id, ok := sel.X.(*ast.Ident); ok && unresolved[id] {
+			sels[id.Name] = append(sels[id.Name], sel.Sel.Name)
+		}
+		return true
+	})
+	return sels
+}
+
+// exportsAll reports whether pkg exports all of the names.
+func exportsAll(pkg *pkgindex.Package, names []string) bool {
+	for _, name := range names {
+		if _, ok := pkg.Symbol(name); !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnresolvedSelectors(t *testing.T) {
+	const src = `package p
+
+import "os"
+
+type T struct{ f int }
+
+func f(t T) {
+	os.Exit(t.f)
+	_ = json.Marshal
+	_, _ = json.Unmarshal, bytes.NewReader
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"json":  {"Marshal", "Unmarshal"},
+		"bytes": {"NewReader"},
+	}
+	if diff := cmp.Diff(want, unresolvedSelectors(f)); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
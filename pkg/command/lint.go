@@ -38,7 +38,7 @@ func (c *Command) cmdLint(ctx context.Context, args []string, file string) {
 	case err := <-errch:
 		switch e := err.(type) {
 		case error:
-			nvimutil.ErrorWrap(c.Nvim, e)
+			nvimutil.ErrorWrap(ctx, c.Nvim, e)
 		case []*nvim.QuickfixError:
 			c.errs.Store("Lint", e)
 			errlist := make(map[string][]*nvim.QuickfixError)
@@ -47,7 +47,7 @@ func (c *Command) cmdLint(ctx context.Context, args []string, file string) {
 				errlist[k] = append(errlist[k], v...)
 				return true
 			})
-			nvimutil.ErrorList(c.Nvim, errlist, true)
+			nvimutil.ErrorList(ctx, c.Nvim, errlist, true)
 		case nil:
 			// nothing to do
 		}
@@ -68,14 +68,16 @@ func (c *Command) Lint(ctx context.Context, args []string, file string) interfac
 	ctx, span = monitoring.StartSpan(ctx, "Lint")
 	defer span.End()
 
+	cfg := config.FromContext(ctx)
+
 	var errlist []*nvim.QuickfixError
 	var err error
 
 	switch len(args) {
 	case 0:
-		switch lintMode(config.GolintMode) {
+		switch lintMode(cfg.Lint.GolintMode) {
 		case current:
-			errlist, err = c.lintDir(filepath.Dir(file))
+			errlist, err = c.lintDir(ctx, filepath.Dir(file))
 		case root:
 			var rootDir string
 			switch c.buildContext.Build.Tool {
@@ -89,7 +91,7 @@ func (c *Command) Lint(ctx context.Context, args []string, file string) interfac
 				rootDir = filepath.Base(c.buildContext.Build.ProjectRoot)
 			}
 			for _, pkgname := range importPaths([]string{rootDir + "/..."}) {
-				errors, err := c.lintPackage(pkgname)
+				errors, err := c.lintPackage(ctx, pkgname)
 				if err != nil {
 					return err
 				}
@@ -103,16 +105,16 @@ func (c *Command) Lint(ctx context.Context, args []string, file string) interfac
 		}
 		switch {
 		case fs.IsDir(path):
-			errlist, err = c.lintDir(path)
+			errlist, err = c.lintDir(ctx, path)
 		case fs.IsExist(path):
-			errlist, err = c.lintFiles(path)
+			errlist, err = c.lintFiles(ctx, path)
 		default:
 			for _, pkgname := range importPaths(args) {
-				errlist, err = c.lintPackage(pkgname)
+				errlist, err = c.lintPackage(ctx, pkgname)
 			}
 		}
 	default: // more than 2
-		errlist, err = c.lintFiles(args...)
+		errlist, err = c.lintFiles(ctx, args...)
 	}
 
 	if err != nil {
@@ -137,7 +139,9 @@ func (c *Command) cmdLintComplete(ctx context.Context, a *nvim.CommandCompletion
 // ----------------------------------------------------------------------------
 // The below code is based by github.com/golang/lint/golint/golint.go
 
-func (c *Command) lintFiles(filenames ...string) ([]*nvim.QuickfixError, error) {
+func (c *Command) lintFiles(ctx context.Context, filenames ...string) ([]*nvim.QuickfixError, error) {
+	cfg := config.FromContext(ctx)
+
 	files := make(map[string][]byte)
 	for _, filename := range filenames {
 		src, err := ioutil.ReadFile(filename)
@@ -150,20 +154,20 @@ func (c *Command) lintFiles(filenames ...string) ([]*nvim.QuickfixError, error)
 	l := new(lint.Linter)
 	ps, err := l.LintFiles(files)
 	if err != nil {
-		return nil, nvimutil.ErrorWrap(c.Nvim, err)
+		return nil, nvimutil.ErrorWrap(ctx, c.Nvim, err)
 	}
 
 	var cwdRes interface{}
 	if err := c.Nvim.Eval("getcwd()", &cwdRes); err != nil {
-		return nil, nvimutil.ErrorWrap(c.Nvim, err)
+		return nil, nvimutil.ErrorWrap(ctx, c.Nvim, err)
 	}
 	cwd := cwdRes.(string)
 
 	var errlist []*nvim.QuickfixError
 	for _, p := range ps {
-		if p.Confidence >= config.GolintMinConfidence {
+		if p.Confidence >= cfg.Lint.GolintMinConfidence {
 			file := p.Position.Filename
-			if contain(file, config.GolintIgnore) {
+			if contain(file, cfg.Lint.GolintIgnore) {
 				continue
 			}
 			frel, err := filepath.Rel(cwd, file)
@@ -192,17 +196,17 @@ func contain(s string, ignore []string) bool {
 	return false
 }
 
-func (c *Command) lintDir(dirname string) ([]*nvim.QuickfixError, error) {
+func (c *Command) lintDir(ctx context.Context, dirname string) ([]*nvim.QuickfixError, error) {
 	pkg, err := build.ImportDir(dirname, 0)
-	return c.lintImportedPackage(pkg, err)
+	return c.lintImportedPackage(ctx, pkg, err)
 }
 
-func (c *Command) lintPackage(pkgname string) ([]*nvim.QuickfixError, error) {
+func (c *Command) lintPackage(ctx context.Context, pkgname string) ([]*nvim.QuickfixError, error) {
 	pkg, err := build.Import(pkgname, ".", 0)
-	return c.lintImportedPackage(pkg, err)
+	return c.lintImportedPackage(ctx, pkg, err)
 }
 
-func (c *Command) lintImportedPackage(pkg *build.Package, err error) ([]*nvim.QuickfixError, error) {
+func (c *Command) lintImportedPackage(ctx context.Context, pkg *build.Package, err error) ([]*nvim.QuickfixError, error) {
 	if err != nil {
 		if _, nogo := err.(*build.NoGoError); nogo {
 			// Don't complain if the failure is due to no Go source files.
@@ -222,7 +226,7 @@ func (c *Command) lintImportedPackage(pkg *build.Package, err error) ([]*nvim.Qu
 	}
 	// TODO(dsymonds): Do foo_test too (pkg.XTestGoFiles)
 
-	return c.lintFiles(files...)
+	return c.lintFiles(ctx, files...)
 }
 
 // ----------------------------------------------------------------------------
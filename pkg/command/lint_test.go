@@ -84,7 +84,7 @@ func TestCommand_Lint(t *testing.T) {
 			t.Parallel()
 
 			ctx := tt.fields.ctx
-			c := NewCommand(ctx, tt.fields.Nvim, tt.fields.bctxt)
+			c := NewCommand(ctx, tt.fields.Nvim, tt.fields.bctxt, NewCache())
 			c.Nvim.SetCurrentDirectory(filepath.Dir(tt.args.file))
 
 			err := c.Lint(ctx, tt.args.args, tt.args.file)
@@ -176,7 +176,7 @@ func TestCommand_cmdLintComplete(t *testing.T) {
 			t.Parallel()
 
 			ctx := tt.fields.ctx
-			c := NewCommand(ctx, tt.fields.Nvim, tt.fields.bctxt)
+			c := NewCommand(ctx, tt.fields.Nvim, tt.fields.bctxt, NewCache())
 			gotFilelist, err := c.cmdLintComplete(ctx, tt.args.a, tt.args.cwd)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("%q. Commands.cmdLintComplete(%v, %v) error = %v, wantErr %v", tt.name, tt.args.a, tt.args.cwd, err, tt.wantErr)
@@ -33,7 +33,7 @@ func (c *Command) cmdMetalinter(ctx context.Context, cwd string) {
 	case err := <-errch:
 		switch e := err.(type) {
 		case error:
-			nvimutil.ErrorWrap(c.Nvim, e)
+			nvimutil.ErrorWrap(ctx, c.Nvim, e)
 		case nil:
 			// nothing to do
 		}
@@ -55,6 +55,8 @@ func (c *Command) Metalinter(ctx context.Context, cwd string) error {
 	ctx, span = monitoring.StartSpan(ctx, "MetaLinter")
 	defer span.End()
 
+	cfg := config.FromContext(ctx)
+
 	var loclist []*nvim.QuickfixError
 	w := nvim.Window(c.buildContext.WinID)
 
@@ -65,18 +67,19 @@ func (c *Command) Metalinter(ctx context.Context, cwd string) error {
 	case "gb":
 		args = append(args, c.buildContext.Build.ProjectRoot+"/...")
 	}
-	args = append(args, []string{"--json", "--disable-all", "--deadline", config.MetalinterDeadline}...)
+	args = append(args, []string{"--json", "--disable-all", "--deadline", cfg.Lint.MetalinterDeadline}...)
 
-	for _, t := range config.MetalinterTools {
+	for _, t := range cfg.Lint.MetalinterTools {
 		args = append(args, "--enable", t)
 	}
-	if len(config.MetalinterSkipDir) != 0 {
-		for _, dir := range config.MetalinterSkipDir {
+	if len(cfg.Lint.MetalinterSkipDir) != 0 {
+		for _, dir := range cfg.Lint.MetalinterSkipDir {
 			args = append(args, "--skip", dir)
 		}
 	}
 
 	cmd := exec.Command("gometalinter", args...)
+	cmd.Env = c.buildContext.Environ()
 	stdout, err := cmd.Output()
 	cmd.Run()
 
@@ -102,7 +105,7 @@ func (c *Command) Metalinter(ctx context.Context, cwd string) error {
 
 	if err := nvimutil.SetLoclist(c.Nvim, loclist); err != nil {
 		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
-		return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
+		return nvimutil.ErrorWrap(ctx, c.Nvim, errors.WithStack(err))
 	}
 	return nvimutil.OpenLoclist(c.Nvim, w, loclist, true)
 }
@@ -0,0 +1,254 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"go/build"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"go.uber.org/zap"
+	"golang.org/x/tools/go/buildutil"
+
+	"github.com/zchee/nvim-go/pkg/fs"
+	"github.com/zchee/nvim-go/pkg/internal/rename"
+	"github.com/zchee/nvim-go/pkg/logger"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+type cmdMoveEval struct {
+	Cwd  string `msgpack:",array"`
+	File string
+}
+
+func (c *Command) cmdRenamePackage(ctx context.Context, args []string, eval *cmdMoveEval) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.RenamePackage(ctx, args, eval)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// RenamePackage renames the current package to args[0], and moves the package directory to the same parent directory.
+func (c *Command) RenamePackage(pctx context.Context, args []string, eval *cmdMoveEval) error {
+	ctx, span := monitoring.StartSpan(pctx, "RenamePackage")
+	defer span.End()
+
+	name := args[0]
+	if !token.IsIdentifier(name) {
+		err := errors.Errorf("GoRenamePackage: %q is not a valid package name", name)
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return err
+	}
+
+	err := c.movePackage(ctx, eval, func(from string) string {
+		return path.Join(path.Dir(from), name)
+	})
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (c *Command) cmdMove(ctx context.Context, args []string, eval *cmdMoveEval) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.Move(ctx, args, eval)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// Move moves the current package to the args[0] import path, like gomvpkg.
+func (c *Command) Move(pctx context.Context, args []string, eval *cmdMoveEval) error {
+	ctx, span := monitoring.StartSpan(pctx, "Move")
+	defer span.End()
+
+	err := c.movePackage(ctx, eval, func(string) string {
+		return path.Clean(args[0])
+	})
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// movePackage moves the package of eval.File to the import path which returned by dst.
+//
+// The packages are resolved through the GOPATH like gomvpkg, so the package must be in a GOPATH src directory;
+// moving a package of a module outside the GOPATH is refused.
+// The package directory is moved before any file is changed, and moved back if the edits can not be applied.
+// The importers are updated within the Guru scope, the loaded buffers of them are updated in place.
+// The files of the moved package are saved with the edits in the new directory,
+// and the loaded buffers of them are reopened from the new directory.
+func (c *Command) movePackage(ctx context.Context, eval *cmdMoveEval, dst func(from string) string) error {
+	overlay, err := nvimutil.ModifiedBuffers(c.Nvim)
+	if err != nil {
+		return err
+	}
+	bctxt := buildutil.OverlayContext(c.buildContext.BuildContext(), overlay)
+
+	dir := filepath.Dir(eval.File)
+	if !inGoPath(bctxt, dir) {
+		if root, ok := fs.FindModuleRoot(dir); ok {
+			return errors.Errorf("%s is in the module %s outside GOPATH, moving packages is only supported in GOPATH", dir, root)
+		}
+		return errors.Errorf("%s is not in GOPATH, moving packages is only supported in GOPATH", dir)
+	}
+
+	pkg, err := bctxt.ImportDir(dir, build.FindOnly)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	from, to := pkg.ImportPath, dst(pkg.ImportPath)
+
+	scope, err := c.guruScope(eval.File)
+	if err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Debug("movePackage", zap.String("from", from), zap.String("to", to), zap.Strings("scope", scope))
+
+	res, err := rename.Move(bctxt, from, to, scope)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	moved, edits := splitMoveEdits(res.FromDir, renameEdits(res.Edits))
+
+	// compute the files of the moved package, including the unsaved buffer contents
+	for filename := range overlay {
+		if isInDir(res.FromDir, filename) {
+			if _, ok := moved[filename]; !ok {
+				moved[filename] = nil
+			}
+		}
+	}
+	type movedFile struct {
+		src, out []byte
+	}
+	files := make(map[string]movedFile, len(moved))
+	for filename, fedits := range moved {
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		in := src
+		if data, ok := overlay[filename]; ok {
+			in = data
+		}
+		out, err := nvimutil.ApplyEdits(in, fedits)
+		if err != nil {
+			return errors.Wrap(err, filename)
+		}
+		rel, err := filepath.Rel(res.FromDir, filename)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		files[rel] = movedFile{src: src, out: out}
+	}
+
+	// move the directory at first, so that nothing is changed if the directory can not be moved
+	if _, err := os.Lstat(res.ToDir); !os.IsNotExist(err) {
+		return errors.Errorf("invalid move destination: %s already exists", res.ToDir)
+	}
+	if err := os.MkdirAll(filepath.Dir(res.ToDir), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.Rename(res.FromDir, res.ToDir); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// rollback restores the moved files and moves the directory back
+	rollback := func(err error) error {
+		for rel, f := range files {
+			ioutil.WriteFile(filepath.Join(res.ToDir, rel), f.src, 0644)
+		}
+		if rerr := os.Rename(res.ToDir, res.FromDir); rerr != nil {
+			return errors.Wrapf(err, "could not move %s back to %s: %v", res.ToDir, res.FromDir, rerr)
+		}
+		return err
+	}
+
+	for rel, f := range files {
+		if err := ioutil.WriteFile(filepath.Join(res.ToDir, rel), f.out, 0644); err != nil {
+			return rollback(errors.WithStack(err))
+		}
+	}
+	if _, err := nvimutil.ApplyFileEdits(c.Nvim, edits); err != nil {
+		return rollback(err)
+	}
+
+	if err := nvimutil.MoveBuffers(c.Nvim, res.FromDir, res.ToDir); err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("Moved %s to %s, updated %d file%s in %d package%s.",
+		res.From, res.To, len(res.Files()), plural(len(res.Files())), res.Packages, plural(res.Packages))
+	return nvimutil.EchoSuccess(c.Nvim, "GoMove", msg)
+}
+
+// inGoPath reports whether the dir is under a GOPATH src directory of ctxt.
+func inGoPath(ctxt *build.Context, dir string) bool {
+	for _, gopath := range filepath.SplitList(ctxt.GOPATH) {
+		if gopath != "" && isInDir(filepath.Join(gopath, "src"), dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitMoveEdits splits the edits into the edits of the files under dir, and the others.
+func splitMoveEdits(dir string, edits nvimutil.FileEdits) (moved, others nvimutil.FileEdits) {
+	moved, others = make(nvimutil.FileEdits), make(nvimutil.FileEdits)
+	for filename, fedits := range edits {
+		if isInDir(dir, filename) {
+			moved[filename] = fedits
+			continue
+		}
+		others[filename] = fedits
+	}
+	return moved, others
+}
+
+// isInDir reports whether the filename is under the dir.
+func isInDir(dir, filename string) bool {
+	rel, err := filepath.Rel(dir, filename)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func plural(n int) string {
+	if n != 1 {
+		return "s"
+	}
+	return ""
+}
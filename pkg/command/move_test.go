@@ -0,0 +1,62 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"go/build"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+func TestSplitMoveEdits(t *testing.T) {
+	edits := nvimutil.FileEdits{
+		"/go/src/foo/foo.go":     {{Start: 8, End: 11, New: []byte("bar")}},
+		"/go/src/foo/sub/sub.go": {{Start: 20, End: 25, New: []byte(`"bar/sub"`)}},
+		"/go/src/foobar/main.go": {{Start: 20, End: 25, New: []byte(`"bar"`)}},
+		"/go/src/main/main.go":   {{Start: 20, End: 25, New: []byte(`"bar"`)}},
+	}
+
+	moved, others := splitMoveEdits("/go/src/foo", edits)
+
+	wantMoved := nvimutil.FileEdits{
+		"/go/src/foo/foo.go":     edits["/go/src/foo/foo.go"],
+		"/go/src/foo/sub/sub.go": edits["/go/src/foo/sub/sub.go"],
+	}
+	if diff := cmp.Diff(wantMoved, moved); diff != "" {
+		t.Errorf("moved: (-want +got)\n%s", diff)
+	}
+	wantOthers := nvimutil.FileEdits{
+		"/go/src/foobar/main.go": edits["/go/src/foobar/main.go"],
+		"/go/src/main/main.go":   edits["/go/src/main/main.go"],
+	}
+	if diff := cmp.Diff(wantOthers, others); diff != "" {
+		t.Errorf("others: (-want +got)\n%s", diff)
+	}
+}
+
+func TestInGoPath(t *testing.T) {
+	ctxt := &build.Context{GOPATH: strings.Join([]string{"/go", "/home/gopher/go"}, string(filepath.ListSeparator))}
+
+	tests := []struct {
+		dir  string
+		want bool
+	}{
+		{dir: "/go/src/foo", want: true},
+		{dir: "/home/gopher/go/src/foo/bar", want: true},
+		{dir: "/go/pkg/mod/foo", want: false},
+		{dir: "/go/srcfoo", want: false},
+		{dir: "/home/gopher/src/foo", want: false},
+	}
+	for _, tt := range tests {
+		if got := inGoPath(ctxt, tt.dir); got != tt.want {
+			t.Errorf("inGoPath(%q) = %v, want %v", tt.dir, got, tt.want)
+		}
+	}
+}
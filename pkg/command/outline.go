@@ -0,0 +1,417 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// outlineBufferName buffer name of the GoOutline.
+const outlineBufferName = "__GO_OUTLINE__"
+
+// outlineHighlight is the highlight group of the declaration which encloses the cursor.
+const outlineHighlight = "Visual"
+
+// outline represents the GoOutline buffer of the source buffer.
+type outline struct {
+	buffer nvim.Buffer // the outline buffer
+	source nvim.Buffer // the source buffer
+	nsID   int         // the namespace of the cursor highlight
+	// items is the outline items of each outline buffer line.
+	items []outlineItem
+	// current is the highlighted outline buffer line (0-based), or -1.
+	current int
+}
+
+// outlineItem represents a line of the outline.
+type outlineItem struct {
+	text       string
+	line, col  int // the position of the declared name, or 0 for the section header
+	start, end int // the line range of the declaration
+}
+
+// outlineItems returns the tree of the declarations in f, which is grouped by imports, consts, vars, types and funcs.
+// The methods are grouped under the receiver type.
+func outlineItems(fset *token.FileSet, f *ast.File) []outlineItem {
+	const indent = "  "
+
+	item := func(depth int, text string, name, decl ast.Node) outlineItem {
+		pos := fset.Position(name.Pos())
+		return outlineItem{
+			text:  strings.Repeat(indent, depth) + text,
+			line:  pos.Line,
+			col:   pos.Column,
+			start: fset.Position(decl.Pos()).Line,
+			end:   fset.Position(decl.End()).Line,
+		}
+	}
+
+	var imports, consts, vars, funcs []outlineItem
+	var typeNames []string
+	typeItems := make(map[string]outlineItem)
+	methods := make(map[string][]outlineItem)
+
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				node := ast.Node(spec)
+				if !decl.Lparen.IsValid() {
+					node = decl
+				}
+				switch spec := spec.(type) {
+				case *ast.ImportSpec:
+					path, _ := strconv.Unquote(spec.Path.Value)
+					if spec.Name != nil {
+						path = spec.Name.Name + " " + path
+					}
+					imports = append(imports, item(1, path, spec, node))
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						text := name.Name
+						if spec.Type != nil {
+							text += " " + types.ExprString(spec.Type)
+						}
+						if decl.Tok == token.CONST {
+							consts = append(consts, item(1, text, name, node))
+						} else {
+							vars = append(vars, item(1, text, name, node))
+						}
+					}
+				case *ast.TypeSpec:
+					text := spec.Name.Name
+					switch spec.Type.(type) {
+					case *ast.StructType:
+						text += " struct"
+					case *ast.InterfaceType:
+						text += " interface"
+					default:
+						text += " " + types.ExprString(spec.Type)
+					}
+					typeNames = append(typeNames, spec.Name.Name)
+					typeItems[spec.Name.Name] = item(1, text, spec.Name, node)
+				}
+			}
+
+		case *ast.FuncDecl:
+			sig := strings.TrimPrefix(types.ExprString(decl.Type), "func")
+			if decl.Recv == nil || len(decl.Recv.List) == 0 {
+				funcs = append(funcs, item(1, decl.Name.Name+sig, decl.Name, decl))
+				continue
+			}
+			recv := types.ExprString(decl.Recv.List[0].Type)
+			typeName := strings.TrimPrefix(recv, "*")
+			if i := strings.IndexByte(typeName, '['); i >= 0 {
+				typeName = typeName[:i]
+			}
+			if _, ok := typeItems[typeName]; !ok && len(methods[typeName]) == 0 {
+				typeNames = append(typeNames, typeName)
+			}
+			methods[typeName] = append(methods[typeName], item(2, "("+recv+") "+decl.Name.Name+sig, decl.Name, decl))
+		}
+	}
+
+	var items []outlineItem
+	section := func(name string, list []outlineItem) {
+		if len(list) > 0 {
+			items = append(items, outlineItem{text: "▼ " + name})
+			items = append(items, list...)
+		}
+	}
+	section("imports", imports)
+	section("consts", consts)
+	section("vars", vars)
+
+	var typeList []outlineItem
+	for _, name := range typeNames {
+		if ti, ok := typeItems[name]; ok {
+			typeList = append(typeList, ti)
+		} else {
+			typeList = append(typeList, outlineItem{text: indent + name}) // the type is declared in the other file
+		}
+		typeList = append(typeList, methods[name]...)
+	}
+	section("types", typeList)
+	section("funcs", funcs)
+
+	return items
+}
+
+// outlineCursorItem returns the index of the innermost outline item which encloses the source line, or -1.
+func outlineCursorItem(items []outlineItem, line int) int {
+	idx := -1
+	for i, item := range items {
+		if item.line == 0 || line < item.start || item.end < line {
+			continue
+		}
+		if idx < 0 || item.end-item.start < items[idx].end-items[idx].start {
+			idx = i
+		}
+	}
+	return idx
+}
+
+// outlineLines returns the outline buffer lines of the items.
+func outlineLines(file string, items []outlineItem) [][]byte {
+	lines := [][]byte{[]byte(fmt.Sprintf("\" %s", filepath.Base(file)))}
+	for _, item := range items {
+		lines = append(lines, []byte(item.text))
+	}
+	return lines
+}
+
+func (c *Command) cmdOutline(ctx context.Context, file string) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.Outline(ctx, file)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// Outline toggles the outline buffer of the declarations in the current buffer.
+func (c *Command) Outline(pctx context.Context, file string) error {
+	ctx, span := monitoring.StartSpan(pctx, "Outline")
+	defer span.End()
+
+	c.mu.Lock()
+	o := c.outline
+	c.outline = nil
+	c.mu.Unlock()
+	if o != nil && nvimutil.IsBufferValid(c.Nvim, o.buffer) {
+		if err := c.Nvim.Command(fmt.Sprintf("silent! bwipeout %d", o.buffer)); err != nil {
+			span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+			return errors.WithStack(err)
+		}
+		return nil
+	}
+
+	source := nvim.Buffer(c.buildContext.BufNr)
+	win, err := c.Nvim.CurrentWindow()
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	nsID, err := c.Nvim.CreateNamespace("nvim-go-outline")
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	b := nvimutil.NewBuffer(c.Nvim)
+	option := map[nvimutil.NvimOption]map[string]interface{}{
+		nvimutil.BufferOption: {
+			nvimutil.BufOptionBufhidden:  nvimutil.BufhiddenWipe,
+			nvimutil.BufOptionBuflisted:  false,
+			nvimutil.BufOptionBuftype:    nvimutil.BuftypeNofile,
+			nvimutil.BufOptionFiletype:   nvimutil.FiletypeGoAnalyze,
+			nvimutil.BufOptionModifiable: false,
+			nvimutil.BufOptionSwapfile:   false,
+		},
+		nvimutil.WindowOption: {
+			nvimutil.WinOptionList:           false,
+			nvimutil.WinOptionNumber:         false,
+			nvimutil.WinOptionRelativenumber: false,
+			nvimutil.WinOptionWinfixwidth:    true,
+			nvimutil.WinOptionWrap:           false,
+		},
+	}
+	if err := b.Create(outlineBufferName, nvimutil.FiletypeGoAnalyze, "botright vertical 40new", option); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	if err := b.SetLocalMapping(nvimutil.NoremapNormal, map[string]string{
+		"<CR>": ":<C-u>GoOutlineJump<CR>",
+		"q":    ":<C-u>quit<CR>",
+	}); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	if err := c.Nvim.SetCurrentWindow(win); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	c.mu.Lock()
+	c.outline = &outline{buffer: b.Buffer(), source: source, nsID: nsID, current: -1}
+	c.mu.Unlock()
+
+	if err := c.OutlineRefresh(ctx, int(source), file); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return err
+	}
+	cursor, err := c.Nvim.WindowCursor(win)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	return c.OutlineFollow(ctx, int(source), cursor[0])
+}
+
+// activeOutline returns the outline of the source buffer bufnr, or nil if the outline is not opened.
+func (c *Command) activeOutline(bufnr int) *outline {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.outline == nil || c.outline.source != nvim.Buffer(bufnr) {
+		return nil
+	}
+	if !nvimutil.IsBufferValid(c.Nvim, c.outline.buffer) { // closed by the user
+		c.outline = nil
+		return nil
+	}
+	return c.outline
+}
+
+// OutlineRefresh updates the outline of the source buffer bufnr if it is opened.
+func (c *Command) OutlineRefresh(pctx context.Context, bufnr int, file string) error {
+	ctx, span := monitoring.StartSpan(pctx, "OutlineRefresh")
+	defer span.End()
+
+	o := c.activeOutline(bufnr)
+	if o == nil {
+		return nil
+	}
+
+	buflines, err := c.Nvim.BufferLines(o.source, 0, -1, true)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	// use the partial AST even if the buffer has the syntax errors during editing
+	fset := token.NewFileSet()
+	f, _ := parser.ParseFile(fset, file, nvimutil.ToByteSlice(buflines), 0)
+	if f == nil {
+		return nil
+	}
+	items := outlineItems(fset, f)
+
+	c.mu.Lock()
+	o.items, o.current = items, -1
+	c.mu.Unlock()
+
+	lines, err := c.Nvim.BufferLines(o.buffer, 0, -1, true)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	c.Nvim.SetBufferOption(o.buffer, nvimutil.BufOptionModifiable, true)
+	defer c.Nvim.SetBufferOption(o.buffer, nvimutil.BufOptionModifiable, false)
+
+	return minUpdate(ctx, c.Nvim, o.buffer, lines, outlineLines(file, items))
+}
+
+// OutlineFollow highlights the outline item of the declaration which encloses the line of the source buffer bufnr.
+func (c *Command) OutlineFollow(pctx context.Context, bufnr, line int) error {
+	_, span := monitoring.StartSpan(pctx, "OutlineFollow")
+	defer span.End()
+
+	o := c.activeOutline(bufnr)
+	if o == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	idx := outlineCursorItem(o.items, line)
+	changed := idx != o.current
+	o.current = idx
+	c.mu.Unlock()
+	if !changed {
+		return nil
+	}
+
+	if err := c.Nvim.ClearBufferHighlight(o.buffer, o.nsID, 0, -1); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	if idx < 0 {
+		return nil
+	}
+	if _, err := c.Nvim.AddBufferHighlight(o.buffer, o.nsID, outlineHighlight, idx+1, 0, -1); err != nil { // +1 for the header line
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (c *Command) cmdOutlineJump(ctx context.Context, line int) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.OutlineJump(ctx, line)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// OutlineJump jumps to the declaration of the outline buffer line.
+func (c *Command) OutlineJump(pctx context.Context, line int) error {
+	_, span := monitoring.StartSpan(pctx, "OutlineJump")
+	defer span.End()
+
+	c.mu.Lock()
+	o := c.outline
+	var item outlineItem
+	if o != nil && 2 <= line && line-2 < len(o.items) { // skip the header line
+		item = o.items[line-2]
+	}
+	c.mu.Unlock()
+	if o == nil {
+		return errors.New("GoOutlineJump: the outline is not opened")
+	}
+	if item.line == 0 {
+		return nil // the section header
+	}
+
+	var winID int
+	if err := c.Nvim.Call("bufwinid", &winID, int(o.source)); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	if winID < 0 {
+		return errors.New("GoOutlineJump: the source buffer is not displayed")
+	}
+	win := nvim.Window(winID)
+
+	batch := c.Nvim.NewBatch()
+	batch.SetCurrentWindow(win)
+	batch.SetWindowCursor(win, [2]int{item.line, item.col - 1})
+	if err := batch.Execute(); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const outlineSrc = `package p
+
+import (
+	"fmt"
+	str "strings"
+)
+
+const Max = 10
+
+var (
+	Debug   bool
+	a, b    = 1, 2
+)
+
+type T struct {
+	n int
+}
+
+func NewT() *T { return &T{} }
+
+func (t *T) String() string {
+	return fmt.Sprint(t.n) + str.TrimSpace("")
+}
+
+func (o Other) Len() int { return 0 }
+
+type Mode int
+`
+
+func TestOutlineItems(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", outlineSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := outlineItems(fset, f)
+
+	want := `" p.go
+▼ imports
+  fmt
+  str strings
+▼ consts
+  Max
+▼ vars
+  Debug bool
+  a
+  b
+▼ types
+  T struct
+    (*T) String() string
+  Other
+    (Other) Len() int
+  Mode int
+▼ funcs
+  NewT() *T`
+	var got []string
+	for _, line := range outlineLines("/path/to/p.go", items) {
+		got = append(got, string(line))
+	}
+	if diff := cmp.Diff(want, strings.Join(got, "\n")); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+
+	tests := []struct {
+		name string
+		line int
+		want string
+	}{
+		{name: "Import", line: 5, want: "  str strings"},
+		{name: "GroupedVar", line: 12, want: "  a"},
+		{name: "TypeBody", line: 16, want: "  T struct"},
+		{name: "MethodBody", line: 22, want: "    (*T) String() string"},
+		{name: "Func", line: 19, want: "  NewT() *T"},
+		{name: "Blank", line: 2, want: ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got string
+			if idx := outlineCursorItem(items, tt.line); idx >= 0 {
+				got = items[idx].text
+			}
+			if got != tt.want {
+				t.Errorf("outlineCursorItem(%d) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
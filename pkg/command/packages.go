@@ -0,0 +1,58 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"go/build"
+	"path/filepath"
+
+	"github.com/zchee/nvim-go/pkg/fs"
+	"github.com/zchee/nvim-go/pkg/internal/gopathwalk"
+	"github.com/zchee/nvim-go/pkg/internal/pkgindex"
+)
+
+// packageDir represents the import path and the directory of a package.
+type packageDir struct {
+	path string
+	dir  string
+}
+
+// packageRoots returns the roots of the standard library and the Go module of dir.
+// If deps is true, packageRoots also returns the dependency modules of the Go module in the module cache.
+func (c *Cache) packageRoots(dir string, deps bool) []pkgindex.Root {
+	roots := []pkgindex.Root{{Root: gopathwalk.Root{Path: filepath.Join(build.Default.GOROOT, "src"), Type: gopathwalk.RootGOROOT}}}
+
+	root, ok := fs.FindModuleRoot(dir)
+	if !ok {
+		return roots
+	}
+	if modPath, ok := fs.ModulePath(root); ok {
+		roots = append(roots, pkgindex.Root{Root: gopathwalk.Root{Path: root, Type: gopathwalk.RootCurrentModule}, ImportPath: modPath})
+	}
+	if deps {
+		for _, mod := range c.moduleDependencies(root) {
+			roots = append(roots, pkgindex.Root{Root: gopathwalk.Root{Path: mod.Dir, Type: gopathwalk.RootModuleCache}, ImportPath: mod.Path})
+		}
+	}
+
+	return roots
+}
+
+// packageIndex returns the packages of the standard library, the Go module of dir and its dependencies.
+// The packages are read from the package index, which is refreshed by the refresh policy.
+func (c *Command) packageIndex(ctx context.Context, dir string) []packageDir {
+	roots := c.packageRoots(dir, true)
+	c.refreshPackageRoots(ctx, dir, roots)
+
+	var pkgs []packageDir
+	for _, root := range roots {
+		for _, pkg := range c.pkgIndex.Under(root.Path) {
+			pkgs = append(pkgs, packageDir{path: pkg.ImportPath, dir: pkg.Dir})
+		}
+	}
+
+	return pkgs
+}
@@ -0,0 +1,171 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// picker represents the picker buffer which jumps to the location of the selected line.
+type picker struct {
+	buffer nvim.Buffer // the picker buffer
+	window nvim.Window // the window which opened the picker
+	items  []pickerItem
+}
+
+// pickerItem represents a line of the picker.
+type pickerItem struct {
+	text      string
+	file      string
+	line, col int
+}
+
+// openPicker opens the picker buffer name of the items, which replaces the previous picker.
+func (c *Command) openPicker(name string, items []pickerItem) error {
+	c.mu.Lock()
+	p := c.picker
+	c.picker = nil
+	c.mu.Unlock()
+	if p != nil && nvimutil.IsBufferValid(c.Nvim, p.buffer) {
+		if err := c.Nvim.Command(fmt.Sprintf("silent! bwipeout %d", p.buffer)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	win, err := c.Nvim.CurrentWindow()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	b := nvimutil.NewBuffer(c.Nvim)
+	option := map[nvimutil.NvimOption]map[string]interface{}{
+		nvimutil.BufferOption: {
+			nvimutil.BufOptionBufhidden:  nvimutil.BufhiddenWipe,
+			nvimutil.BufOptionBuflisted:  false,
+			nvimutil.BufOptionBuftype:    nvimutil.BuftypeNofile,
+			nvimutil.BufOptionFiletype:   nvimutil.FiletypeGoAnalyze,
+			nvimutil.BufOptionModifiable: false,
+			nvimutil.BufOptionSwapfile:   false,
+		},
+		nvimutil.WindowOption: {
+			nvimutil.WinOptionList:           false,
+			nvimutil.WinOptionNumber:         false,
+			nvimutil.WinOptionRelativenumber: false,
+			nvimutil.WinOptionWrap:           false,
+		},
+	}
+	if err := b.Create(name, nvimutil.FiletypeGoAnalyze, "botright 12new", option); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := b.SetLocalMapping(nvimutil.NoremapNormal, map[string]string{
+		"<CR>": ":<C-u>GoPickerJump<CR>",
+		"q":    ":<C-u>quit<CR>",
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	lines := make([][]byte, len(items))
+	for i, item := range items {
+		lines[i] = []byte(item.text)
+	}
+	c.Nvim.SetBufferOption(b.Buffer(), nvimutil.BufOptionModifiable, true)
+	err = c.Nvim.SetBufferLines(b.Buffer(), 0, -1, true, lines)
+	c.Nvim.SetBufferOption(b.Buffer(), nvimutil.BufOptionModifiable, false)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	c.mu.Lock()
+	c.picker = &picker{buffer: b.Buffer(), window: win, items: items}
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *Command) cmdPickerJump(ctx context.Context, line int) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.PickerJump(ctx, line)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// PickerJump closes the picker, and jumps to the location of the picker buffer line
+// in the window which opened the picker.
+func (c *Command) PickerJump(pctx context.Context, line int) error {
+	_, span := monitoring.StartSpan(pctx, "PickerJump")
+	defer span.End()
+
+	c.mu.Lock()
+	p := c.picker
+	if p != nil && 1 <= line && line <= len(p.items) {
+		c.picker = nil
+	}
+	c.mu.Unlock()
+	if p == nil {
+		return errors.New("GoPickerJump: the picker is not opened")
+	}
+	if line < 1 || line > len(p.items) {
+		return nil
+	}
+
+	batch := c.Nvim.NewBatch()
+	batch.Command(fmt.Sprintf("silent! bwipeout %d", p.buffer))
+	if nvimutil.IsWindowValid(c.Nvim, p.window) {
+		batch.SetCurrentWindow(p.window)
+	}
+	if err := batch.Execute(); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	if err := c.jumpTo(p.items[line-1]); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return err
+	}
+
+	return nil
+}
+
+// jumpTo edits the file of the item in the current window, and moves the cursor to the location of the item.
+// The file of the item is the absolute path. The previous cursor position is added to the jumplist.
+func (c *Command) jumpTo(item pickerItem) error {
+	var current, fname string
+	batch := c.Nvim.NewBatch()
+	batch.Call("expand", &current, "%:p")
+	batch.Call("fnameescape", &fname, item.file)
+	if err := batch.Execute(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	col := item.col - 1
+	if col < 0 {
+		col = 0
+	}
+	batch.Command("normal! m'")
+	if current != item.file { // keep the modified current buffer
+		batch.Command(fmt.Sprintf("edit %s", fname))
+	}
+	batch.SetWindowCursor(0, [2]int{item.line, col})
+	batch.Command("normal! zz")
+
+	return errors.WithStack(batch.Execute())
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/types"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/loader"
+
+	"github.com/zchee/nvim-go/pkg/internal/refactor"
+)
+
+// loadRefactorFile type-checks the buffer contents src of file with the other files of the same package.
+// The type errors are allowed, since the buffer is being edited.
+func loadRefactorFile(ctxt *build.Context, file string, src []byte) (*refactor.File, error) {
+	f, _, err := loadRefactorProgram(ctxt, file, src)
+	return f, err
+}
+
+// loadRefactorProgram is like loadRefactorFile, but also loads the packages of the import paths,
+// and returns the loaded program.
+func loadRefactorProgram(ctxt *build.Context, file string, src []byte, paths ...string) (*refactor.File, *loader.Program, error) {
+	dir := filepath.Dir(file)
+	conf := loader.Config{
+		ParserMode:  parser.ParseComments,
+		TypeChecker: types.Config{FakeImportC: true, DisableUnusedImportCheck: true},
+		Build:       ctxt,
+		Cwd:         dir,
+		AllowErrors: true,
+	}
+
+	f, err := conf.ParseFile(file, src)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	files := []*ast.File{f}
+
+	// the other files of the same package are saved files, ignore the errors since the buffer is the main target
+	if bp, err := conf.Build.ImportDir(dir, 0); err == nil {
+		var names []string
+		switch {
+		case f.Name.Name == bp.Name:
+			names = append(names, bp.GoFiles...)
+			if strings.HasSuffix(file, "_test.go") {
+				names = append(names, bp.TestGoFiles...)
+			}
+		case strings.HasSuffix(file, "_test.go"):
+			names = append(names, bp.XTestGoFiles...)
+		}
+		for _, name := range names {
+			filename := filepath.Join(dir, name)
+			if filename == file {
+				continue
+			}
+			if sf, err := conf.ParseFile(filename, nil); err == nil {
+				files = append(files, sf)
+			}
+		}
+	}
+
+	conf.CreateFromFiles(file, files...)
+	for _, path := range paths {
+		conf.Import(path)
+	}
+	prog, err := conf.Load()
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	info := prog.Created[0]
+
+	return &refactor.File{
+		Fset: prog.Fset,
+		File: f,
+		Pkg:  info.Pkg,
+		Info: &info.Info,
+		Src:  src,
+	}, prog, nil
+}
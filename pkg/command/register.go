@@ -11,109 +11,243 @@ import (
 	"github.com/neovim/go-client/nvim/plugin"
 
 	"github.com/zchee/nvim-go/pkg/buildctxt"
+	"github.com/zchee/nvim-go/pkg/config"
 	"github.com/zchee/nvim-go/pkg/logger"
 )
 
 // Register register nvim-go command or function to Neovim over the msgpack-rpc plugin interface.
-func Register(ctx context.Context, p *plugin.Plugin, bctxt *buildctxt.Context) *Command {
-	c := NewCommand(ctx, p.Nvim, bctxt)
+// The cache is shared with the other clients of the process.
+func Register(ctx context.Context, p *plugin.Plugin, bctxt *buildctxt.Context, cache *Cache) *Command {
+	c := NewCommand(ctx, p.Nvim, bctxt, cache)
 	log := logger.FromContext(ctx).Named("command")
 	ctx = logger.NewContext(ctx, log)
 
 	// CommandOptions order:
 	//  Name, NArgs, Range, Count, Addr, Bang, Register, Eval, Bar, Complete
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoAddTags", NArgs: "*", Range: ".", Eval: "expand('%:p')"},
+		func(args []string, ranges [2]int, file string) {
+			c.cmdAddTags(config.Snapshot(ctx), args, ranges, file)
+		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoBuild", NArgs: "*", Bang: true, Eval: "[getcwd(), expand('%:p')]"},
 		func(args []string, bang bool, eval *CmdBuildEval) {
-			c.cmdBuild(ctx, args, bang, eval)
+			c.cmdBuild(config.Snapshot(ctx), args, bang, eval)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoComment", Eval: "expand('%:p')"},
+		func(file string) {
+			c.cmdComment(config.Snapshot(ctx), file)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoCommentMissing", Eval: "expand('%:p')"},
+		func(file string) {
+			c.cmdCommentMissing(config.Snapshot(ctx), file)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoConfig", Eval: "*"},
+		func(eval *cmdConfigShowEval) {
+			c.cmdConfigShow(config.Snapshot(ctx), eval)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoConfigReload", Eval: "*"},
+		func(eval *cmdConfigEval) {
+			c.cmdConfigReload(config.Snapshot(ctx), eval)
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoCover", NArgs: "?", Eval: "[getcwd(), expand('%:p')]"},
 		func(args []string, eval *cmdCoverEval) {
-			c.cmdCover(ctx, args, eval)
+			c.cmdCover(config.Snapshot(ctx), args, eval)
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoCoverClear"},
 		func() {
-			c.cmdClearCover(ctx)
+			c.cmdClearCover(config.Snapshot(ctx))
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoDoc", NArgs: "?", Eval: "expand('%:p')"},
+		func(args []string, file string) {
+			c.cmdDoc(config.Snapshot(ctx), args, file)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoDocBrowse", NArgs: "?", Eval: "[getcwd(), expand('%:p')]", Complete: "customlist,GoDocBrowseCompletion"},
+		func(args []string, eval *cmdDocBrowseEval) {
+			c.cmdDocBrowse(config.Snapshot(ctx), args, eval)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoDocBrowseJump", Eval: "[expand('%'), getline('.'), line('.'), col('.')]"},
+		func(eval *cmdDocBrowseJumpEval) {
+			c.cmdDocBrowseJump(config.Snapshot(ctx), eval)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoDocFollow", Eval: "[getline('.'), col('.')]"},
+		func(eval *cmdDocFollowEval) {
+			c.cmdDocFollow(config.Snapshot(ctx), eval)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoExtractFunc", NArgs: "1", Range: ".", Eval: "[expand('%:p'), getpos(\"'<\"), getpos(\"'>\")]"},
+		func(args []string, ranges [2]int, eval *cmdExtractEval) {
+			c.cmdExtractFunc(config.Snapshot(ctx), args, ranges, eval)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoExtractVar", NArgs: "1", Range: ".", Eval: "[expand('%:p'), getpos(\"'<\"), getpos(\"'>\")]"},
+		func(args []string, ranges [2]int, eval *cmdExtractEval) {
+			c.cmdExtractVar(config.Snapshot(ctx), args, ranges, eval)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoFillStruct", Eval: "expand('%:p')"},
+		func(file string) {
+			c.cmdFillStruct(config.Snapshot(ctx), file)
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoFmt", Eval: "expand('%:p:h')"},
 		func(dir string) {
-			c.cmdFmt(ctx, dir)
+			c.cmdFmt(config.Snapshot(ctx), dir)
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoGenerateTest", NArgs: "*", Range: "%", Addr: "line", Bang: true, Eval: "expand('%:p:h')", Complete: "file"},
 		func(args []string, ranges [2]int, bang bool, dir string) {
-			c.cmdGenerateTest(ctx, args, ranges, bang, dir)
+			c.cmdGenerateTest(config.Snapshot(ctx), args, ranges, bang, dir)
+		})
+	p.HandleFunction(&plugin.FunctionOptions{Name: "GoComplete", Eval: "[bufnr('%'), expand('%:p'), getcwd(), line('.'), col('.'), getline('.')]"}, // the 'omnifunc'
+		func(args *funcCompleteArgs, eval *funcCompleteEval) (interface{}, error) {
+			return c.funcComplete(config.Snapshot(ctx), args, eval)
 		})
 	p.HandleFunction(&plugin.FunctionOptions{Name: "GoGuru", Eval: "[getcwd(), expand('%:p'), &modified, line2byte(line('.')) + (col('.')-2)]"},
 		func(args []string, eval *funcGuruEval) {
-			c.funcGuru(ctx, args, eval)
+			c.funcGuru(config.Snapshot(ctx), args, eval)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoHighlight", Eval: "expand('%:p')"},
+		func(file string) {
+			c.cmdHighlight(config.Snapshot(ctx), file)
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoIferr", Eval: "expand('%:p')"},
 		func(file string) {
-			c.cmdIferr(ctx, file)
+			c.cmdIferr(config.Snapshot(ctx), file)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoImpl", NArgs: "+", Eval: "[getcwd(), expand('%:p')]", Complete: "customlist,GoImplCompletion"},
+		func(args []string, eval *cmdImplEval) {
+			c.cmdImpl(config.Snapshot(ctx), args, eval)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoImport", NArgs: "1", Eval: "[getcwd(), expand('%:p')]", Complete: "customlist,GoImportCompletion"},
+		func(args []string, eval *cmdImportEval) {
+			c.cmdImport(config.Snapshot(ctx), args, eval)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoImplementations", Eval: "[getcwd(), expand('%:p'), &modified, line2byte(line('.')) + (col('.')-2)]"},
+		func(eval *funcGuruEval) {
+			c.cmdImplementations(config.Snapshot(ctx), eval)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoInline", Eval: "expand('%:p')"},
+		func(file string) {
+			c.cmdInline(config.Snapshot(ctx), file)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoInterfaces", Eval: "[getcwd(), expand('%:p'), &modified, line2byte(line('.')) + (col('.')-2)]"},
+		func(eval *funcGuruEval) {
+			c.cmdInterfaces(config.Snapshot(ctx), eval)
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoLint", NArgs: "?", Eval: "expand('%:p')", Complete: "customlist,GoLintCompletion"},
 		func(args []string, file string) {
-			c.cmdLint(ctx, args, file)
+			c.cmdLint(config.Snapshot(ctx), args, file)
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoMetalinter", Eval: "getcwd()"},
 		func(cwd string) {
-			c.cmdMetalinter(ctx, cwd)
+			c.cmdMetalinter(config.Snapshot(ctx), cwd)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoMove", NArgs: "1", Eval: "[getcwd(), expand('%:p')]"},
+		func(args []string, eval *cmdMoveEval) {
+			c.cmdMove(config.Snapshot(ctx), args, eval)
 		})
-	p.HandleCommand(&plugin.CommandOptions{Name: "GoRename", NArgs: "?", Bang: true, Eval: "[getcwd(), expand('%:p'), expand('<cword>')]"},
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoOutline", Eval: "expand('%:p')"},
+		func(file string) {
+			c.cmdOutline(config.Snapshot(ctx), file)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoOutlineJump", Eval: "line('.')"},
+		func(line int) {
+			c.cmdOutlineJump(config.Snapshot(ctx), line)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoPickerJump", Eval: "line('.')"},
+		func(line int) {
+			c.cmdPickerJump(config.Snapshot(ctx), line)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoRemoveTags", NArgs: "*", Range: ".", Eval: "expand('%:p')"},
+		func(args []string, ranges [2]int, file string) {
+			c.cmdRemoveTags(config.Snapshot(ctx), args, ranges, file)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoRename", NArgs: "*", Bang: true, Eval: "[getcwd(), expand('%:p'), expand('<cword>')]"},
 		func(args []string, bang bool, eval *cmdRenameEval) {
-			c.cmdRename(ctx, args, bang, eval)
+			c.cmdRename(config.Snapshot(ctx), args, bang, eval)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoRenameApply"},
+		func() {
+			c.cmdRenameApply(config.Snapshot(ctx))
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoRenamePackage", NArgs: "1", Eval: "[getcwd(), expand('%:p')]"},
+		func(args []string, eval *cmdMoveEval) {
+			c.cmdRenamePackage(config.Snapshot(ctx), args, eval)
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoRun", NArgs: "*", Eval: "expand('%:p')"},
 		func(args []string, file string) {
-			c.cmdRun(ctx, args, file)
+			c.cmdRun(config.Snapshot(ctx), args, file)
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoRunLast", Eval: "expand('%:p')"},
 		func(file string) {
-			c.cmdRunLast(ctx, file)
+			c.cmdRunLast(config.Snapshot(ctx), file)
+		})
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoSymbols", NArgs: "1", Eval: "[getcwd(), expand('%:p')]"},
+		func(args []string, eval *cmdSymbolsEval) {
+			c.cmdSymbols(config.Snapshot(ctx), args, eval)
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoTest", NArgs: "*", Eval: "expand('%:p:h')"},
 		func(args []string, dir string) {
-			c.cmdTest(ctx, args, dir)
+			c.cmdTest(config.Snapshot(ctx), args, dir)
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoSwitchTest", Eval: "*"},
 		func(eval *cmdTestSwitchEval) {
-			c.SwitchTest(ctx, eval)
+			c.SwitchTest(config.Snapshot(ctx), eval)
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoVet", NArgs: "*", Eval: "[getcwd(), expand('%:p')]", Complete: "customlist,GoVetCompletion"},
 		func(args []string, eval *CmdVetEval) {
-			c.cmdVet(ctx, args, eval)
+			c.cmdVet(config.Snapshot(ctx), args, eval)
+		})
+
+	// Handle the notifications of the buffers which attached by the semantic highlighting
+	p.Handle("nvim_buf_lines_event",
+		func(b nvim.Buffer, changedtick interface{}, first, last int, data []string, more bool) {
+			c.SemanticLinesEvent(ctx, b, first, last, data)
+		})
+	p.Handle("nvim_buf_detach_event",
+		func(b nvim.Buffer) {
+			c.SemanticDetachEvent(b)
 		})
 
 	// Commnad completion
+	p.HandleFunction(&plugin.FunctionOptions{Name: "GoDocBrowseCompletion", Eval: "getcwd()"}, // list the packages of the standard library, the module and its dependencies
+		func(a *nvim.CommandCompletionArgs, cwd string) ([]string, error) {
+			return c.cmdDocBrowseComplete(config.Snapshot(ctx), a, cwd)
+		})
+	p.HandleFunction(&plugin.FunctionOptions{Name: "GoImplCompletion", Eval: "getcwd()"}, // list the interfaces of the standard library and the module
+		func(a *nvim.CommandCompletionArgs, cwd string) ([]string, error) {
+			return c.cmdImplComplete(config.Snapshot(ctx), a, cwd)
+		})
+	p.HandleFunction(&plugin.FunctionOptions{Name: "GoImportCompletion", Eval: "[getcwd(), expand('%:p')]"}, // list the importable packages
+		func(a *nvim.CommandCompletionArgs, eval *cmdImportEval) ([]string, error) {
+			return c.cmdImportComplete(config.Snapshot(ctx), a, eval)
+		})
 	p.HandleFunction(&plugin.FunctionOptions{Name: "GoLintCompletion", Eval: "getcwd()"}, // list the file, directory and go packages
 		func(a *nvim.CommandCompletionArgs, cwd string) {
-			c.cmdLintComplete(ctx, a, cwd)
+			c.cmdLintComplete(config.Snapshot(ctx), a, cwd)
 		})
 	p.HandleFunction(&plugin.FunctionOptions{Name: "GoVetCompletion", Eval: "getcwd()"}, // flag for go tool vet
 		func(a *nvim.CommandCompletionArgs, dir string) {
-			c.cmdVetComplete(ctx, a, dir)
+			c.cmdVetComplete(config.Snapshot(ctx), a, dir)
 		})
 
 	// for debug
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoByteOffset", Eval: "expand('%:p')"},
 		func() {
-			c.cmdByteOffset(ctx)
+			c.cmdByteOffset(config.Snapshot(ctx))
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoBuffers"},
 		func() {
-			c.cmdBuffers(ctx)
+			c.cmdBuffers(config.Snapshot(ctx))
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoWindows"},
 		func() {
-			c.cmdWindows(ctx)
+			c.cmdWindows(config.Snapshot(ctx))
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoTabpages"},
 		func() {
-			c.cmdTabpagas(ctx)
+			c.cmdTabpagas(config.Snapshot(ctx))
 		})
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoNotify", NArgs: "*"},
 		func(args []string) {
-			c.cmdNotify(ctx, args)
+			c.cmdNotify(config.Snapshot(ctx), args)
 		})
 
+	go c.refreshPackageIndex(ctx, "")
+
 	return c
 }
@@ -7,16 +7,16 @@ package command
 import (
 	"context"
 	"fmt"
-	"go/build"
-	"io/ioutil"
-	"os"
+	"strings"
 
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
 	"go.uber.org/zap"
-	"golang.org/x/tools/refactor/rename"
+	"golang.org/x/tools/go/buildutil"
 
 	"github.com/zchee/nvim-go/pkg/config"
+	"github.com/zchee/nvim-go/pkg/internal/rename"
 	"github.com/zchee/nvim-go/pkg/logger"
 	"github.com/zchee/nvim-go/pkg/monitoring"
 	"github.com/zchee/nvim-go/pkg/nvimutil"
@@ -42,7 +42,7 @@ func (c *Command) cmdRename(ctx context.Context, args []string, bang bool, eval
 	case err := <-errch:
 		switch e := err.(type) {
 		case error:
-			nvimutil.ErrorWrap(c.Nvim, e)
+			nvimutil.ErrorWrap(ctx, c.Nvim, e)
 		case []*nvim.QuickfixError:
 			c.errs.Store("Rename", e)
 			errlist := make(map[string][]*nvim.QuickfixError)
@@ -51,18 +51,22 @@ func (c *Command) cmdRename(ctx context.Context, args []string, bang bool, eval
 				errlist[k] = append(errlist[k], v...)
 				return true
 			})
-			nvimutil.ErrorList(c.Nvim, errlist, true)
+			nvimutil.ErrorList(ctx, c.Nvim, errlist, true)
 		case nil:
 			// nothing to do
 		}
 	}
 }
 
-// Rename rename the current cursor word use golang.org/x/tools/refactor/rename.
+// Rename rename the current cursor word use the fork of golang.org/x/tools/refactor/rename.
+// Rename uses the unsaved buffer contents, and updates the loaded buffers in place
+// so that the renaming can be undone in each buffer.
 func (c *Command) Rename(pctx context.Context, args []string, bang bool, eval *cmdRenameEval) interface{} {
 	ctx, span := monitoring.StartSpan(pctx, "Rename")
 	defer span.End()
 
+	cfg := config.FromContext(ctx)
+
 	b := nvim.Buffer(c.buildContext.BufNr)
 	w := nvim.Window(c.buildContext.WinID)
 
@@ -72,13 +76,22 @@ func (c *Command) Rename(pctx context.Context, args []string, bang bool, eval *c
 	}
 	pos := fmt.Sprintf("%s:#%d", eval.File, offset)
 
+	// ":GoRename? {name}" or ":GoRename?{name}" previews the renaming
+	preview := cfg.Rename.Preview
+	if len(args) > 0 && strings.HasPrefix(args[0], "?") {
+		preview = true
+		if args[0] = strings.TrimPrefix(args[0], "?"); args[0] == "" {
+			args = args[1:]
+		}
+	}
+
 	var renameTo string
 	if len(args) > 0 {
 		renameTo = args[0]
 	} else {
 		askMessage := fmt.Sprintf("%s: Rename '%s' to: ", pkgRename, eval.RenameFrom)
 		var toResult interface{}
-		if config.RenamePrefill {
+		if cfg.Rename.Prefill {
 			err := c.Nvim.Call("input", &toResult, askMessage, eval.RenameFrom)
 			if err != nil {
 				return errors.New("GoRename: Keyboard interrupt")
@@ -97,52 +110,72 @@ func (c *Command) Rename(pctx context.Context, args []string, bang bool, eval *c
 
 	c.Nvim.Command(fmt.Sprintf("echo '%s: Renaming ' | echohl Identifier | echon '%s' | echohl None | echon ' to ' | echohl Identifier | echon '%s' | echohl None | echon ' ...'", pkgRename, eval.RenameFrom, renameTo))
 
-	if bang {
-		rename.Force = true
+	// use the unsaved buffer contents instead of the files
+	overlay, err := nvimutil.ModifiedBuffers(c.Nvim)
+	if err != nil {
+		return errors.WithStack(err)
 	}
+	bctxt := buildutil.OverlayContext(c.buildContext.BuildContext(), overlay)
+	logger.FromContext(ctx).Debug("Rename", zap.String("bctxt.GOROOT", bctxt.GOROOT), zap.String("bctxt.GOPATH", bctxt.GOPATH), zap.Int("overlay", len(overlay)))
 
-	// TODO(zchee): More elegant way
-	// save original stdout and stderr
-	saveStdout, saveStderr := os.Stdout, os.Stderr
-	rd, wd, err := os.Pipe()
+	// the preview shows the edits with the conflicts, and the conflicts are checked again when applying
+	res, err := rename.Rename(bctxt, pos, "", renameTo, bang || preview)
 	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		if err == rename.ConflictError && len(res.Conflicts) > 0 {
+			return renameConflicts(res.Conflicts)
+		}
 		return errors.WithStack(err)
 	}
 
-	// migrate and stderr and stdout
-	os.Stderr = os.Stdout
-	os.Stderr = wd
-	defer func() {
-		os.Stderr = saveStdout
-		os.Stderr = saveStderr
-	}()
-
-	bctxt := &build.Default
-	logger.FromContext(ctx).Debug("Rename", zap.String("bctxt.GOROOT", bctxt.GOROOT), zap.String("bctxt.GOPATH", bctxt.GOPATH))
-
-	// TODO(zchee): reached race limit, dying when race build
-	if err = rename.Main(bctxt, pos, "", renameTo); err != nil {
-		wd.Close()
-		renameErr, err := ioutil.ReadAll(rd)
-		if err != nil {
+	if preview {
+		p := &renamePreview{
+			from:  eval.RenameFrom,
+			to:    renameTo,
+			res:   res,
+			force: bang,
+		}
+		if err := c.openRenamePreview(ctx, p, overlay); err != nil {
+			span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
 			return errors.WithStack(err)
 		}
-
-		loclist, _ := nvimutil.ParseError(ctx, renameErr, eval.Cwd, &c.buildContext.Build, nil)
-		nvimutil.SetLoclist(c.Nvim, loclist)
-		nvimutil.OpenLoclist(c.Nvim, w, loclist, true)
-
-		return loclist
+		return nil
 	}
 
-	wd.Close()
-	out, err := ioutil.ReadAll(rd)
-	if err != nil {
+	if _, err := nvimutil.ApplyFileEdits(c.Nvim, renameEdits(res.Edits)); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
 		return errors.WithStack(err)
 	}
-	defer nvimutil.EchoSuccess(c.Nvim, pkgRename, fmt.Sprintf("%s", out))
+	c.errs.Delete("Rename")
+
+	return nvimutil.EchoSuccess(c.Nvim, pkgRename, res.Message())
+}
+
+// renameEdits converts the rename edits to the nvimutil.FileEdits.
+func renameEdits(edits []rename.Edit) nvimutil.FileEdits {
+	fedits := make(nvimutil.FileEdits)
+	for _, e := range edits {
+		fedits[e.Pos.Filename] = append(fedits[e.Pos.Filename], nvimutil.TextEdit{
+			Start: e.Pos.Offset,
+			End:   e.End,
+			Old:   []byte(e.Old),
+			New:   []byte(e.New),
+		})
+	}
+	return fedits
+}
 
-	// TODO(zchee): 'edit' command is ugly.
-	// Should create tempfile and use SetBufferLines.
-	return c.Nvim.Command("silent edit")
+// renameConflicts converts the rename conflicts to the quickfix errors.
+func renameConflicts(conflicts []rename.Conflict) []*nvim.QuickfixError {
+	errs := make([]*nvim.QuickfixError, 0, len(conflicts))
+	for _, c := range conflicts {
+		errs = append(errs, &nvim.QuickfixError{
+			FileName: c.Pos.Filename,
+			LNum:     c.Pos.Line,
+			Col:      c.Pos.Column,
+			Text:     strings.TrimSpace(c.Message),
+			Type:     "E",
+		})
+	}
+	return errs
 }
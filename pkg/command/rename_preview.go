@@ -0,0 +1,313 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"go.uber.org/zap"
+
+	"github.com/zchee/nvim-go/pkg/internal/rename"
+	"github.com/zchee/nvim-go/pkg/logger"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// renamePreviewBufferName buffer name of the GoRename preview.
+const renamePreviewBufferName = "__GO_RENAME__"
+
+const (
+	renameSelected   = "  [x] "
+	renameUnselected = "  [ ] "
+	renameConflict   = "  [!] "
+)
+
+// renamePreview represents a pending renaming which shown in the preview buffer.
+type renamePreview struct {
+	from, to string
+	res      *rename.Result
+	force    bool
+
+	buffer nvim.Buffer
+	// lines maps the preview buffer line (0-based) to the index of res.Edits.
+	lines map[int]int
+	// stamps records the state of the edited files when the preview is opened.
+	stamps map[string]string
+}
+
+// errRenamePreviewStale is returned when the edited files are changed since the preview is opened.
+var errRenamePreviewStale = errors.Errorf("rename preview is stale, rerun :%s", pkgRename)
+
+// fileStamps returns the state of each edited file keyed by the file name.
+// The state is the b:changedtick of the loaded buffer, or the modification time and size of the file.
+func (c *Command) fileStamps(edits []rename.Edit) (map[string]string, error) {
+	loaded, err := nvimutil.LoadedBuffers(c.Nvim)
+	if err != nil {
+		return nil, err
+	}
+
+	stamps := make(map[string]string)
+	for _, e := range edits {
+		filename := e.Pos.Filename
+		if _, ok := stamps[filename]; ok {
+			continue
+		}
+		if b, ok := loaded[filename]; ok {
+			tick, err := c.Nvim.BufferChangedTick(b)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			stamps[filename] = fmt.Sprintf("buffer %d %d", b, tick)
+			continue
+		}
+		fi, err := os.Stat(filename)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		stamps[filename] = fmt.Sprintf("file %d %d", fi.ModTime().UnixNano(), fi.Size())
+	}
+
+	return stamps, nil
+}
+
+// format formats the preview buffer contents, and records the line of each edit.
+// The conflicts are placed right under the occurrence which they affect.
+// src returns the lines of filename.
+func (p *renamePreview) format(src func(filename string) [][]byte) []byte {
+	var buf bytes.Buffer
+	line := 0
+	writeln := func(format string, a ...interface{}) {
+		fmt.Fprintf(&buf, format+"\n", a...)
+		line++
+	}
+	writeConflicts := func(conflicts []rename.Conflict, filename string) {
+		for _, c := range conflicts {
+			pos := c.Pos.String()
+			if c.Pos.Filename == filename {
+				pos = fmt.Sprintf("%d:%d", c.Pos.Line, c.Pos.Column)
+			}
+			writeln("%s%s: %s", renameConflict, pos, strings.TrimSpace(c.Message))
+		}
+	}
+
+	writeln("\" %s: %s -> %s", pkgRename, p.from, p.to)
+	writeln("\" <Space>: toggle the occurrence, <CR>: apply the selected occurrences, q: cancel")
+	if len(p.res.Conflicts) > 0 && !p.force {
+		writeln("\" Warning: renaming has conflicts, use :%s!? to force", pkgRename)
+	}
+
+	conflicts, unassigned := p.assignConflicts()
+	p.lines = make(map[int]int)
+	var lines [][]byte
+	for i, e := range p.res.Edits {
+		if i == 0 || p.res.Edits[i-1].Pos.Filename != e.Pos.Filename {
+			writeln("")
+			writeln("%s", e.Pos.Filename)
+			lines = src(e.Pos.Filename)
+		}
+
+		var text string
+		if n := e.Pos.Line - 1; n < len(lines) {
+			text = string(bytes.TrimSpace(lines[n]))
+		}
+		p.lines[line] = i
+		writeln("%s%d:%d: %s", renameSelected, e.Pos.Line, e.Pos.Column, text)
+		writeConflicts(conflicts[i], e.Pos.Filename)
+	}
+
+	// the conflicts in the files which have no occurrence
+	if len(unassigned) > 0 {
+		writeln("")
+		writeln("\" Conflicts")
+		writeConflicts(unassigned, "")
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// assignConflicts assigns each conflict to the index of the edit which the conflict affects.
+// A conflict consists of the primary message and the following detail messages which start with a tab,
+// and is assigned to the edit on the same line of the primary message, or the nearest edit in the same file.
+// assignConflicts returns the conflicts which are not in the edited files as unassigned.
+func (p *renamePreview) assignConflicts() (assigned map[int][]rename.Conflict, unassigned []rename.Conflict) {
+	assigned = make(map[int][]rename.Conflict)
+
+	conflicts := p.res.Conflicts
+	for len(conflicts) > 0 {
+		n := 1
+		for n < len(conflicts) && strings.HasPrefix(conflicts[n].Message, "\t") {
+			n++
+		}
+		group := conflicts[:n]
+		conflicts = conflicts[n:]
+
+		idx, dist := -1, 0
+		for i, e := range p.res.Edits {
+			if e.Pos.Filename != group[0].Pos.Filename {
+				continue
+			}
+			d := e.Pos.Line - group[0].Pos.Line
+			if d < 0 {
+				d = -d
+			}
+			if idx == -1 || d < dist || (d == 0 && e.Pos.Column == group[0].Pos.Column) {
+				idx, dist = i, d
+			}
+		}
+		if idx == -1 {
+			unassigned = append(unassigned, group...)
+			continue
+		}
+		assigned[idx] = append(assigned[idx], group...)
+	}
+
+	return assigned, unassigned
+}
+
+// selected returns the edits which selected in the preview buffer lines.
+func (p *renamePreview) selected(lines [][]byte) []rename.Edit {
+	var edits []rename.Edit
+	for i, line := range lines {
+		idx, ok := p.lines[i]
+		if !ok {
+			continue
+		}
+		if bytes.HasPrefix(line, []byte(renameSelected)) {
+			edits = append(edits, p.res.Edits[idx])
+		}
+	}
+	return edits
+}
+
+// openRenamePreview opens the preview buffer of p, and holds p as the pending renaming.
+func (c *Command) openRenamePreview(ctx context.Context, p *renamePreview, overlay map[string][]byte) error {
+	// the source lines of the occurrences, use the unsaved buffer contents if exist
+	src := func(filename string) [][]byte {
+		if data, ok := overlay[filename]; ok {
+			return nvimutil.ToBufferLines(data)
+		}
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil
+		}
+		return nvimutil.ToBufferLines(data)
+	}
+	data := p.format(src)
+
+	stamps, err := c.fileStamps(p.res.Edits)
+	if err != nil {
+		return err
+	}
+	p.stamps = stamps
+
+	b := nvimutil.NewBuffer(c.Nvim)
+	option := map[nvimutil.NvimOption]map[string]interface{}{
+		nvimutil.BufferOption: {
+			nvimutil.BufOptionBufhidden: nvimutil.BufhiddenWipe,
+			nvimutil.BufOptionBuflisted: false,
+			nvimutil.BufOptionBuftype:   nvimutil.BuftypeNofile,
+			nvimutil.BufOptionFiletype:  nvimutil.FiletypeGoRename,
+			nvimutil.BufOptionSwapfile:  false,
+		},
+	}
+	if err := b.Create(renamePreviewBufferName, nvimutil.FiletypeGoRename, "belowright new", option); err != nil {
+		return errors.WithStack(err)
+	}
+
+	c.Nvim.SetBufferOption(b.Buffer(), nvimutil.BufOptionModifiable, true)
+	if err := b.SetBufferLines(0, -1, true, data); err != nil {
+		return errors.WithStack(err)
+	}
+	c.Nvim.SetBufferOption(b.Buffer(), nvimutil.BufOptionModifiable, false)
+	p.buffer = b.Buffer()
+
+	c.mu.Lock()
+	c.renamePreview = p
+	c.mu.Unlock()
+
+	toggle := `setlocal modifiable <Bar> call setline('.', substitute(getline('.'), '^  \[\zs[x ]\ze\]', '\=submatch(0) ==# "x" ? " " : "x"', '')) <Bar> setlocal nomodifiable`
+	return b.SetLocalMapping("nnoremap", map[string]string{
+		"<Space>": ":<C-u>" + toggle + "<CR>",
+		"<CR>":    ":<C-u>GoRenameApply<CR>",
+		"q":       ":<C-u>quit<CR>",
+	})
+}
+
+func (c *Command) cmdRenameApply(ctx context.Context) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.RenameApply(ctx)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// RenameApply applies the occurrences which selected in the GoRename preview buffer.
+func (c *Command) RenameApply(pctx context.Context) error {
+	ctx, span := monitoring.StartSpan(pctx, "RenameApply")
+	defer span.End()
+
+	c.mu.Lock()
+	p := c.renamePreview
+	c.mu.Unlock()
+	if p == nil || !nvimutil.IsBufferValid(c.Nvim, p.buffer) {
+		return errors.New("GoRenameApply: no pending rename preview")
+	}
+	if len(p.res.Conflicts) > 0 && !p.force {
+		return errors.Errorf("GoRenameApply: renaming has %d conflicts, use :%s!? to force", len(p.res.Conflicts), pkgRename)
+	}
+
+	// the offsets of the edits are only valid for the contents when the preview is opened
+	stamps, err := c.fileStamps(p.res.Edits)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(stamps, p.stamps) {
+		return errRenamePreviewStale
+	}
+
+	lines, err := c.Nvim.BufferLines(p.buffer, 0, -1, true)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	edits := p.selected(lines)
+	logger.FromContext(ctx).Debug("RenameApply", zap.Int("selected", len(edits)), zap.Int("edits", len(p.res.Edits)))
+
+	if _, err := nvimutil.ApplyFileEdits(c.Nvim, renameEdits(edits)); err != nil {
+		if errors.Cause(err) == nvimutil.ErrStaleEdit {
+			return errRenamePreviewStale
+		}
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	c.mu.Lock()
+	c.renamePreview = nil
+	c.mu.Unlock()
+	c.errs.Delete("Rename")
+
+	if err := c.Nvim.Command(fmt.Sprintf("silent! bwipeout %d", p.buffer)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nvimutil.EchoSuccess(c.Nvim, pkgRename, fmt.Sprintf("Renamed %d of %d occurrences.", len(edits), len(p.res.Edits)))
+}
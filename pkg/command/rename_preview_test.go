@@ -0,0 +1,107 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"go/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/zchee/nvim-go/pkg/internal/rename"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+func TestRenamePreview(t *testing.T) {
+	const src = "package foo\n\n// Bar does bar.\nfunc Bar() int { return 1 }\n\nvar x = Bar()\n"
+
+	edit := func(line, col, offset int, comment bool) rename.Edit {
+		return rename.Edit{
+			Pos:     token.Position{Filename: "/foo/foo.go", Offset: offset, Line: line, Column: col},
+			End:     offset + 3,
+			Old:     "Bar",
+			New:     "Baz",
+			Comment: comment,
+		}
+	}
+	res := &rename.Result{
+		Edits: []rename.Edit{
+			edit(3, 4, 16, true),
+			edit(4, 6, 35, false),
+			edit(6, 9, 67, false),
+		},
+		Conflicts: []rename.Conflict{
+			{Pos: token.Position{Filename: "/foo/foo.go", Line: 6, Column: 5}, Message: "\tconflicts with var in same block"},
+		},
+	}
+
+	p := &renamePreview{from: "Bar", to: "Baz", res: res}
+	got := p.format(func(string) [][]byte { return nvimutil.ToBufferLines([]byte(src)) })
+
+	want := `" GoRename: Bar -> Baz
+" <Space>: toggle the occurrence, <CR>: apply the selected occurrences, q: cancel
+" Warning: renaming has conflicts, use :GoRename!? to force
+
+/foo/foo.go
+  [x] 3:4: // Bar does bar.
+  [x] 4:6: func Bar() int { return 1 }
+  [x] 6:9: var x = Bar()
+  [!] 6:5: conflicts with var in same block`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("format: (-want +got)\n%s", diff)
+	}
+
+	// toggle off the doc comment occurrence
+	lines := nvimutil.ToBufferLines(got)
+	lines[5] = bytes.Replace(lines[5], []byte(renameSelected), []byte(renameUnselected), 1)
+	if diff := cmp.Diff(res.Edits[1:], p.selected(lines)); diff != "" {
+		t.Errorf("selected: (-want +got)\n%s", diff)
+	}
+}
+
+func TestRenamePreview_conflicts(t *testing.T) {
+	const src = "package foo\n\nfunc Bar() {}\n\nfunc baz() {\n\tvar Baz int\n\tBar()\n\t_ = Baz\n}\n"
+
+	pos := func(filename string, line, col int) token.Position {
+		return token.Position{Filename: filename, Line: line, Column: col}
+	}
+	edit := func(line, col int) rename.Edit {
+		return rename.Edit{Pos: pos("/foo/foo.go", line, col), Old: "Bar", New: "Baz"}
+	}
+	res := &rename.Result{
+		Edits: []rename.Edit{edit(3, 6), edit(7, 2)},
+		Conflicts: []rename.Conflict{
+			{Pos: pos("/foo/foo.go", 7, 2), Message: `renaming this func "Bar" to "Baz"`},
+			{Pos: pos("/foo/foo.go", 6, 6), Message: "\twould shadow this reference"},
+			{Pos: pos("/foo/foo.go", 3, 6), Message: "\tto the func declared here"},
+			{Pos: pos("/foo/bar.go", 1, 1), Message: "renaming this func \"Bar\" to \"Baz\" would conflict"},
+		},
+	}
+
+	p := &renamePreview{from: "Bar", to: "Baz", res: res, force: true}
+	got := p.format(func(string) [][]byte { return nvimutil.ToBufferLines([]byte(src)) })
+
+	want := `" GoRename: Bar -> Baz
+" <Space>: toggle the occurrence, <CR>: apply the selected occurrences, q: cancel
+
+/foo/foo.go
+  [x] 3:6: func Bar() {}
+  [x] 7:2: Bar()
+  [!] 7:2: renaming this func "Bar" to "Baz"
+  [!] 6:6: would shadow this reference
+  [!] 3:6: to the func declared here
+
+" Conflicts
+  [!] /foo/bar.go:1:1: renaming this func "Bar" to "Baz" would conflict`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("format: (-want +got)\n%s", diff)
+	}
+
+	// the conflict lines are not the occurrences
+	if diff := cmp.Diff(res.Edits, p.selected(nvimutil.ToBufferLines(got))); diff != "" {
+		t.Errorf("selected: (-want +got)\n%s", diff)
+	}
+}
@@ -16,11 +16,6 @@ import (
 	"github.com/zchee/nvim-go/pkg/nvimutil"
 )
 
-var (
-	runTerm     *nvimutil.Terminal
-	runLastArgs []string
-)
-
 func (c *Command) cmdRun(ctx context.Context, args []string, file string) {
 	errch := make(chan interface{}, 1)
 	go func() {
@@ -33,7 +28,7 @@ func (c *Command) cmdRun(ctx context.Context, args []string, file string) {
 	case err := <-errch:
 		switch e := err.(type) {
 		case error:
-			nvimutil.ErrorWrap(c.Nvim, e)
+			nvimutil.ErrorWrap(ctx, c.Nvim, e)
 		case []*nvim.QuickfixError:
 			c.errs.Store("Run", e)
 			errlist := make(map[string][]*nvim.QuickfixError)
@@ -42,7 +37,7 @@ func (c *Command) cmdRun(ctx context.Context, args []string, file string) {
 				errlist[k] = append(errlist[k], v...)
 				return true
 			})
-			nvimutil.ErrorList(c.Nvim, errlist, true)
+			nvimutil.ErrorList(ctx, c.Nvim, errlist, true)
 		case nil:
 			// nothing to do
 		}
@@ -50,9 +45,12 @@ func (c *Command) cmdRun(ctx context.Context, args []string, file string) {
 }
 
 func (c *Command) cmdRunLast(ctx context.Context, file string) {
+	c.mu.Lock()
+	runLastArgs := c.runLastArgs
+	c.mu.Unlock()
 	if len(runLastArgs) == 0 {
 		err := errors.New("not found GoRun last arguments")
-		nvimutil.ErrorWrap(c.Nvim, err)
+		nvimutil.ErrorWrap(ctx, c.Nvim, err)
 		return
 	}
 
@@ -67,7 +65,7 @@ func (c *Command) cmdRunLast(ctx context.Context, file string) {
 	case err := <-errch:
 		switch e := err.(type) {
 		case error:
-			nvimutil.ErrorWrap(c.Nvim, e)
+			nvimutil.ErrorWrap(ctx, c.Nvim, e)
 		case []*nvim.QuickfixError:
 			c.errs.Store("Run", e)
 			errlist := make(map[string][]*nvim.QuickfixError)
@@ -76,7 +74,7 @@ func (c *Command) cmdRunLast(ctx context.Context, file string) {
 				errlist[k] = append(errlist[k], v...)
 				return true
 			})
-			nvimutil.ErrorList(c.Nvim, errlist, true)
+			nvimutil.ErrorList(ctx, c.Nvim, errlist, true)
 		case nil:
 			// nothing to do
 		}
@@ -85,18 +83,24 @@ func (c *Command) cmdRunLast(ctx context.Context, file string) {
 
 // Run runs the go run command for current buffer's packages.
 func (c *Command) Run(ctx context.Context, args []string, file string) error {
+	cfg := config.FromContext(ctx)
+
 	cmd := []string{"go", "run", file}
+
+	c.mu.Lock()
 	if len(args) != 0 {
-		runLastArgs = args
+		c.runLastArgs = args
 		cmd = append(cmd, args...)
 	}
-
-	if runTerm == nil {
-		runTerm = nvimutil.NewTerminal(c.Nvim, "__GO_RUN__", cmd, config.TerminalMode)
+	if c.runTerm == nil {
+		c.runTerm = nvimutil.NewTerminal(c.Nvim, "__GO_RUN__", cmd, cfg.Terminal.Mode)
 	}
+	runTerm := c.runTerm
+	c.mu.Unlock()
 	runTerm.Dir = fs.FindVCSRoot(filepath.Dir(file))
+	runTerm.Env = c.buildContext.Env()
 
-	if err := runTerm.Run(cmd); err != nil {
+	if err := runTerm.Run(ctx, cmd); err != nil {
 		return errors.WithStack(err)
 	}
 
@@ -0,0 +1,250 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"go/types"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"golang.org/x/tools/go/ast/astutil"
+
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// signatureFloat represents the floating window of the signature help.
+type signatureFloat struct {
+	float    *nvimutil.Float
+	prefix   string   // the buffer contents until the left parenthesis of the call
+	label    string   // the signature shown in the floating window
+	params   [][2]int // the byte range of each parameter in label
+	variadic bool
+}
+
+// SignatureHelp shows the signature of the function which is called at the cursor in the floating window above the cursor,
+// and highlights the parameter of the argument at the cursor.
+// line and col are the 1-based cursor position of the buffer bufnr, such as the result of line('.') and col('.').
+// SignatureHelp closes the floating window if the cursor is not in the arguments of the call.
+func (c *Command) SignatureHelp(pctx context.Context, bufnr int, file string, line, col int) error {
+	ctx, span := monitoring.StartSpan(pctx, "SignatureHelp")
+	defer span.End()
+
+	b := nvim.Buffer(bufnr)
+	buflines, err := c.Nvim.BufferLines(b, 0, -1, true)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	src := nvimutil.ToByteSlice(buflines)
+
+	offset := bufferOffset(buflines, line, col)
+	if offset > len(src) {
+		offset = len(src)
+	}
+
+	lparen, active, ok := callParen(src, offset)
+	if !ok || file == "" {
+		return c.closeSignature()
+	}
+
+	c.mu.Lock()
+	sf := c.signature
+	c.mu.Unlock()
+	prefix := string(src[:lparen+1])
+	if sf == nil || sf.prefix != prefix || !nvimutil.IsWindowValid(c.Nvim, sf.float.Window) {
+		f, ok := c.checker.check(ctx, file, src)
+		if !ok {
+			return c.closeSignature()
+		}
+		name, sig := calleeSignature(f.Fset, f.File, f.Info, lparen)
+		if sig == nil {
+			return c.closeSignature()
+		}
+		label, params := signatureLabel(name, sig, types.RelativeTo(f.Pkg))
+
+		if err := c.closeSignature(); err != nil {
+			span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+			return err
+		}
+		float, err := nvimutil.OpenFloatAbove(c.Nvim, [][]byte{[]byte(label)}, "go", "InsertLeave", "BufLeave")
+		if err != nil {
+			span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+			return errors.WithStack(err)
+		}
+		sf = &signatureFloat{float: float, prefix: prefix, label: label, params: params, variadic: sig.Variadic()}
+
+		c.mu.Lock()
+		c.signature = sf
+		c.mu.Unlock()
+	}
+
+	nsID, err := c.Nvim.CreateNamespace("nvim-go-signature")
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	batch := c.Nvim.NewBatch()
+	batch.ClearBufferNamespace(sf.float.Buffer, nsID, 0, -1)
+	if i := activeParameter(active, len(sf.params), sf.variadic); i >= 0 {
+		var id int
+		batch.SetBufferExtmark(sf.float.Buffer, nsID, 0, sf.params[i][0], map[string]interface{}{
+			"end_col":  sf.params[i][1],
+			"hl_group": "GoSignatureActiveParameter",
+		}, &id)
+	}
+	if err := batch.Execute(); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// closeSignature closes the floating window of the signature help if opened.
+func (c *Command) closeSignature() error {
+	c.mu.Lock()
+	sf := c.signature
+	c.signature = nil
+	c.mu.Unlock()
+
+	if sf == nil || !nvimutil.IsWindowValid(c.Nvim, sf.float.Window) {
+		return nil
+	}
+	return errors.WithStack(c.Nvim.CloseWindow(sf.float.Window, true))
+}
+
+// callParen returns the byte offset of the left parenthesis of the innermost parentheses
+// which encloses the byte offset of src, and the number of the commas between them.
+// callParen reports false if the innermost bracket is not the parenthesis, such as the body of the function literal.
+func callParen(src []byte, offset int) (lparen, commas int, ok bool) {
+	type bracket struct {
+		tok    token.Token
+		offset int
+		commas int
+	}
+	var stack []bracket
+
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile("", -1, offset), src[:offset], nil, 0)
+	for {
+		pos, tok, _ := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		switch tok {
+		case token.LPAREN, token.LBRACK, token.LBRACE:
+			stack = append(stack, bracket{tok: tok, offset: fset.Position(pos).Offset})
+		case token.RPAREN, token.RBRACK, token.RBRACE:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case token.COMMA:
+			if len(stack) > 0 {
+				stack[len(stack)-1].commas++
+			}
+		}
+	}
+
+	if len(stack) == 0 || stack[len(stack)-1].tok != token.LPAREN {
+		return 0, 0, false
+	}
+	top := stack[len(stack)-1]
+	return top.offset, top.commas, true
+}
+
+// calleeSignature returns the name and the signature of the function which is called with the left parenthesis
+// at the byte offset lparen of file. The name is "func" if the function has no name such as the function literal.
+func calleeSignature(fset *token.FileSet, file *ast.File, info *types.Info, lparen int) (string, *types.Signature) {
+	tokFile := fset.File(file.Pos())
+	if lparen <= 0 || lparen > tokFile.Size() {
+		return "", nil
+	}
+	pos := tokFile.Pos(lparen)
+
+	// the callee expression ends at the left parenthesis even if the call is incomplete
+	path, _ := astutil.PathEnclosingInterval(file, pos-1, pos-1)
+	for _, node := range path {
+		expr, ok := node.(ast.Expr)
+		if !ok || expr.End() != pos {
+			continue
+		}
+		if tv, ok := info.Types[expr]; ok && tv.IsType() {
+			return "", nil // the conversion
+		}
+		sig, ok := info.TypeOf(expr).(*types.Signature)
+		if !ok {
+			continue
+		}
+		switch expr := expr.(type) {
+		case *ast.Ident:
+			return expr.Name, sig
+		case *ast.SelectorExpr:
+			return expr.Sel.Name, sig
+		}
+		return "func", sig
+	}
+
+	return "", nil
+}
+
+// signatureLabel returns the signature of the function name such as "func Name(a int, b ...string) error",
+// and the byte range of each parameter in it.
+func signatureLabel(name string, sig *types.Signature, qf types.Qualifier) (string, [][2]int) {
+	var buf bytes.Buffer
+	buf.WriteString("func")
+	if name != "func" {
+		buf.WriteString(" " + name)
+	}
+	buf.WriteString("(")
+
+	params := make([][2]int, sig.Params().Len())
+	for i := 0; i < sig.Params().Len(); i++ {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		start := buf.Len()
+		v := sig.Params().At(i)
+		if v.Name() != "" {
+			buf.WriteString(v.Name() + " ")
+		}
+		if s, ok := v.Type().(*types.Slice); ok && sig.Variadic() && i == sig.Params().Len()-1 {
+			buf.WriteString("..." + types.TypeString(s.Elem(), qf))
+		} else {
+			buf.WriteString(types.TypeString(v.Type(), qf))
+		}
+		params[i] = [2]int{start, buf.Len()}
+	}
+	buf.WriteString(")")
+
+	results := sig.Results()
+	switch {
+	case results.Len() == 1 && results.At(0).Name() == "":
+		buf.WriteString(" " + types.TypeString(results.At(0).Type(), qf))
+	case results.Len() > 0:
+		buf.WriteString(" " + types.TypeString(results, qf))
+	}
+
+	return buf.String(), params
+}
+
+// activeParameter returns the index of the parameter of the argument after the commas,
+// or -1 if the function has no such parameter.
+func activeParameter(commas, params int, variadic bool) int {
+	switch {
+	case commas < params:
+		return commas
+	case variadic && params > 0:
+		return params - 1
+	}
+	return -1
+}
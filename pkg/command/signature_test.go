@@ -0,0 +1,97 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"go/types"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const signatureSrc = `package p
+
+import "strings"
+
+func sum(base int, rest ...int) (total int) { return base }
+
+func f(s string) {
+	sum(1, 2, 3`
+
+func TestSignatureHelp(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string // the source which is typed after signatureSrc, the cursor is at the end
+		want   string
+		active int
+	}{
+		{
+			name:   "Variadic",
+			src:    ", ",
+			want:   "func sum(base int, rest ...int) (total int)",
+			active: 1,
+		},
+		{
+			name:   "Qualified",
+			src:    ")\n\tstrings.Replace(s, \"(\", ",
+			want:   "func Replace(s string, old string, new string, n int) string",
+			active: 2,
+		},
+		{
+			name:   "Nested",
+			src:    ", strings.ToUpper(",
+			want:   "func ToUpper(s string) string",
+			active: 0,
+		},
+		{
+			name: "FuncLit",
+			src:  ", func() {\n\t\t",
+		},
+		{
+			name: "Conversion",
+			src:  ")\n\t_ = int64(",
+		},
+		{
+			name: "Closed",
+			src:  ")\n\t",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			src := []byte(signatureSrc + tt.src)
+			var label string
+			var params [][2]int
+			var active int
+			if lparen, commas, ok := callParen(src, len(src)); ok {
+				var tc typeChecker
+				f, ok := tc.check(context.Background(), filepath.Join(t.TempDir(), "p.go"), src)
+				if !ok {
+					t.Fatal("failed to parse")
+				}
+				if name, sig := calleeSignature(f.Fset, f.File, f.Info, lparen); sig != nil {
+					label, params = signatureLabel(name, sig, types.RelativeTo(f.Pkg))
+					active = activeParameter(commas, len(params), sig.Variadic())
+				}
+			}
+
+			if label != tt.want {
+				t.Fatalf("%s: got %q, want %q", tt.name, label, tt.want)
+			}
+			if label == "" {
+				return
+			}
+			if active != tt.active {
+				t.Fatalf("%s: got active parameter %d, want %d", tt.name, active, tt.active)
+			}
+			if p := label[params[active][0]:params[active][1]]; !strings.Contains(label, "("+p) && !strings.Contains(label, ", "+p) {
+				t.Errorf("%s: invalid range of the parameter %q", tt.name, p)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/zchee/nvim-go/pkg/config"
+	"github.com/zchee/nvim-go/pkg/fs"
+	"github.com/zchee/nvim-go/pkg/internal/gopathwalk"
+	"github.com/zchee/nvim-go/pkg/internal/pkgindex"
+	"github.com/zchee/nvim-go/pkg/internal/symbols"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// symbolsBufferName buffer name of the GoSymbols picker.
+const symbolsBufferName = "__GO_SYMBOLS__"
+
+// symbolsLimit is the maximum number of the GoSymbols results.
+const symbolsLimit = 200
+
+// symbolsKey represents the GoSymbols workspace, which is the Go module root and whether includes its dependencies.
+type symbolsKey struct {
+	root string
+	deps bool
+}
+
+// symbolsIndex represents the symbols index of the GoSymbols workspace, and the refresh policy of it.
+type symbolsIndex struct {
+	*symbols.Index
+	refresh refreshPolicy
+}
+
+type cmdSymbolsEval struct {
+	Cwd  string `msgpack:",array"`
+	File string
+}
+
+func (c *Command) cmdSymbols(ctx context.Context, args []string, eval *cmdSymbolsEval) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.Symbols(ctx, args, eval)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// Symbols fuzzily searches the top-level declarations and the methods in the workspace by the query args[0],
+// and shows the results in the quickfix list or the picker buffer.
+// The workspace is the Go module of the current buffer, and its dependencies if go#symbols#deps is enabled.
+func (c *Command) Symbols(pctx context.Context, args []string, eval *cmdSymbolsEval) error {
+	ctx, span := monitoring.StartSpan(pctx, "Symbols")
+	defer span.End()
+
+	cfg := config.FromContext(ctx)
+
+	dir := eval.Cwd
+	if eval.File != "" {
+		dir = filepath.Dir(eval.File)
+	}
+	root, ok := fs.FindModuleRoot(dir)
+	if !ok {
+		root = eval.Cwd
+	}
+	modPath, _ := fs.ModulePath(root)
+	roots := []pkgindex.Root{{Root: gopathwalk.Root{Path: root, Type: gopathwalk.RootCurrentModule}, ImportPath: modPath}}
+	if cfg.Symbols.Deps {
+		for _, mod := range c.moduleDependencies(root) {
+			roots = append(roots, pkgindex.Root{Root: gopathwalk.Root{Path: mod.Dir, Type: gopathwalk.RootModuleCache}, ImportPath: mod.Path})
+		}
+	}
+
+	key := symbolsKey{root: root, deps: cfg.Symbols.Deps}
+	v, ok := c.symbols.Load(key)
+	if !ok {
+		v, _ = c.symbols.LoadOrStore(key, &symbolsIndex{Index: symbols.New()})
+	}
+	ix := v.(*symbolsIndex)
+	var stale []pkgindex.Root
+	for _, r := range roots {
+		if ix.refresh.stale(r.Path, root) {
+			stale = append(stale, r)
+		}
+	}
+	if err := ix.Refresh(stale); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoSymbols")
+	}
+
+	matches := ix.Search(strings.Join(args, ""), symbolsLimit)
+	if len(matches) == 0 {
+		err := errors.Errorf("GoSymbols: no symbols match %q", strings.Join(args, ""))
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return err
+	}
+
+	if cfg.Symbols.List == "picker" {
+		if err := c.openPicker(symbolsBufferName, symbolItems(matches, eval.Cwd)); err != nil {
+			span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+			return err
+		}
+		return nil
+	}
+
+	qflist := make([]*nvim.QuickfixError, len(matches))
+	for i, m := range matches {
+		qflist[i] = &nvim.QuickfixError{
+			FileName: m.File,
+			LNum:     m.Line,
+			Col:      m.Col,
+			Text:     m.Kind.String() + " " + m.QualifiedName(),
+		}
+	}
+	w, err := c.Nvim.CurrentWindow()
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+	batch := c.Nvim.NewBatch()
+	nvimutil.SetQuickfix(batch, qflist)
+	if err := nvimutil.OpenOuickfix(batch, w, false); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// symbolItems returns the picker items of the matches.
+// The file names of the picker lines are relative to cwd if the files are under cwd.
+func symbolItems(matches []symbols.Match, cwd string) []pickerItem {
+	width := 0
+	for _, m := range matches {
+		if n := len(m.QualifiedName()); n > width {
+			width = n
+		}
+	}
+
+	items := make([]pickerItem, len(matches))
+	for i, m := range matches {
+		file := m.File
+		if rel, err := filepath.Rel(cwd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+		items[i] = pickerItem{
+			text: fmt.Sprintf("%-*s  %-6s  %s:%d", width, m.QualifiedName(), m.Kind, file, m.Line),
+			file: m.File,
+			line: m.Line,
+			col:  m.Col,
+		}
+	}
+	return items
+}
@@ -0,0 +1,34 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/zchee/nvim-go/pkg/internal/symbols"
+)
+
+func TestSymbolItems(t *testing.T) {
+	cwd := filepath.FromSlash("/src/example.com/m")
+	matches := []symbols.Match{
+		{Symbol: symbols.Symbol{Name: "Serve", Recv: "Server", Kind: symbols.Method, File: filepath.Join(cwd, "server.go"), Line: 10}},
+		{Symbol: symbols.Symbol{Name: "Server", Kind: symbols.Type, File: filepath.FromSlash("/mod/example.com/dep/dep.go"), Line: 3}},
+	}
+
+	want := []string{
+		"Server.Serve  method  server.go:10",
+		"Server        type    " + filepath.FromSlash("/mod/example.com/dep/dep.go") + ":3",
+	}
+	var got []string
+	for _, item := range symbolItems(matches, cwd) {
+		got = append(got, item.text)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
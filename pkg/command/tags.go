@@ -0,0 +1,349 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/zchee/nvim-go/pkg/internal/strutil"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+	"github.com/zchee/nvim-go/pkg/nvimutil"
+)
+
+// tagTransforms is the naming conventions of the tag names which generated from the field names.
+var tagTransforms = map[string]func(string) string{
+	"snakecase":  strutil.ToSnakeCase,
+	"camelcase":  nvimutil.ToMixedCase,
+	"pascalcase": nvimutil.ToPascalCase,
+	"kebabcase":  strutil.ToKebab,
+	"lispcase":   strutil.ToKebab,
+	"keep":       func(s string) string { return s },
+}
+
+// defaultTagKey is the tag key which used if the keys are not specified in GoAddTags.
+const defaultTagKey = "json"
+
+func (c *Command) cmdAddTags(ctx context.Context, args []string, ranges [2]int, file string) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.AddTags(ctx, args, ranges, file)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// AddTags adds the struct tags of the keys such as "json,yaml" to the fields of the struct under the cursor,
+// or the fields in the range. The tag names are generated from the field names by "-transform" option,
+// and the existing options such as "omitempty" are kept.
+func (c *Command) AddTags(pctx context.Context, args []string, ranges [2]int, file string) error {
+	ctx, span := monitoring.StartSpan(pctx, "AddTags")
+	defer span.End()
+
+	keys, transform, err := parseTagsArgs(args)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return errors.Wrap(err, "GoAddTags")
+	}
+	if len(keys) == 0 {
+		keys = []string{defaultTagKey}
+	}
+
+	if err := c.rewriteTags(ctx, file, ranges, func(tags structTag, name string) structTag {
+		return tags.add(keys, transform(name))
+	}); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoAddTags")
+	}
+
+	return nil
+}
+
+func (c *Command) cmdRemoveTags(ctx context.Context, args []string, ranges [2]int, file string) {
+	errch := make(chan error, 1)
+	go func() {
+		errch <- c.RemoveTags(ctx, args, ranges, file)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case err := <-errch:
+		if err != nil {
+			nvimutil.ErrorWrap(ctx, c.Nvim, err)
+		}
+	}
+}
+
+// RemoveTags removes the struct tags of the keys from the fields of the struct under the cursor,
+// or the fields in the range. All tags are removed if the keys are not specified.
+func (c *Command) RemoveTags(pctx context.Context, args []string, ranges [2]int, file string) error {
+	ctx, span := monitoring.StartSpan(pctx, "RemoveTags")
+	defer span.End()
+
+	keys, _, err := parseTagsArgs(args)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return errors.Wrap(err, "GoRemoveTags")
+	}
+
+	if err := c.rewriteTags(ctx, file, ranges, func(tags structTag, _ string) structTag {
+		return tags.remove(keys)
+	}); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return errors.Wrap(err, "GoRemoveTags")
+	}
+
+	return nil
+}
+
+// rewriteTags rewrites the struct tags of the fields in the buffer by fn, and updates the buffer.
+func (c *Command) rewriteTags(ctx context.Context, file string, ranges [2]int, fn func(tags structTag, name string) structTag) error {
+	b := nvim.Buffer(c.buildContext.BufNr)
+	buflines, err := c.Nvim.BufferLines(b, 0, -1, true)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	out, err := tagsSource(file, nvimutil.ToByteSlice(buflines), ranges[0], ranges[1], fn)
+	if err != nil {
+		return err
+	}
+
+	return minUpdate(ctx, c.Nvim, b, buflines, nvimutil.ToBufferLines(bytes.TrimSuffix(out, []byte{'\n'})))
+}
+
+// parseTagsArgs parses the arguments of GoAddTags and GoRemoveTags such as "json,yaml -transform camelcase".
+func parseTagsArgs(args []string) (keys []string, transform func(string) string, err error) {
+	transform = tagTransforms["snakecase"]
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-transform" {
+			if i+1 >= len(args) {
+				return nil, nil, errors.New("-transform requires the naming convention")
+			}
+			i++
+			fn, ok := tagTransforms[args[i]]
+			if !ok {
+				return nil, nil, errors.Errorf("unknown transform %q", args[i])
+			}
+			transform = fn
+			continue
+		}
+		for _, key := range strings.Split(args[i], ",") {
+			if key != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys, transform, nil
+}
+
+// tagsSource rewrites the struct tags of the fields in the line range [line1, line2] of src by fn,
+// and returns the formatted source.
+// If the range is the single line, the target is all fields of the innermost struct type which contains the line.
+func tagsSource(file string, src []byte, line1, line2 int, fn func(tags structTag, name string) structTag) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.ParseComments)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	tf := fset.File(f.Pos())
+
+	var fields []*ast.Field
+	if line1 == line2 {
+		var st *ast.StructType
+		ast.Inspect(f, func(n ast.Node) bool {
+			if n, ok := n.(*ast.StructType); ok && tf.Line(n.Pos()) <= line1 && line1 <= tf.Line(n.End()) {
+				st = n
+			}
+			return true
+		})
+		if st == nil {
+			return nil, errors.New("no struct type at the cursor")
+		}
+		fields = st.Fields.List
+	} else {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if n, ok := n.(*ast.StructType); ok {
+				for _, field := range n.Fields.List {
+					if line := tf.Line(field.Pos()); line1 <= line && line <= line2 {
+						fields = append(fields, field)
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	type edit struct {
+		start, end int
+		new        string
+	}
+	var edits []edit
+	for _, field := range fields {
+		if len(field.Names) == 0 || field.Names[0].Name == "_" {
+			continue // embedded or blank field
+		}
+
+		var tags structTag
+		if field.Tag != nil {
+			s, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			tags = parseStructTag(s)
+		}
+		tags = fn(tags, field.Names[0].Name)
+
+		start, end := tf.Offset(field.Type.End()), tf.Offset(field.Type.End())
+		if field.Tag != nil {
+			end = tf.Offset(field.Tag.End())
+		}
+		edits = append(edits, edit{start: start, end: end, new: tags.literal()})
+	}
+	if len(edits) == 0 {
+		return nil, errors.New("no struct fields to rewrite the tags")
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var buf bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		buf.Write(src[last:e.start])
+		buf.WriteString(e.new)
+		last = e.end
+	}
+	buf.Write(src[last:])
+
+	return format.Source(buf.Bytes())
+}
+
+// structTag represents the key:"value" pairs of the struct tag in order.
+type structTag []tagPair
+
+// tagPair represents the key:"value" pair of the struct tag.
+type tagPair struct {
+	key, value string
+}
+
+// parseStructTag parses the conventional struct tag such as `json:"name,omitempty" yaml:"name"`.
+func parseStructTag(tag string) structTag {
+	var tags structTag
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+		i := strings.Index(tag, `:"`)
+		if i <= 0 {
+			break
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// the quoted value, which can contain the escaped quotes
+		j := 1
+		for j < len(tag) && tag[j] != '"' {
+			if tag[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		if j >= len(tag) {
+			break
+		}
+		value, err := strconv.Unquote(tag[:j+1])
+		if err != nil {
+			break
+		}
+		tags = append(tags, tagPair{key: key, value: value})
+		tag = tag[j+1:]
+	}
+	return tags
+}
+
+// add adds the keys with the name, or renames the existing keys keeping the options such as "omitempty".
+func (tags structTag) add(keys []string, name string) structTag {
+	for _, key := range keys {
+		i := tags.index(key)
+		if i < 0 {
+			tags = append(tags, tagPair{key: key, value: name})
+			continue
+		}
+		value := tags[i].value
+		if value == "-" {
+			continue // the field is ignored explicitly
+		}
+		if j := strings.IndexByte(value, ','); j >= 0 {
+			tags[i].value = name + value[j:]
+		} else {
+			tags[i].value = name
+		}
+	}
+	return tags
+}
+
+// remove removes the keys, or all keys if keys is empty.
+func (tags structTag) remove(keys []string) structTag {
+	if len(keys) == 0 {
+		return nil
+	}
+	var rest structTag
+	for _, tag := range tags {
+		removed := false
+		for _, key := range keys {
+			if tag.key == key {
+				removed = true
+			}
+		}
+		if !removed {
+			rest = append(rest, tag)
+		}
+	}
+	return rest
+}
+
+// index returns the index of the key, or -1 if the key is not found.
+func (tags structTag) index(key string) int {
+	for i, tag := range tags {
+		if tag.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// literal returns the raw string literal of the struct tag with the leading space,
+// or empty if tags is empty.
+func (tags structTag) literal() string {
+	if len(tags) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(tags))
+	for i, tag := range tags {
+		pairs[i] = fmt.Sprintf("%s:%s", tag.key, strconv.Quote(tag.value))
+	}
+	s := strings.Join(pairs, " ")
+	if strings.ContainsRune(s, '`') {
+		return " " + strconv.Quote(s)
+	}
+	return " `" + s + "`"
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTagsSource(t *testing.T) {
+	const src = `package p
+
+type User struct {
+	ID       int ` + "`json:\"id,omitempty\" db:\"id\"`" + `
+	UserName string
+	Ignored  bool ` + "`json:\"-\"`" + `
+	Address  struct {
+		ZipCode string
+	}
+	Embedded
+}
+
+type Embedded struct{}
+`
+	add := func(args ...string) func(structTag, string) structTag {
+		keys, transform, err := parseTagsArgs(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return func(tags structTag, name string) structTag { return tags.add(keys, transform(name)) }
+	}
+	remove := func(keys ...string) func(structTag, string) structTag {
+		return func(tags structTag, _ string) structTag { return tags.remove(keys) }
+	}
+
+	tests := []struct {
+		name         string
+		line1, line2 int
+		fn           func(structTag, string) structTag
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:  "AddStruct",
+			line1: 5,
+			line2: 5,
+			fn:    add("json,yaml"),
+			want: `package p
+
+type User struct {
+	ID       int    ` + "`json:\"id,omitempty\" db:\"id\" yaml:\"id\"`" + `
+	UserName string ` + "`json:\"user_name\" yaml:\"user_name\"`" + `
+	Ignored  bool   ` + "`json:\"-\" yaml:\"ignored\"`" + `
+	Address  struct {
+		ZipCode string
+	} ` + "`json:\"address\" yaml:\"address\"`" + `
+	Embedded
+}
+
+type Embedded struct{}
+`,
+		},
+		{
+			name:  "AddNested",
+			line1: 8,
+			line2: 8,
+			fn:    add("json", "-transform", "camelcase"),
+			want: `package p
+
+type User struct {
+	ID       int ` + "`json:\"id,omitempty\" db:\"id\"`" + `
+	UserName string
+	Ignored  bool ` + "`json:\"-\"`" + `
+	Address  struct {
+		ZipCode string ` + "`json:\"zipCode\"`" + `
+	}
+	Embedded
+}
+
+type Embedded struct{}
+`,
+		},
+		{
+			name:  "AddRange",
+			line1: 4,
+			line2: 5,
+			fn:    add("json", "-transform", "kebabcase"),
+			want: `package p
+
+type User struct {
+	ID       int    ` + "`json:\"id,omitempty\" db:\"id\"`" + `
+	UserName string ` + "`json:\"user-name\"`" + `
+	Ignored  bool   ` + "`json:\"-\"`" + `
+	Address  struct {
+		ZipCode string
+	}
+	Embedded
+}
+
+type Embedded struct{}
+`,
+		},
+		{
+			name:  "RemoveKey",
+			line1: 3,
+			line2: 3,
+			fn:    remove("json"),
+			want: `package p
+
+type User struct {
+	ID       int ` + "`db:\"id\"`" + `
+	UserName string
+	Ignored  bool
+	Address  struct {
+		ZipCode string
+	}
+	Embedded
+}
+
+type Embedded struct{}
+`,
+		},
+		{
+			name:  "RemoveAll",
+			line1: 4,
+			line2: 4,
+			fn:    remove(),
+			want: `package p
+
+type User struct {
+	ID       int
+	UserName string
+	Ignored  bool
+	Address  struct {
+		ZipCode string
+	}
+	Embedded
+}
+
+type Embedded struct{}
+`,
+		},
+		{
+			name:    "NoStruct",
+			line1:   1,
+			line2:   1,
+			fn:      remove(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tagsSource("p.go", []byte(src), tt.line1, tt.line2, tt.fn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tagsSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"context"
 	"fmt"
 	"go/ast"
-	"go/build"
 	"go/parser"
 	"go/token"
 	"path/filepath"
@@ -42,7 +41,7 @@ func (c *Command) cmdTest(ctx context.Context, args []string, dir string) {
 	case err := <-errch:
 		switch e := err.(type) {
 		case error:
-			nvimutil.ErrorWrap(c.Nvim, e)
+			nvimutil.ErrorWrap(ctx, c.Nvim, e)
 		case []*nvim.QuickfixError:
 			c.errs.Store("Test", e)
 			errlist := make(map[string][]*nvim.QuickfixError)
@@ -51,16 +50,13 @@ func (c *Command) cmdTest(ctx context.Context, args []string, dir string) {
 				errlist[k] = append(errlist[k], v...)
 				return true
 			})
-			nvimutil.ErrorList(c.Nvim, errlist, true)
+			nvimutil.ErrorList(ctx, c.Nvim, errlist, true)
 		case nil:
 			// nothing to do
 		}
 	}
 }
 
-// testTerm cache nvimutil.Terminal use global variable.
-var testTerm *nvimutil.Terminal
-
 // Test run the package test command use compile tool that determined from
 // the directory structure.
 func (c *Command) Test(ctx context.Context, args []string, dir string) error {
@@ -68,16 +64,18 @@ func (c *Command) Test(ctx context.Context, args []string, dir string) error {
 	ctx, span = monitoring.StartSpan(ctx, "Test")
 	defer span.End()
 
-	cmd := []string{c.buildContext.Build.Tool, "test", strings.Join(config.TestFlags, " ")}
+	cfg := config.FromContext(ctx)
+
+	cmd := []string{c.buildContext.Build.Tool, "test", strings.Join(cfg.Test.Flags, " ")}
 	if len(args) > 0 {
 		cmd = append(cmd, args...)
 	}
 
 	var testPkgs []string
-	if config.TestAll {
+	if cfg.Test.AllPackage {
 		switch c.buildContext.Build.Tool {
 		case "go":
-			pkgs, err := fs.FindAllPackage(dir, build.Default, nil, fs.ModeExcludeVendor)
+			pkgs, err := fs.FindAllPackage(dir, *c.buildContext.BuildContext(), nil, fs.ModeExcludeVendor)
 			if err != nil {
 				span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
 				return errors.WithStack(err)
@@ -99,14 +97,18 @@ func (c *Command) Test(ctx context.Context, args []string, dir string) error {
 
 	cmd = append(cmd, testPkgs...)
 
-	if testTerm == nil {
-		testTerm = nvimutil.NewTerminal(c.Nvim, "__GO_TEST__", cmd, config.TerminalMode)
-		testTerm.Dir = fs.FindVCSRoot(dir)
+	c.mu.Lock()
+	if c.testTerm == nil {
+		c.testTerm = nvimutil.NewTerminal(c.Nvim, "__GO_TEST__", cmd, cfg.Terminal.Mode)
+		c.testTerm.Dir = fs.FindVCSRoot(dir)
 	}
+	testTerm := c.testTerm
+	c.mu.Unlock()
+	testTerm.Env = c.buildContext.Env()
 
-	if err := testTerm.Run(cmd); err != nil {
+	if err := testTerm.Run(ctx, cmd); err != nil {
 		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
-		return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
+		return nvimutil.ErrorWrap(ctx, c.Nvim, errors.WithStack(err))
 	}
 
 	return nil
@@ -0,0 +1,113 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/zchee/nvim-go/pkg/internal/refactor"
+	"github.com/zchee/nvim-go/pkg/monitoring"
+)
+
+// typeCheckMaxBase is the FileSet base which the typeChecker resets the FileSet and the importer cache.
+const typeCheckMaxBase = 1 << 28
+
+// typeChecker type-checks the buffer contents with the source importer which caches the imported packages,
+// for the features which run on each change of the buffer such as the semantic highlighting.
+type typeChecker struct {
+	mu   sync.Mutex     // guards the type checking, because the source importer is not safe for concurrent use
+	fset *token.FileSet // the FileSet shared with imp
+	imp  types.Importer // the source importer which caches the imported packages
+}
+
+// check type-checks the buffer contents src of file with the other saved files of the same package.
+// The result has the partial AST and types even if src has the errors during editing,
+// and check reports false only if src cannot be parsed at all.
+func (tc *typeChecker) check(ctx context.Context, file string, src []byte) (*refactor.File, bool) {
+	_, span := monitoring.StartSpan(ctx, "typeChecker.check")
+	defer span.End()
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	tc.reset()
+
+	f, _ := parser.ParseFile(tc.fset, file, src, 0)
+	if f == nil {
+		return nil, false
+	}
+	files := append([]*ast.File{f}, packageSiblings(tc.fset, file, f.Name.Name)...)
+
+	info := &types.Info{
+		Types:     make(map[ast.Expr]types.TypeAndValue),
+		Defs:      make(map[*ast.Ident]types.Object),
+		Uses:      make(map[*ast.Ident]types.Object),
+		Implicits: make(map[ast.Node]types.Object),
+		Scopes:    make(map[ast.Node]*types.Scope),
+	}
+	conf := types.Config{
+		Importer: tc.imp,
+		Error:    func(error) {}, // collect the types as much as possible
+	}
+	pkg, _ := conf.Check(f.Name.Name, tc.fset, files, info)
+
+	return &refactor.File{Fset: tc.fset, File: f, Pkg: pkg, Info: info, Src: src}, true
+}
+
+// reset renews the FileSet and the importer if not initialized or the FileSet grows too large.
+func (tc *typeChecker) reset() {
+	if tc.fset == nil || tc.fset.Base() > typeCheckMaxBase {
+		tc.fset = token.NewFileSet()
+		tc.imp = importer.ForCompiler(tc.fset, "source", nil)
+	}
+}
+
+// packageSiblings parses the other files of the package pkgName in the directory of file.
+func packageSiblings(fset *token.FileSet, file, pkgName string) []*ast.File {
+	dir := filepath.Dir(file)
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var files []*ast.File
+	for _, fi := range fis {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, ".go") || filepath.Join(dir, name) == file {
+			continue
+		}
+		if strings.HasSuffix(name, "_test.go") && !strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		f, _ := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if f != nil && f.Name.Name == pkgName {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// bufferOffset returns the byte offset of the 1-based line and column of the buffer lines,
+// such as the result of line('.') and col('.').
+func bufferOffset(lines [][]byte, line, col int) int {
+	offset := col - 1
+	for i := 0; i < line-1 && i < len(lines); i++ {
+		offset += len(lines[i]) + 1
+	}
+	return offset
+}
@@ -39,7 +39,7 @@ func (c *Command) cmdVet(ctx context.Context, args []string, eval *CmdVetEval) {
 	case err := <-errch:
 		switch e := err.(type) {
 		case error:
-			nvimutil.ErrorWrap(c.Nvim, e)
+			nvimutil.ErrorWrap(ctx, c.Nvim, e)
 		case []*nvim.QuickfixError:
 			c.errs.Store("Vet", e)
 			errlist := make(map[string][]*nvim.QuickfixError)
@@ -48,7 +48,7 @@ func (c *Command) cmdVet(ctx context.Context, args []string, eval *CmdVetEval) {
 				errlist[k] = append(errlist[k], v...)
 				return true
 			})
-			nvimutil.ErrorList(c.Nvim, errlist, true)
+			nvimutil.ErrorList(ctx, c.Nvim, errlist, true)
 		case nil:
 			// nothing to do
 		}
@@ -60,8 +60,11 @@ func (c *Command) Vet(pctx context.Context, args []string, eval *CmdVetEval) int
 	ctx, span := monitoring.StartSpan(pctx, "Vet")
 	defer span.End()
 
+	cfg := config.FromContext(ctx)
+
 	vetCmd := exec.CommandContext(ctx, "go", "tool", "vet")
 	vetCmd.Dir = eval.Cwd
+	vetCmd.Env = c.buildContext.Environ()
 
 	switch {
 	case len(args) > 0:
@@ -87,8 +90,8 @@ func (c *Command) Vet(pctx context.Context, args []string, eval *CmdVetEval) int
 			vetCmd.Args = append(vetCmd.Args, args...)
 			vetCmd.Args = append(vetCmd.Args, ".")
 		}
-	case len(config.GoVetFlags) > 0:
-		vetCmd.Args = append(vetCmd.Args, config.GoVetFlags...)
+	case len(cfg.Lint.GoVetFlags) > 0:
+		vetCmd.Args = append(vetCmd.Args, cfg.Lint.GoVetFlags...)
 		vetCmd.Args = append(vetCmd.Args, ".")
 	default:
 		vetCmd.Args = append(vetCmd.Args, ".")
@@ -99,7 +102,7 @@ func (c *Command) Vet(pctx context.Context, args []string, eval *CmdVetEval) int
 
 	vetErr := vetCmd.Run()
 	if vetErr != nil {
-		errlist, err := nvimutil.ParseError(ctx, stderr.Bytes(), eval.Cwd, &c.buildContext.Build, config.GoVetIgnore)
+		errlist, err := nvimutil.ParseError(ctx, stderr.Bytes(), eval.Cwd, &c.buildContext.Build, cfg.Lint.GoVetIgnore)
 		if err != nil {
 			span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
 			return errors.WithStack(err)
@@ -136,7 +136,7 @@ func TestCommand_Vet(t *testing.T) {
 
 			ctx := tt.fields.ctx
 			tt.fields.bctxt.Build.Tool = tt.tool
-			c := NewCommand(ctx, tt.fields.Nvim, tt.fields.bctxt)
+			c := NewCommand(ctx, tt.fields.Nvim, tt.fields.bctxt, NewCache())
 			if got := c.Vet(ctx, tt.args.args, tt.args.eval); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Command.Vet(%v, %v) = %v, want %v", tt.args.args, tt.args.eval, got, tt.want)
 			}
@@ -5,6 +5,9 @@
 package config
 
 import (
+	"context"
+	"sync/atomic"
+
 	"github.com/neovim/go-client/nvim"
 )
 
@@ -12,295 +15,198 @@ import (
 // Each type must be exported for plugin.HandleAutocmd Eval option.
 // Also it does not support embeded type.
 type Config struct {
-	Global *Global
-
-	Build    *build
-	Cover    *cover
-	Fmt      *fmt
-	Generate *generate
-	Guru     *guru
-	Iferr    *iferr
-	Lint     *lint
-	Rename   *rename
-	Terminal *terminal
-	Test     *test
-
-	Debug *debug
+	Global *Global `toml:"global"`
+
+	Build      *build      `toml:"build"`
+	Cover      *cover      `toml:"cover"`
+	Fmt        *fmt        `toml:"fmt"`
+	FillStruct *fillStruct `toml:"fillstruct"`
+	Generate   *generate   `toml:"generate"`
+	Guru       *guru       `toml:"guru"`
+	Highlight  *highlight  `toml:"highlight"`
+	Iferr      *iferr      `toml:"iferr"`
+	Lint       *lint       `toml:"lint"`
+	Rename     *rename     `toml:"rename"`
+	Signature  *signature  `toml:"signature"`
+	Symbols    *symbols    `toml:"symbols"`
+	Terminal   *terminal   `toml:"terminal"`
+	Test       *test       `toml:"test"`
+
+	Debug *debug `toml:"debug"`
 }
 
 // Global represents a global config variable.
 type Global struct {
-	ChannelID     int
-	ServerName    string `eval:"v:servername"`
-	ErrorListType string `eval:"get(g:, 'go#global#errorlisttype', 'locationlist')"`
+	ChannelID     int    `toml:"-"`
+	ServerName    string `eval:"v:servername" toml:"-"`
+	ErrorListType string `eval:"get(g:, 'go#global#errorlisttype', 'locationlist')" toml:"errorlisttype"`
 }
 
 // build GoBuild command config variable.
 type build struct {
-	Appengine bool     `eval:"get(g:, 'go#build#appengine', v:false)"`
-	Autosave  bool     `eval:"get(g:, 'go#build#autosave', v:false)"`
-	Force     bool     `eval:"get(g:, 'go#build#force', v:false)"`
-	Flags     []string `eval:"get(g:, 'go#build#flags', [])"`
-	IsNotGb   bool     `eval:"get(g:, 'go#build#is_not_gb', v:false)"`
+	Appengine bool     `eval:"get(g:, 'go#build#appengine', v:false)" toml:"appengine"`
+	Autosave  bool     `eval:"get(g:, 'go#build#autosave', v:false)" toml:"autosave"`
+	Force     bool     `eval:"get(g:, 'go#build#force', v:false)" toml:"force"`
+	Flags     []string `eval:"get(g:, 'go#build#flags', [])" toml:"flags"`
+	IsNotGb   bool     `eval:"get(g:, 'go#build#is_not_gb', v:false)" toml:"is_not_gb"`
 }
 
 type cover struct {
-	Flags []string `eval:"get(g:, 'go#cover#flags', [])"`
-	Mode  string   `eval:"get(g:, 'go#cover#mode', 'atomic')"`
+	Flags []string `eval:"get(g:, 'go#cover#flags', [])" toml:"flags"`
+	Mode  string   `eval:"get(g:, 'go#cover#mode', 'atomic')" toml:"mode"`
 }
 
 // fmt represents a GoFmt command config variable.
 type fmt struct {
-	Autosave       bool     `eval:"get(g:, 'go#fmt#autosave', v:false)"`
-	Mode           string   `eval:"get(g:, 'go#fmt#mode', 'goimports')"`
-	GoImportsLocal []string `eval:"get(g:, 'go#fmt#goimports_local', [])"`
+	Autosave       bool     `eval:"get(g:, 'go#fmt#autosave', v:false)" toml:"autosave"`
+	Mode           string   `eval:"get(g:, 'go#fmt#mode', 'goimports')" toml:"mode"`
+	GoImportsLocal []string `eval:"get(g:, 'go#fmt#goimports_local', [])" toml:"goimports_local"`
+}
+
+// fillStruct represents a GoFillStruct command config variable.
+type fillStruct struct {
+	Recursive bool `eval:"get(g:, 'go#fillstruct#recursive', v:false)" toml:"recursive"`
 }
 
 // generate represents a GoGenerate command config variables.
 type generate struct {
-	TestAllFuncs       bool   `eval:"get(g:, 'go#generate#test#allfuncs', v:true)"`
-	TestExclFuncs      string `eval:"get(g:, 'go#generate#test#exclude', '')"`
-	TestExportedFuncs  bool   `eval:"get(g:, 'go#generate#test#exportedfuncs', v:false)"`
-	TestSubTest        bool   `eval:"get(g:, 'go#generate#test#subtest', v:true)"`
-	TestParallel       bool   `eval:"get(g:, 'go#generate#test#parallel', v:true)"`
-	TestTemplateDir    string `eval:"get(g:, 'go#generate#test#template_dir', '')"`
-	TemplateParamsPath string `eval:"get(g:, 'go#generate#test#template_params_path', '')"`
+	TestAllFuncs       bool   `eval:"get(g:, 'go#generate#test#allfuncs', v:true)" toml:"test_allfuncs"`
+	TestExclFuncs      string `eval:"get(g:, 'go#generate#test#exclude', '')" toml:"test_exclude"`
+	TestExportedFuncs  bool   `eval:"get(g:, 'go#generate#test#exportedfuncs', v:false)" toml:"test_exportedfuncs"`
+	TestSubTest        bool   `eval:"get(g:, 'go#generate#test#subtest', v:true)" toml:"test_subtest"`
+	TestParallel       bool   `eval:"get(g:, 'go#generate#test#parallel', v:true)" toml:"test_parallel"`
+	TestTemplateDir    string `eval:"get(g:, 'go#generate#test#template_dir', '')" toml:"test_template_dir"`
+	TemplateParamsPath string `eval:"get(g:, 'go#generate#test#template_params_path', '')" toml:"test_template_params_path"`
 }
 
 // guru represents a GoGuru command config variable.
 type guru struct {
-	Reflection bool            `eval:"get(g:, 'go#guru#reflection', v:false)"`
-	KeepCursor map[string]bool `eval:"get(g:, 'go#guru#keep_cursor', {'callees':v:false,'callers':v:false,'callstack':v:false,'definition':v:false,'describe':v:false,'freevars':v:false,'implements':v:false,'peers':v:false,'pointsto':v:false,'referrers':v:false,'whicherrs':v:false})"`
-	JumpFirst  bool            `eval:"get(g:, 'go#guru#jump_first', v:false)"`
+	Reflection bool            `eval:"get(g:, 'go#guru#reflection', v:false)" toml:"reflection"`
+	KeepCursor map[string]bool `eval:"get(g:, 'go#guru#keep_cursor', {'callees':v:false,'callers':v:false,'callstack':v:false,'definition':v:false,'describe':v:false,'freevars':v:false,'implements':v:false,'peers':v:false,'pointsto':v:false,'referrers':v:false,'whicherrs':v:false})" toml:"keep_cursor"`
+	JumpFirst  bool            `eval:"get(g:, 'go#guru#jump_first', v:false)" toml:"jump_first"`
+}
+
+// highlight represents a semantic highlighting config variable.
+type highlight struct {
+	Semantic bool `eval:"get(g:, 'go#highlight#semantic', v:false)" toml:"semantic"`
 }
 
 // iferr represents a GoIferr command config variable.
 type iferr struct {
-	Autosave bool `eval:"get(g:, 'go#iferr#autosave', v:false)"`
+	Autosave bool   `eval:"get(g:, 'go#iferr#autosave', v:false)" toml:"autosave"`
+	Template string `eval:"get(g:, 'go#iferr#template', '')" toml:"template"`
 }
 
 // lint represents a code lint commands config variable.
 type lint struct {
-	GolintAutosave          bool     `eval:"get(g:, 'go#lint#golint#autosave', v:false)"`
-	GolintIgnore            []string `eval:"get(g:, 'go#lint#golint#ignore', [])"`
-	GolintMinConfidence     float64  `eval:"get(g:, 'go#lint#golint#min_confidence', 0.8)"`
-	GolintMode              string   `eval:"get(g:, 'go#lint#golint#mode', 'current')"`
-	GoVetAutosave           bool     `eval:"get(g:, 'go#lint#govet#autosave', v:false)"`
-	GoVetFlags              []string `eval:"get(g:, 'go#lint#govet#flags', [])"`
-	GoVetIgnore             []string `eval:"get(g:, 'go#lint#govet#ignore', [])"`
-	MetalinterAutosave      bool     `eval:"get(g:, 'go#lint#metalinter#autosave', v:false)"`
-	MetalinterAutosaveTools []string `eval:"get(g:, 'go#lint#metalinter#autosave#tools', ['vet', 'golint'])"`
-	MetalinterTools         []string `eval:"get(g:, 'go#lint#metalinter#tools', ['vet', 'golint'])"`
-	MetalinterDeadline      string   `eval:"get(g:, 'go#lint#metalinter#deadline', '5s')"`
-	MetalinterSkipDir       []string `eval:"get(g:, 'go#lint#metalinter#skip_dir', [])"`
+	GolintAutosave          bool     `eval:"get(g:, 'go#lint#golint#autosave', v:false)" toml:"golint_autosave"`
+	GolintIgnore            []string `eval:"get(g:, 'go#lint#golint#ignore', [])" toml:"golint_ignore"`
+	GolintMinConfidence     float64  `eval:"get(g:, 'go#lint#golint#min_confidence', 0.8)" toml:"golint_min_confidence"`
+	GolintMode              string   `eval:"get(g:, 'go#lint#golint#mode', 'current')" toml:"golint_mode"`
+	GoVetAutosave           bool     `eval:"get(g:, 'go#lint#govet#autosave', v:false)" toml:"govet_autosave"`
+	GoVetFlags              []string `eval:"get(g:, 'go#lint#govet#flags', [])" toml:"govet_flags"`
+	GoVetIgnore             []string `eval:"get(g:, 'go#lint#govet#ignore', [])" toml:"govet_ignore"`
+	MetalinterAutosave      bool     `eval:"get(g:, 'go#lint#metalinter#autosave', v:false)" toml:"metalinter_autosave"`
+	MetalinterAutosaveTools []string `eval:"get(g:, 'go#lint#metalinter#autosave#tools', ['vet', 'golint'])" toml:"metalinter_autosave_tools"`
+	MetalinterTools         []string `eval:"get(g:, 'go#lint#metalinter#tools', ['vet', 'golint'])" toml:"metalinter_tools"`
+	MetalinterDeadline      string   `eval:"get(g:, 'go#lint#metalinter#deadline', '5s')" toml:"metalinter_deadline"`
+	MetalinterSkipDir       []string `eval:"get(g:, 'go#lint#metalinter#skip_dir', [])" toml:"metalinter_skip_dir"`
 }
 
 // rename represents a GoRename command config variable.
 type rename struct {
-	Prefill bool `eval:"get(g:, 'go#rename#prefill', v:false)"`
+	Prefill bool `eval:"get(g:, 'go#rename#prefill', v:false)" toml:"prefill"`
+	Preview bool `eval:"get(g:, 'go#rename#preview', v:false)" toml:"preview"`
+}
+
+// signature represents the signature help config variable.
+type signature struct {
+	Enable bool `eval:"get(g:, 'go#signature#enable', v:true)" toml:"enable"`
+}
+
+// symbols represents a GoSymbols command config variable.
+type symbols struct {
+	Deps bool   `eval:"get(g:, 'go#symbols#deps', v:false)" toml:"deps"`
+	List string `eval:"get(g:, 'go#symbols#list', 'quickfix')" toml:"list"`
 }
 
 // terminal represents a configure of Neovim terminal buffer.
 type terminal struct {
-	Mode       string `eval:"get(g:, 'go#terminal#mode', 'vsplit')"`
-	Position   string `eval:"get(g:, 'go#terminal#position', 'belowright')"`
-	Height     int64  `eval:"get(g:, 'go#terminal#height', 0)"`
-	Width      int64  `eval:"get(g:, 'go#terminal#width', 0)"`
-	StopInsert bool   `eval:"get(g:, 'go#terminal#stop_insert', v:true)"`
+	Mode       string `eval:"get(g:, 'go#terminal#mode', 'vsplit')" toml:"mode"`
+	Position   string `eval:"get(g:, 'go#terminal#position', 'belowright')" toml:"position"`
+	Height     int64  `eval:"get(g:, 'go#terminal#height', 0)" toml:"height"`
+	Width      int64  `eval:"get(g:, 'go#terminal#width', 0)" toml:"width"`
+	StopInsert bool   `eval:"get(g:, 'go#terminal#stop_insert', v:true)" toml:"stop_insert"`
 }
 
 // Test represents a GoTest command config variables.
 type test struct {
-	AllPackage bool     `eval:"get(g:, 'go#test#all_package', v:false)"`
-	Autosave   bool     `eval:"get(g:, 'go#test#autosave', v:false)"`
-	Flags      []string `eval:"get(g:, 'go#test#flags', [])"`
+	AllPackage bool     `eval:"get(g:, 'go#test#all_package', v:false)" toml:"all_package"`
+	Autosave   bool     `eval:"get(g:, 'go#test#autosave', v:false)" toml:"autosave"`
+	Flags      []string `eval:"get(g:, 'go#test#flags', [])" toml:"flags"`
 }
 
 // Debug represents a debug of nvim-go config variable.
 type debug struct {
-	Enable bool `eval:"get(g:, 'go#debug', v:false)"`
-	Pprof  bool `eval:"get(g:, 'go#debug#pprof', v:false)"`
+	Enable bool `eval:"get(g:, 'go#debug', v:false)" toml:"enable"`
+	Pprof  bool `eval:"get(g:, 'go#debug#pprof', v:false)" toml:"pprof"`
 }
 
-var (
-	// ChannelID remote plugins channel id.
-	ChannelID int
-	// ServerName Neovim socket listen location.
-	ServerName string
-	// ErrorListType type of error list window.
-	ErrorListType string
-
-	// BuildAppengine enable appengine bulid.
-	BuildAppengine bool
-	// BuildAutosave call the GoBuild command automatically at during the BufWritePost.
-	BuildAutosave bool
-	// BuildForce builds the binary instead of fake(use ioutil.TempFiile) build.
-	BuildForce bool
-	// BuildFlags flag of compile tools build command.
-	BuildFlags []string
-
-	// BuildIsNotGb workaround for not ues gb compiler.
-	BuildIsNotGb bool
-
-	// CoverFlags flags for cover command.
-	CoverFlags []string
-	// CoverMode mode of cover command.
-	CoverMode string
-
-	// FmtAutosave call the GoFmt command automatically at during the BufWritePre.
-	FmtAutosave bool
-	// FmtMode formatting mode of Fmt command.
-	FmtMode string
-	// FmtGoImportsLocal list packages of goimports -local flag.
-	FmtGoImportsLocal []string
-
-	// GenerateTestAllFuncs accept all functions to the GenerateTest.
-	GenerateTestAllFuncs bool
-	// GenerateTestExclFuncs exclude function of GenerateTest.
-	GenerateTestExclFuncs string
-	// GenerateTestExportedFuncs accept exported functions to the GenerateTest.
-	GenerateTestExportedFuncs bool
-	// GenerateTestSubTest whether the use Go subtest idiom or not.
-	GenerateTestSubTest bool
-	// GenerateTestParallel print tests that runs the subtests in parallel.
-	GenerateTestParallel bool
-	// GenerateTestTemplateDir path to custom template set.
-	GenerateTestTemplateDir string
-	// GenerateTestTemplateParamsPath path to custom paramters json file(s).
-	GenerateTestTemplateParamsPath string
-
-	// GuruReflection use the type reflection on GoGuru commmands.
-	GuruReflection bool
-	// GuruKeepCursor keep the cursor focus to source buffer instead of quickfix or locationlist.
-	GuruKeepCursor map[string]bool
-	// GuruJumpFirst jump the first error position on GoGuru commands.
-	GuruJumpFirst bool
-
-	// IferrAutosave call the GoIferr command automatically at during the BufWritePre.
-	IferrAutosave bool
-
-	// GolintAutosave call the GoLint command automatically at during the BufWritePost.
-	GolintAutosave bool
-	// GolintIgnore ignore file for lint command.
-	GolintIgnore []string
-	// GolintMinConfidence minimum confidence of a problem to print it
-	GolintMinConfidence float64
-	// GolintMode mode of golint. available value are "root", "current" and "recursive".
-	GolintMode string
-	// GoVetAutosave call the GoVet command automatically at during the BufWritePost.
-	GoVetAutosave bool
-	// GoVetFlags default flags for GoVet commands
-	GoVetFlags []string
-	// GoVetIgnore ignore directories for go vet command.
-	GoVetIgnore []string
-	// MetalinterAutosave call the GoMetaLinter command automatically at during the BufWritePre.
-	MetalinterAutosave bool
-	// MetalinterAutosaveTools lint tool list for MetalinterAutosave.
-	MetalinterAutosaveTools []string
-	// MetalinterTools lint tool list for GoMetaLinter command.
-	MetalinterTools []string
-	// MetalinterDeadline deadline of GoMetaLinter command timeout.
-	MetalinterDeadline string
-	// MetalinterSkipDir skips of lint of the directory.
-	MetalinterSkipDir []string
-
-	// RenamePrefill Enable naming prefill.
-	RenamePrefill bool
-
-	// TerminalMode open the terminal window mode.
-	TerminalMode string
-	// TerminalPosition open the terminal window position.
-	TerminalPosition string
-	// TerminalHeight open the terminal window height.
-	TerminalHeight int64
-	// TerminalWidth open the terminal window width.
-	TerminalWidth int64
-	// TerminalStopInsert workaround if users set "autocmd BufEnter term://* startinsert".
-	TerminalStopInsert bool
-
-	// TestAutosave call the GoBuild command automatically at during the BufWritePost.
-	TestAutosave bool
-	// TestAll enable all package test on GoTest. similar "go test ./...", but ignored vendor and testdata.
-	TestAll bool
-	// TestFlags test command default flags.
-	TestFlags []string
-
-	// DebugEnable Enable debugging.
-	DebugEnable bool
-	// DebugPprof Enable net/http/pprof debugging.
-	DebugPprof bool
-)
+// Store holds the current config snapshot of a Neovim client, which is swapped atomically by Reload.
+type Store struct {
+	current atomic.Value
+	// base the config of g: variables which is not overridden by the ProjectFile.
+	base atomic.Value
+}
+
+// NewStore returns the new Store.
+func NewStore() *Store {
+	return new(Store)
+}
+
+// Current returns the current config snapshot of s.
+// The returned Config must not be modified. Current returns the Default config if the config has not been loaded yet.
+func (s *Store) Current() *Config {
+	if cfg, ok := s.current.Load().(*Config); ok {
+		return cfg
+	}
+	return Default()
+}
+
+// Reload resolves the effective config of dir from the g: variables gcfg and the ProjectFile,
+// and swaps the current config of s to it.
+// Reload swaps the current config to gcfg even if failed to load the ProjectFile.
+func (s *Store) Reload(v *nvim.Nvim, gcfg *Config, dir string) (*Config, error) {
+	gcfg.Global.ChannelID = v.ChannelID()
+
+	cfg, err := Resolve(gcfg, dir)
+	s.base.Store(gcfg)
+	s.current.Store(cfg)
+
+	return cfg, err
+}
+
+// Resolve returns the effective config of dir from the g: variables of the last Reload and the ProjectFile.
+// Unlike Reload, Resolve does not swap the current config of s.
+func (s *Store) Resolve(dir string) (*Config, error) {
+	gcfg, ok := s.base.Load().(*Config)
+	if !ok {
+		gcfg = Default()
+	}
+	return Resolve(gcfg, dir)
+}
+
+// defaultStore is the Store which used if the context has no Store.
+var defaultStore = NewStore()
+
+// Current returns the current config snapshot of the default Store.
+// The returned Config must not be modified.
+func Current() *Config {
+	return defaultStore.Current()
+}
 
-// Get gets the user config variables and convert to global varialble.
-func Get(v *nvim.Nvim, cfg *Config) {
-	// Client
-	ChannelID = cfg.Global.ChannelID
-	ServerName = cfg.Global.ServerName
-	ErrorListType = cfg.Global.ErrorListType
-
-	// Build
-	BuildAppengine = cfg.Build.Appengine
-	BuildAutosave = cfg.Build.Autosave
-	BuildForce = cfg.Build.Force
-	BuildFlags = cfg.Build.Flags
-	BuildIsNotGb = cfg.Build.IsNotGb
-
-	// Cover
-	CoverFlags = cfg.Cover.Flags
-	CoverMode = cfg.Cover.Mode
-
-	// Fmt
-	FmtAutosave = cfg.Fmt.Autosave
-	FmtMode = cfg.Fmt.Mode
-	FmtGoImportsLocal = cfg.Fmt.GoImportsLocal
-
-	// Generate
-	GenerateTestAllFuncs = cfg.Generate.TestAllFuncs
-	GenerateTestExclFuncs = cfg.Generate.TestExclFuncs
-	GenerateTestExportedFuncs = cfg.Generate.TestExportedFuncs
-	GenerateTestSubTest = cfg.Generate.TestSubTest
-	GenerateTestParallel = cfg.Generate.TestSubTest
-	GenerateTestTemplateDir = cfg.Generate.TestTemplateDir
-	GenerateTestTemplateParamsPath = cfg.Generate.TemplateParamsPath
-
-	// Guru
-	GuruReflection = cfg.Guru.Reflection
-	GuruKeepCursor = cfg.Guru.KeepCursor
-	GuruJumpFirst = cfg.Guru.JumpFirst
-
-	// Iferr
-	IferrAutosave = cfg.Iferr.Autosave
-
-	// Lint
-	GolintAutosave = cfg.Lint.GolintAutosave
-	GolintIgnore = cfg.Lint.GolintIgnore
-	GolintMinConfidence = cfg.Lint.GolintMinConfidence
-	GolintMode = cfg.Lint.GolintMode
-	GoVetAutosave = cfg.Lint.GoVetAutosave
-	GoVetFlags = cfg.Lint.GoVetFlags
-	GoVetIgnore = cfg.Lint.GoVetIgnore
-	MetalinterAutosave = cfg.Lint.MetalinterAutosave
-	MetalinterAutosaveTools = cfg.Lint.MetalinterAutosaveTools
-	MetalinterTools = cfg.Lint.MetalinterTools
-	MetalinterDeadline = cfg.Lint.MetalinterDeadline
-	MetalinterSkipDir = cfg.Lint.MetalinterSkipDir
-
-	// Rename
-	RenamePrefill = cfg.Rename.Prefill
-
-	// Terminal
-	TerminalMode = cfg.Terminal.Mode
-	TerminalPosition = cfg.Terminal.Position
-	TerminalHeight = cfg.Terminal.Height
-	TerminalWidth = cfg.Terminal.Width
-	TerminalStopInsert = cfg.Terminal.StopInsert
-
-	// Test
-	TestAutosave = cfg.Test.Autosave
-	TestAll = cfg.Test.AllPackage
-	TestFlags = cfg.Test.Flags
-
-	// Debug
-	DebugEnable = cfg.Debug.Enable
-	DebugPprof = cfg.Debug.Pprof
+// Reload reloads the config of the Store which associated with ctx, and returns the reloaded config.
+// See Store.Reload.
+func Reload(ctx context.Context, v *nvim.Nvim, gcfg *Config, dir string) (*Config, error) {
+	return StoreFromContext(ctx).Reload(v, gcfg, dir)
 }
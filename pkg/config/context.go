@@ -0,0 +1,57 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"path/filepath"
+)
+
+type contextKey struct{}
+
+type storeContextKey struct{}
+
+// NewContext returns a copy of parent context in which the config snapshot cfg is associated.
+func NewContext(ctx context.Context, cfg *Config) context.Context {
+	return context.WithValue(ctx, contextKey{}, cfg)
+}
+
+// FromContext returns the config snapshot associated with ctx.
+// FromContext returns the current config of the Store associated with ctx if ctx has no config.
+func FromContext(ctx context.Context) *Config {
+	cfg, ok := ctx.Value(contextKey{}).(*Config)
+	if !ok || cfg == nil {
+		return StoreFromContext(ctx).Current()
+	}
+	return cfg
+}
+
+// Snapshot returns a copy of parent context in which the current config is associated.
+// Each request handler should take the snapshot at the start, so that the config is not changed while handling the request.
+func Snapshot(ctx context.Context) context.Context {
+	return NewContext(ctx, FromContext(ctx))
+}
+
+// NewStoreContext returns a copy of parent context in which the Store s is associated.
+func NewStoreContext(ctx context.Context, s *Store) context.Context {
+	return context.WithValue(ctx, storeContextKey{}, s)
+}
+
+// StoreFromContext returns the Store associated with ctx, or the default Store if ctx has no Store.
+func StoreFromContext(ctx context.Context) *Store {
+	s, ok := ctx.Value(storeContextKey{}).(*Store)
+	if !ok || s == nil {
+		return defaultStore
+	}
+	return s
+}
+
+// SnapshotFile returns a copy of parent context in which the effective config of file is associated.
+// Unlike Snapshot, SnapshotFile resolves the config from the ProjectFile of file instead of the last entered buffer,
+// and returns the context with the g: variables config if failed to load the ProjectFile.
+func SnapshotFile(ctx context.Context, file string) (context.Context, error) {
+	cfg, err := StoreFromContext(ctx).Resolve(filepath.Dir(file))
+	return NewContext(ctx, cfg), err
+}
@@ -0,0 +1,98 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	defaultConfig     *Config
+	defaultConfigOnce sync.Once
+)
+
+// Default returns the default config which is parsed from the default value of each eval tags.
+// The returned Config must not be modified.
+func Default() *Config {
+	defaultConfigOnce.Do(func() {
+		cfg := new(Config)
+
+		v := reflect.ValueOf(cfg).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			section := reflect.New(v.Type().Field(i).Type.Elem())
+			for j := 0; j < section.Elem().NumField(); j++ {
+				_, def, ok := parseEvalTag(section.Elem().Type().Field(j).Tag.Get("eval"))
+				if !ok {
+					continue
+				}
+				if err := json.Unmarshal([]byte(vimToJSON(def)), section.Elem().Field(j).Addr().Interface()); err != nil {
+					panic(errors.Wrapf(err, "invalid default value of %s.%s", v.Type().Field(i).Name, section.Elem().Type().Field(j).Name))
+				}
+			}
+			v.Field(i).Set(section)
+		}
+
+		defaultConfig = cfg
+	})
+
+	return defaultConfig
+}
+
+// parseEvalTag parses the "get(g:, '<name>', <default>)" form eval tag and returns the g: variable name and default value.
+func parseEvalTag(tag string) (name, def string, ok bool) {
+	const prefix = "get(g:, '"
+	if !strings.HasPrefix(tag, prefix) || !strings.HasSuffix(tag, ")") {
+		return "", "", false
+	}
+	tag = strings.TrimSuffix(strings.TrimPrefix(tag, prefix), ")")
+
+	idx := strings.Index(tag, "', ")
+	if idx < 0 {
+		return "", "", false
+	}
+
+	return tag[:idx], tag[idx+len("', "):], true
+}
+
+// vimToJSON converts the Vim script literal expr to JSON.
+// vimToJSON only supports the literals which used in eval tags, that is number, v:true, v:false, single quoted string, list and dictionary.
+func vimToJSON(expr string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case c == '\'':
+			var s strings.Builder
+			for i++; i < len(expr); i++ {
+				if expr[i] == '\'' {
+					if i+1 < len(expr) && expr[i+1] == '\'' { // '' is the escaped single quote
+						s.WriteByte('\'')
+						i++
+						continue
+					}
+					break
+				}
+				s.WriteByte(expr[i])
+			}
+			b, _ := json.Marshal(s.String())
+			sb.Write(b)
+		case strings.HasPrefix(expr[i:], "v:true"):
+			sb.WriteString("true")
+			i += len("v:true") - 1
+		case strings.HasPrefix(expr[i:], "v:false"):
+			sb.WriteString("false")
+			i += len("v:false") - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String()
+}
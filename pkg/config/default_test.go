@@ -0,0 +1,70 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDefault(t *testing.T) {
+	cfg := Default()
+
+	if got, want := cfg.Global.ErrorListType, "locationlist"; got != want {
+		t.Errorf("Global.ErrorListType = %q, want %q", got, want)
+	}
+	if got, want := cfg.Generate.TestAllFuncs, true; got != want {
+		t.Errorf("Generate.TestAllFuncs = %v, want %v", got, want)
+	}
+	if got, want := cfg.Lint.GolintMinConfidence, 0.8; got != want {
+		t.Errorf("Lint.GolintMinConfidence = %v, want %v", got, want)
+	}
+	if diff := cmp.Diff([]string{"vet", "golint"}, cfg.Lint.MetalinterTools); diff != "" {
+		t.Errorf("Lint.MetalinterTools: (-want +got)\n%s", diff)
+	}
+	if cfg.Guru.KeepCursor == nil || cfg.Guru.KeepCursor["callers"] {
+		t.Errorf("Guru.KeepCursor = %v", cfg.Guru.KeepCursor)
+	}
+	if cfg.Build.Flags == nil || len(cfg.Build.Flags) != 0 {
+		t.Errorf("Build.Flags = %#v, want empty slice", cfg.Build.Flags)
+	}
+}
+
+func TestVimToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{name: "number", expr: "0.8", want: "0.8"},
+		{name: "bool", expr: "v:false", want: "false"},
+		{name: "string", expr: "'goimports'", want: `"goimports"`},
+		{name: "escaped quote", expr: "'it''s'", want: `"it's"`},
+		{name: "list", expr: "['vet', 'golint']", want: `["vet", "golint"]`},
+		{name: "dict", expr: "{'callers':v:true,'peers':v:false}", want: `{"callers":true,"peers":false}`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := vimToJSON(tt.expr); got != tt.want {
+				t.Errorf("vimToJSON(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != Current() {
+		t.Errorf("FromContext(context.Background()) = %#v, want Current()", got)
+	}
+
+	cfg := testConfig()
+	if got := FromContext(NewContext(context.Background(), cfg)); got != cfg {
+		t.Errorf("FromContext(NewContext(ctx, cfg)) = %#v, want %#v", got, cfg)
+	}
+}
@@ -2,5 +2,5 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// Package config implements a parse of init.vim configs and user environment variables.
+// Package config implements a parse of init.vim configs, per-project config file and user environment variables.
 package config
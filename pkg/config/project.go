@@ -0,0 +1,224 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+
+	"github.com/zchee/nvim-go/pkg/fs"
+)
+
+// ProjectFile is the file name of per-project config file.
+//
+// The config file placed at the VCS or module root directory overrides the g:go#... variables.
+// Each table and key corresponds to the g: variable name, like:
+//
+//  [fmt]
+//  mode = "goimports"        # g:go#fmt#mode
+//  goimports_local = ["github.com/zchee"]
+//
+//  [lint]
+//  golint_autosave = true    # g:go#lint#golint#autosave
+const ProjectFile = ".nvim-go.toml"
+
+// rootMarkers list of the file or directory names which marks the project root directory.
+var rootMarkers = []string{"go.mod", ".git", ".hg", ".svn", "_darcs"}
+
+// Project represents a per-project config file.
+type Project struct {
+	// Path full path of the project config file.
+	Path string
+	// Keys list of defined keys in the project config file, like "fmt.mode".
+	Keys []string
+
+	cfg     *Config
+	md      toml.MetaData
+	modTime time.Time
+}
+
+// projects cache of loaded Project keyed by the config file path.
+var projects sync.Map
+
+// FindProjectFile finds the ProjectFile from dir to the project root directory.
+func FindProjectFile(dir string) (string, bool) {
+	if !fs.IsDir(dir) {
+		dir = filepath.Dir(dir)
+	}
+	dir = filepath.Clean(dir)
+
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			return path, true
+		}
+
+		if isProjectRoot(dir) {
+			return "", false
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// isProjectRoot reports whether the dir contains any rootMarkers.
+func isProjectRoot(dir string) bool {
+	for _, marker := range rootMarkers {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadProject loads the project config file.
+// LoadProject returns the cached Project if the file is not modified since the last load.
+func LoadProject(path string) (*Project, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if v, ok := projects.Load(path); ok {
+		if p := v.(*Project); p.modTime.Equal(fi.ModTime()) {
+			return p, nil
+		}
+	}
+
+	cfg := new(Config)
+	md, err := toml.DecodeFile(path, cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s", path)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return nil, errors.Errorf("unknown keys in %s: %s", path, strings.Join(keys, ", "))
+	}
+
+	p := &Project{
+		Path:    path,
+		cfg:     cfg,
+		md:      md,
+		modTime: fi.ModTime(),
+	}
+	for _, key := range md.Keys() {
+		if len(key) == 2 { // ignore table keys
+			p.Keys = append(p.Keys, key.String())
+		}
+	}
+	sort.Strings(p.Keys)
+	projects.Store(path, p)
+
+	return p, nil
+}
+
+// Apply returns the copy of cfg which is overridden by the project config values.
+func (p *Project) Apply(cfg *Config) *Config {
+	dst := cfg.Clone()
+
+	sv, dv := reflect.ValueOf(p.cfg).Elem(), reflect.ValueOf(dst).Elem()
+	for i := 0; i < sv.NumField(); i++ {
+		section := sv.Field(i)
+		if section.IsNil() {
+			continue
+		}
+		table := tomlName(sv.Type().Field(i))
+		if dv.Field(i).IsNil() {
+			dv.Field(i).Set(reflect.New(section.Type().Elem()))
+		}
+
+		src, dst := section.Elem(), dv.Field(i).Elem()
+		for j := 0; j < src.NumField(); j++ {
+			if key := tomlName(src.Type().Field(j)); key != "-" && p.md.IsDefined(table, key) {
+				dst.Field(j).Set(src.Field(j))
+			}
+		}
+	}
+
+	return dst
+}
+
+// Resolve returns the effective config of dir which is cfg overridden by the ProjectFile.
+// Resolve returns cfg itself if the ProjectFile is not found.
+func Resolve(cfg *Config, dir string) (*Config, error) {
+	path, ok := FindProjectFile(dir)
+	if !ok {
+		return cfg, nil
+	}
+
+	p, err := LoadProject(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	return p.Apply(cfg), nil
+}
+
+// Clone returns a deep copy of cfg.
+func (cfg *Config) Clone() *Config {
+	dst := new(Config)
+
+	sv, dv := reflect.ValueOf(cfg).Elem(), reflect.ValueOf(dst).Elem()
+	for i := 0; i < sv.NumField(); i++ {
+		section := sv.Field(i)
+		if section.IsNil() {
+			continue
+		}
+		cp := reflect.New(section.Type().Elem())
+		cp.Elem().Set(section.Elem())
+		for j := 0; j < cp.Elem().NumField(); j++ {
+			cloneValue(cp.Elem().Field(j))
+		}
+		dv.Field(i).Set(cp)
+	}
+
+	return dst
+}
+
+// cloneValue replaces the slice or map value of v to the copy of its.
+func cloneValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(cp, v)
+		v.Set(cp)
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), iter.Value())
+		}
+		v.Set(cp)
+	}
+}
+
+// tomlName returns the toml key name of the struct field.
+func tomlName(sf reflect.StructField) string {
+	if name := strings.Split(sf.Tag.Get("toml"), ",")[0]; name != "" {
+		return name
+	}
+	return sf.Name
+}
@@ -0,0 +1,147 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/zchee/nvim-go/pkg/internal/testutil"
+)
+
+func testConfig() *Config {
+	return &Config{
+		Global:     &Global{ErrorListType: "locationlist"},
+		Build:      &build{Flags: []string{"-race"}},
+		Cover:      &cover{Mode: "atomic"},
+		Fmt:        &fmt{Mode: "goimports", Autosave: true},
+		FillStruct: &fillStruct{},
+		Generate:   &generate{},
+		Guru:       &guru{KeepCursor: map[string]bool{"callers": true}},
+		Highlight:  &highlight{},
+		Iferr:      &iferr{},
+		Lint:       &lint{GolintMode: "current", MetalinterTools: []string{"vet", "golint"}},
+		Rename:     &rename{},
+		Signature:  &signature{},
+		Symbols:    &symbols{},
+		Terminal:   &terminal{Mode: "vsplit"},
+		Test:       &test{},
+		Debug:      &debug{},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	root := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(root, "go.mod"), "module example.com/foo\n")
+	testutil.WriteFile(t, filepath.Join(root, ProjectFile), `
+[build]
+flags = ["-tags=integration"]
+
+[fmt]
+mode = "fmt"
+goimports_local = ["example.com/foo"]
+
+[lint]
+metalinter_tools = ["staticcheck"]
+`)
+	pkgDir := filepath.Join(root, "pkg", "bar")
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	gcfg := testConfig()
+	got, err := Resolve(gcfg, pkgDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := testConfig()
+	want.Build.Flags = []string{"-tags=integration"}
+	want.Fmt.Mode = "fmt"
+	want.Fmt.GoImportsLocal = []string{"example.com/foo"}
+	want.Lint.MetalinterTools = []string{"staticcheck"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Resolve(%s): (-want +got)\n%s", pkgDir, diff)
+	}
+
+	if diff := cmp.Diff(testConfig(), gcfg); diff != "" {
+		t.Errorf("Resolve(%s) modified the g: config: (-want +got)\n%s", pkgDir, diff)
+	}
+}
+
+func TestResolveNotFound(t *testing.T) {
+	root := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(root, ProjectFile), "[fmt]\nmode = \"fmt\"\n")
+	// go.mod marks the project root, so the ProjectFile of parent directory should not be used
+	modDir := filepath.Join(root, "mod")
+	testutil.WriteFile(t, filepath.Join(modDir, "go.mod"), "module example.com/mod\n")
+
+	gcfg := testConfig()
+	got, err := Resolve(gcfg, modDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != gcfg {
+		t.Errorf("Resolve(%s) = %#v, want the g: config itself", modDir, got)
+	}
+}
+
+func TestSnapshotFile(t *testing.T) {
+	root := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(root, "a", "go.mod"), "module example.com/a\n")
+	testutil.WriteFile(t, filepath.Join(root, "a", ProjectFile), "[fmt]\nmode = \"fmt\"\n")
+	testutil.WriteFile(t, filepath.Join(root, "b", "go.mod"), "module example.com/b\n")
+	testutil.WriteFile(t, filepath.Join(root, "b", ProjectFile), "[fmt]\nmode = \"gofumpt\"\n")
+
+	// the project b is entered at last
+	s := NewStore()
+	s.base.Store(testConfig())
+	cfg, err := Resolve(testConfig(), filepath.Join(root, "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.current.Store(cfg)
+
+	file := filepath.Join(root, "a", "a.go")
+	ctx, err := SnapshotFile(NewStoreContext(context.Background(), s), file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := FromContext(ctx).Fmt.Mode; got != "fmt" {
+		t.Errorf("SnapshotFile(%s): Fmt.Mode = %q, want %q", file, got, "fmt")
+	}
+	if got := s.Current().Fmt.Mode; got != "gofumpt" {
+		t.Errorf("SnapshotFile(%s) swapped the current config: Fmt.Mode = %q", file, got)
+	}
+}
+
+func TestLoadProjectUnknownKey(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, ProjectFile)
+	testutil.WriteFile(t, path, "[fmt]\nmdoe = \"fmt\"\n")
+
+	if _, err := LoadProject(path); err == nil {
+		t.Errorf("LoadProject(%s) should fail with unknown key", path)
+	}
+}
+
+func TestConfig_Clone(t *testing.T) {
+	cfg := testConfig()
+	cp := cfg.Clone()
+	if diff := cmp.Diff(cfg, cp); diff != "" {
+		t.Fatalf("Clone(): (-want +got)\n%s", diff)
+	}
+
+	cp.Build.Flags[0] = "-v"
+	cp.Guru.KeepCursor["callers"] = false
+	cp.Fmt.Mode = "fmt"
+	if cfg.Build.Flags[0] != "-race" || !cfg.Guru.KeepCursor["callers"] || cfg.Fmt.Mode != "goimports" {
+		t.Errorf("Clone() shares the values with original config: %#v", cfg)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Source represents a source of the setting value.
+type Source string
+
+const (
+	// SourceDefault the default value of eval or envconfig tags.
+	SourceDefault Source = "default"
+	// SourceVar the g:go#... variable.
+	SourceVar Source = "g:"
+	// SourceEnv the NVIM_GO_... environment variable.
+	SourceEnv Source = "env"
+	// SourceProject the ProjectFile.
+	SourceProject Source = "project"
+)
+
+// envPrefix prefix of the environment variables which processed by Process.
+const envPrefix = "NVIM_GO"
+
+// vimVars list of the g:go#... variables which used by the Vim script side.
+var vimVars = []string{
+	"go#complete#omnifunc",
+	"go#daemon",
+	"go#daemon#socket",
+	"go#doc#keywordprg",
+	"go#highlight#delve",
+	"go#highlight#terminal#test",
+	"go#loaded#gosnippets",
+	"go#snippet#engine",
+	"go#snippets#engine",
+}
+
+// Setting represents an effective setting value and its source.
+type Setting struct {
+	// Name g: variable name or environment variable name.
+	Name string
+	// Value effective value of the setting.
+	Value interface{}
+	// Source source of the Value.
+	Source Source
+}
+
+// Settings returns the list of the effective settings of cfg.
+// vars is the g:go#... variables which keyed by the name without "g:" prefix,
+// and p is the project config which applied to cfg, or nil.
+func Settings(cfg *Config, vars map[string]interface{}, p *Project) []Setting {
+	var settings []Setting
+
+	v := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		section := v.Field(i)
+		if section.IsNil() {
+			continue
+		}
+		table := tomlName(v.Type().Field(i))
+
+		section = section.Elem()
+		for j := 0; j < section.NumField(); j++ {
+			sf := section.Type().Field(j)
+			name, _, ok := parseEvalTag(sf.Tag.Get("eval"))
+			if !ok {
+				continue
+			}
+
+			s := Setting{
+				Name:   "g:" + name,
+				Value:  section.Field(j).Interface(),
+				Source: SourceDefault,
+			}
+			switch {
+			case p != nil && p.md.IsDefined(table, tomlName(sf)):
+				s.Source = SourceProject
+			case vars != nil:
+				if _, ok := vars[name]; ok {
+					s.Source = SourceVar
+				}
+			}
+			settings = append(settings, s)
+		}
+	}
+
+	return settings
+}
+
+// EnvSettings returns the list of the env settings.
+func EnvSettings(env Env) []Setting {
+	var settings []Setting
+
+	v := reflect.ValueOf(env)
+	for i := 0; i < v.NumField(); i++ {
+		key := v.Type().Field(i).Tag.Get("envconfig")
+		if key == "" {
+			continue
+		}
+
+		s := Setting{
+			Name:   envPrefix + "_" + key,
+			Value:  v.Field(i).Interface(),
+			Source: SourceDefault,
+		}
+		// envconfig also looks up the key without prefix
+		if _, ok := os.LookupEnv(s.Name); ok {
+			s.Source = SourceEnv
+		} else if _, ok := os.LookupEnv(key); ok {
+			s.Name = key
+			s.Source = SourceEnv
+		}
+		settings = append(settings, s)
+	}
+
+	return settings
+}
+
+// UnknownVars returns the sorted g:go#... variable names in vars which are not used by nvim-go.
+func UnknownVars(vars map[string]interface{}) []string {
+	known := make(map[string]bool)
+	for _, s := range Settings(Default(), nil, nil) {
+		known[strings.TrimPrefix(s.Name, "g:")] = true
+	}
+	for _, name := range vimVars {
+		known[name] = true
+	}
+
+	var unknown []string
+	for name := range vars {
+		if strings.HasPrefix(name, "go#") && !known[name] {
+			unknown = append(unknown, "g:"+name)
+		}
+	}
+	sort.Strings(unknown)
+
+	return unknown
+}
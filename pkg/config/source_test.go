@@ -0,0 +1,134 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/zchee/nvim-go/pkg/internal/testutil"
+)
+
+func TestSettings(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, ProjectFile)
+	testutil.WriteFile(t, path, "[fmt]\nmode = \"fmt\"\n")
+	p, err := LoadProject(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars := map[string]interface{}{
+		"go#fmt#mode":     "goimports",
+		"go#build#flags":  []interface{}{"-race"},
+		"go#highlight#go": 1,
+	}
+	cfg := p.Apply(Default())
+
+	sources := make(map[string]Source)
+	values := make(map[string]interface{})
+	for _, s := range Settings(cfg, vars, p) {
+		sources[s.Name] = s.Source
+		values[s.Name] = s.Value
+	}
+
+	tests := []struct {
+		name   string
+		source Source
+		value  interface{}
+	}{
+		{name: "g:go#fmt#mode", source: SourceProject, value: "fmt"},
+		{name: "g:go#build#flags", source: SourceVar, value: []string{}},
+		{name: "g:go#cover#mode", source: SourceDefault, value: "atomic"},
+	}
+	for _, tt := range tests {
+		if got := sources[tt.name]; got != tt.source {
+			t.Errorf("source of %s = %q, want %q", tt.name, got, tt.source)
+		}
+		if diff := cmp.Diff(tt.value, values[tt.name]); diff != "" {
+			t.Errorf("value of %s: (-want +got)\n%s", tt.name, diff)
+		}
+	}
+	if _, ok := sources["g:go#highlight#go"]; ok {
+		t.Errorf("Settings should not contain the unknown variable")
+	}
+}
+
+func TestEnvSettings(t *testing.T) {
+	t.Setenv("NVIM_GO_LOG_LEVEL", "debug")
+
+	sources := make(map[string]Source)
+	for _, s := range EnvSettings(Env{LogLevel: "debug"}) {
+		sources[s.Name] = s.Source
+	}
+	if got := sources["NVIM_GO_LOG_LEVEL"]; got != SourceEnv {
+		t.Errorf("source of NVIM_GO_LOG_LEVEL = %q, want %q", got, SourceEnv)
+	}
+	if got := sources["NVIM_GO_DATADOG_AGENT_HOSTNAME"]; got != SourceDefault {
+		t.Errorf("source of NVIM_GO_DATADOG_AGENT_HOSTNAME = %q, want %q", got, SourceDefault)
+	}
+}
+
+func TestUnknownVars(t *testing.T) {
+	vars := map[string]interface{}{
+		"go#fmt#mode":                "goimports",
+		"go#fmt#mdoe":                "goimports",
+		"go#highlight#terminal#test": 1,
+		"go#lint#golint#autosve":     1,
+	}
+
+	want := []string{"g:go#fmt#mdoe", "g:go#lint#golint#autosve"}
+	if diff := cmp.Diff(want, UnknownVars(vars)); diff != "" {
+		t.Errorf("UnknownVars: (-want +got)\n%s", diff)
+	}
+}
+
+// runtimeVarRe matches the g:go#... variable names which read by the runtime files.
+var runtimeVarRe = regexp.MustCompile(`g:(?:go#[\w#]+|, '+go#[\w#]+)`)
+
+func TestVimVars(t *testing.T) {
+	var files []string
+	for _, dir := range []string{"autoload", "ftplugin", "plugin", "syntax"} {
+		err := filepath.Walk(filepath.Join("..", "..", dir), func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !fi.IsDir() && filepath.Ext(path) == ".vim" {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+	}
+	if len(files) == 0 {
+		t.Fatal("no runtime files found")
+	}
+
+	vars := make(map[string]interface{})
+	for _, path := range files {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range runtimeVarRe.FindAllString(string(data), -1) {
+			name := m[strings.Index(m, "go#"):]
+			if strings.HasSuffix(name, "#") { // wildcard, like dictwatcheradd(g:, 'go#*', ...)
+				continue
+			}
+			vars[name] = nil
+		}
+	}
+	if unknown := UnknownVars(vars); len(unknown) > 0 {
+		t.Errorf("the variables read by the runtime files are not known: %v", unknown)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package daemon
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// spawnTimeout timeout of waiting for the spawned daemon to listen.
+const spawnTimeout = 5 * time.Second
+
+// Dial connects to the daemon which listening on path.
+// If the daemon is not running, Dial spawns the daemon with "-daemon" flag and waits for listening.
+func Dial(ctx context.Context, path string) (net.Conn, error) {
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		return conn, nil
+	}
+
+	if err := spawn(path); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, spawnTimeout)
+	defer cancel()
+
+	delay := 5 * time.Millisecond
+	for {
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			return conn, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(err, "daemon is not listening on %s", path)
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > 200*time.Millisecond {
+			delay = 200 * time.Millisecond
+		}
+	}
+}
+
+// spawn starts the daemon process which is detached from the client process.
+func spawn(path string) error {
+	bin, err := os.Executable()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	cmd := exec.Command(bin, "-daemon", "-socket", path)
+	cmd.SysProcAttr = sysProcAttr()
+	if err := cmd.Start(); err != nil {
+		return errors.Wrap(err, "failed to spawn daemon")
+	}
+
+	return errors.WithStack(cmd.Process.Release())
+}
+
+// Forward forwards the msgpack-rpc stream between the Neovim (r and w) and the daemon listening on path,
+// until either side is closed.
+func Forward(ctx context.Context, path string, r io.Reader, w io.Writer) error {
+	conn, err := Dial(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	errc := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(conn, r)
+		errc <- err
+	}()
+	go func() {
+		_, err := io.Copy(w, conn)
+		errc <- err
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errc:
+		return errors.WithStack(err)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/neovim/go-client/nvim/plugin"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/zchee/nvim-go/pkg/config"
+	"github.com/zchee/nvim-go/pkg/logger"
+	"github.com/zchee/nvim-go/pkg/nctx"
+)
+
+// RegisterFunc registers the handlers of the client to p.
+type RegisterFunc func(ctx context.Context, p *plugin.Plugin) error
+
+// Daemon represents a nvim-go daemon.
+type Daemon struct {
+	// Path unix socket path of the daemon.
+	Path string
+
+	ln       net.Listener
+	register RegisterFunc
+	clients  int64 // for client id
+	wg       sync.WaitGroup
+}
+
+// DefaultSocketPath returns the default unix socket path of the daemon.
+//
+// The socket is placed in $XDG_RUNTIME_DIR/nvim-go, or the user's temporary directory if $XDG_RUNTIME_DIR is empty.
+func DefaultSocketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), nctx.AppName+"-"+strconv.Itoa(os.Getuid()))
+	} else {
+		dir = filepath.Join(dir, nctx.AppName)
+	}
+
+	return filepath.Join(dir, "daemon.sock")
+}
+
+// Listen listens on the unix socket path.
+// Listen removes the stale socket file if the other daemon is not listening on it.
+func Listen(path string, register RegisterFunc) (*Daemon, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, errors.Errorf("daemon is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &Daemon{
+		Path:     path,
+		ln:       ln,
+		register: register,
+	}, nil
+}
+
+// Serve accepts the client connections and serves each client until ctx is canceled.
+func (d *Daemon) Serve(ctx context.Context) error {
+	log := logger.FromContext(ctx).Named("daemon")
+	ctx = logger.NewContext(ctx, log)
+
+	go func() {
+		<-ctx.Done()
+		d.ln.Close()
+	}()
+
+	log.Info("listening", zap.String("path", d.Path))
+	for {
+		conn, err := d.ln.Accept()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				d.wg.Wait()
+				return nil
+			default:
+			}
+			return errors.WithStack(err)
+		}
+
+		id := atomic.AddInt64(&d.clients, 1)
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			if err := d.serveClient(ctx, id, conn); err != nil {
+				log.Error("serveClient", zap.Int64("client", id), zap.Error(err))
+			}
+		}()
+	}
+}
+
+// serveClient serves the client connection as the remote plugin host of the client Neovim.
+func (d *Daemon) serveClient(ctx context.Context, id int64, conn net.Conn) error {
+	defer conn.Close()
+
+	ctx = logger.WithContext(ctx, zap.Int64("client", id))
+	log := logger.FromContext(ctx)
+
+	v, err := nvim.New(conn, conn, conn, func(format string, a ...interface{}) {
+		log.Info(fmt.Sprintf(format, a...))
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer v.Close()
+
+	// the config is reloaded per client by the BufEnter autocmd, so each client has own Store
+	ctx = config.NewStoreContext(ctx, config.NewStore())
+
+	p := plugin.New(v)
+	if err := d.register(ctx, p); err != nil {
+		return errors.WithStack(err)
+	}
+
+	log.Info("client connected")
+	defer log.Info("client disconnected")
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- v.Serve()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errc:
+		return err
+	}
+}
+
+// Close closes the listener of the daemon and removes the socket file.
+func (d *Daemon) Close() error {
+	err := d.ln.Close()
+	if rmErr := os.Remove(d.Path); rmErr != nil && !os.IsNotExist(rmErr) && err == nil {
+		err = rmErr
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/neovim/go-client/nvim/plugin"
+	"go.uber.org/zap"
+
+	"github.com/zchee/nvim-go/pkg/config"
+	"github.com/zchee/nvim-go/pkg/logger"
+)
+
+// storeRegister registers the "store" handler which returns the address of the client Store.
+func storeRegister(ctx context.Context, p *plugin.Plugin) error {
+	return p.Nvim.RegisterHandler("store", func() (string, error) {
+		return fmt.Sprintf("%p", config.StoreFromContext(ctx)), nil
+	})
+}
+
+func startDaemon(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "daemon.sock")
+	d, err := Listen(path, storeRegister)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(logger.NewContext(context.Background(), zap.NewNop()))
+	errc := make(chan error, 1)
+	go func() {
+		errc <- d.Serve(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-errc; err != nil {
+			t.Error(err)
+		}
+	})
+
+	return path
+}
+
+func newClient(t *testing.T, r io.Reader, w io.Writer, c io.Closer) *nvim.Nvim {
+	t.Helper()
+
+	v, err := nvim.New(r, w, c, t.Logf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go v.Serve()
+	t.Cleanup(func() { v.Close() })
+
+	return v
+}
+
+func TestDaemon_Serve(t *testing.T) {
+	path := startDaemon(t)
+
+	stores := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("unix", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := newClient(t, conn, conn, conn)
+
+		var store string
+		if err := v.Request("store", &store); err != nil {
+			t.Fatal(err)
+		}
+		stores[store] = true
+	}
+
+	if len(stores) != 2 {
+		t.Errorf("clients should have the own config.Store: %v", stores)
+	}
+}
+
+func TestListen_AlreadyListening(t *testing.T) {
+	path := startDaemon(t)
+
+	if _, err := Listen(path, storeRegister); err == nil {
+		t.Errorf("Listen(%s) should fail if the daemon is already listening", path)
+	}
+}
+
+func TestForward(t *testing.T) {
+	path := startDaemon(t)
+
+	// editor <-> Forward <-> daemon
+	editorR, forwardW := io.Pipe()
+	forwardR, editorW := io.Pipe()
+	go Forward(context.Background(), path, forwardR, forwardW)
+
+	v := newClient(t, editorR, editorW, editorW)
+	var store string
+	if err := v.Request("store", &store); err != nil {
+		t.Fatal(err)
+	}
+	if store == "" {
+		t.Errorf("Request(store) returns empty")
+	}
+}
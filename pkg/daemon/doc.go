@@ -0,0 +1,10 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package daemon implements a nvim-go daemon which serves multiple Neovim instances over the unix socket.
+//
+// Each Neovim runs "nvim-go -client" as the remote plugin host, which forwards the msgpack-rpc stream to the daemon.
+// The daemon shares the process-wide caches across the clients, and the per-client state such as
+// buildctxt.Context and config.Store is created for each connection.
+package daemon
@@ -0,0 +1,16 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !windows
+
+package daemon
+
+import (
+	"syscall"
+)
+
+// sysProcAttr returns the SysProcAttr which detaches the daemon from the session of client.
+func sysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
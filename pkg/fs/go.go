@@ -6,7 +6,11 @@ package fs
 
 import (
 	"go/build"
+	"io/ioutil"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -135,3 +139,98 @@ func PackageRoot(path string) (*build.Package, error) {
 		path = filepath.Dir(path)
 	}
 }
+
+// FindModuleRoot finds the Go module root directory that contains the go.mod file from dir.
+// It reports false if dir is not in any Go module.
+func FindModuleRoot(dir string) (string, bool) {
+	if !IsDir(dir) {
+		dir = filepath.Dir(dir)
+	}
+	dir = filepath.Clean(dir)
+
+	for {
+		if fi, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil && !fi.IsDir() {
+			return dir, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// ModulePath returns the module path which declared in the go.mod file of the module root directory.
+// It reports false if root does not have the go.mod file, or the go.mod file does not declare the module path.
+func ModulePath(root string) (string, bool) {
+	data, err := ioutil.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), true
+		}
+	}
+	return "", false
+}
+
+// Module represents a Go module which is downloaded to the local directory.
+type Module struct {
+	Path string // the module path
+	Dir  string // the directory which holds the module files
+}
+
+// ModuleDependencies returns the dependency modules of the Go module root which are in the module cache,
+// or in the vendor directory if the module is vendored.
+// It does not access the network, skips the modules which are not downloaded, and never modifies the go.mod and go.sum files.
+func ModuleDependencies(root string) ([]Module, error) {
+	if _, err := os.Stat(filepath.Join(root, "vendor", "modules.txt")); err == nil {
+		return vendorModules(root)
+	}
+
+	cmd := exec.Command("go", "list", "-e", "-m", "-f", "{{.Path}}\t{{.Dir}}", "all")
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=readonly", "GOPROXY=off")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var mods []Module
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\t", 2)
+		if len(fields) != 2 || fields[1] == "" || fields[1] == root {
+			continue
+		}
+		mods = append(mods, Module{Path: fields[0], Dir: fields[1]})
+	}
+
+	return mods, nil
+}
+
+// vendorModules returns the modules which listed in the vendor/modules.txt file of the Go module root.
+func vendorModules(root string) ([]Module, error) {
+	data, err := ioutil.ReadFile(filepath.Join(root, "vendor", "modules.txt"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var mods []Module
+	for _, line := range strings.Split(string(data), "\n") {
+		// the module line is "# path version", and "## explicit" is the annotation of the previous module
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "#" {
+			continue
+		}
+		dir := filepath.Join(root, "vendor", filepath.FromSlash(fields[1]))
+		if !IsDir(dir) {
+			continue
+		}
+		mods = append(mods, Module{Path: fields[1], Dir: dir})
+	}
+
+	return mods, nil
+}
@@ -6,9 +6,12 @@ package fs_test
 
 import (
 	"go/build"
+	"io/ioutil"
 	"path/filepath"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/zchee/nvim-go/pkg/fs"
 	"github.com/zchee/nvim-go/pkg/internal/testutil"
 )
@@ -167,3 +170,95 @@ func TestPackageID(t *testing.T) {
 		})
 	}
 }
+
+func TestFindModuleRoot(t *testing.T) {
+	moduleRoot, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		dir    string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "package directory",
+			dir:    filepath.Join(moduleRoot, "pkg", "fs"),
+			want:   moduleRoot,
+			wantOK: true,
+		},
+		{
+			name:   "go file",
+			dir:    filepath.Join(moduleRoot, "pkg", "fs", "go.go"),
+			want:   moduleRoot,
+			wantOK: true,
+		},
+		{
+			name:   "module root",
+			dir:    moduleRoot,
+			want:   moduleRoot,
+			wantOK: true,
+		},
+		{
+			name:   "root directory",
+			dir:    string(filepath.Separator),
+			want:   "",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := fs.FindModuleRoot(tt.dir)
+			if ok != tt.wantOK {
+				t.Errorf("FindModuleRoot(%v) ok = %v, wantOK %v", tt.dir, ok, tt.wantOK)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("FindModuleRoot(%v) = got: %v, want %v", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModuleDependencies(t *testing.T) {
+	t.Run("vendor", func(t *testing.T) {
+		root := t.TempDir()
+		testutil.WriteFile(t, filepath.Join(root, "go.mod"), "module example.com/foo\n\nrequire (\n\texample.com/bar v1.0.0\n\texample.com/baz v1.0.0\n)\n")
+		testutil.WriteFile(t, filepath.Join(root, "vendor", "modules.txt"), "# example.com/bar v1.0.0\n## explicit\nexample.com/bar\n# example.com/baz v1.0.0\n## explicit\n")
+		testutil.WriteFile(t, filepath.Join(root, "vendor", "example.com", "bar", "bar.go"), "package bar\n")
+
+		got, err := fs.ModuleDependencies(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []fs.Module{{Path: "example.com/bar", Dir: filepath.Join(root, "vendor", "example.com", "bar")}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ModuleDependencies(%s): (-want +got)\n%s", root, diff)
+		}
+	})
+
+	t.Run("readonly", func(t *testing.T) {
+		root := t.TempDir()
+		// the go directive is missing, which makes the go command add it to go.mod with -mod=mod
+		const gomod = "module example.com/foo\n"
+		testutil.WriteFile(t, filepath.Join(root, "go.mod"), gomod)
+
+		fs.ModuleDependencies(root)
+
+		data, err := ioutil.ReadFile(filepath.Join(root, "go.mod"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != gomod {
+			t.Errorf("ModuleDependencies(%s) modified go.mod:\n%s", root, data)
+		}
+		if fs.IsExist(filepath.Join(root, "go.sum")) {
+			t.Errorf("ModuleDependencies(%s) created go.sum", root)
+		}
+	})
+}
@@ -0,0 +1,301 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package complete provides the completion candidates of the identifier at the position of the type-checked Go file.
+package complete
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Kind represents the kind of the completion candidate.
+type Kind int
+
+// list of the kinds of the completion candidate.
+const (
+	Var Kind = iota
+	Const
+	Func
+	Method
+	Field
+	Type
+	Package
+	Keyword
+)
+
+// letters is the 'kind' of the Vim complete-items of each Kind.
+var letters = [...]string{
+	Var:     "v",
+	Const:   "d",
+	Func:    "f",
+	Method:  "f",
+	Field:   "m",
+	Type:    "t",
+	Package: "p",
+	Keyword: "k",
+}
+
+// Letter returns the 'kind' of the Vim complete-items.
+func (k Kind) Letter() string { return letters[k] }
+
+// Item represents a completion candidate.
+type Item struct {
+	Word   string
+	Kind   Kind
+	Detail string // the type or the signature
+	Import string // the import path which needs to be added, or empty if already imported
+}
+
+// Context represents the completion context at a position of the file.
+type Context struct {
+	// Selector is the expression before the dot of the selector, or nil if the position is not a selector.
+	Selector ast.Expr
+	// Unresolved is the package name of the selector which is not imported, such as "json" of "json.".
+	Unresolved string
+	// Prefix is the partial identifier which is already typed at the position.
+	Prefix string
+}
+
+// keywords is the Go keywords.
+var keywords = []string{
+	"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for", "func",
+	"go", "goto", "if", "import", "interface", "map", "package", "range", "return", "select", "struct", "switch",
+	"type", "var",
+}
+
+// NewContext returns the completion context of the identifier prefix which starts at pos of file.
+// NewContext reports false if pos is in the comment or the string literal.
+func NewContext(file *ast.File, info *types.Info, pos token.Pos, prefix string) (*Context, bool) {
+	for _, cg := range file.Comments {
+		if cg.Pos() < pos && pos <= cg.End() {
+			return nil, false
+		}
+	}
+	inLit := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Pos() < pos && pos < lit.End() {
+			inLit = true
+		}
+		return !inLit
+	})
+	if inLit {
+		return nil, false
+	}
+
+	ctx := &Context{Prefix: prefix}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ctx.Selector != nil {
+			return false
+		}
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		// the dot is just before pos, and the Sel is the partial identifier or the placeholder of the parse error
+		if sel.X.End() == pos-1 {
+			ctx.Selector = sel.X
+			return false
+		}
+		return true
+	})
+
+	if id, ok := ctx.Selector.(*ast.Ident); ok && info.ObjectOf(id) == nil {
+		ctx.Selector, ctx.Unresolved = nil, id.Name
+	}
+
+	return ctx, true
+}
+
+// Items returns the completion candidates of ctx at pos of pkg. The candidates are sorted by the scope and the name.
+func (ctx *Context) Items(pkg *types.Package, info *types.Info, pos token.Pos) []Item {
+	qf := types.RelativeTo(pkg)
+	if ctx.Selector != nil {
+		return ctx.members(pkg, info, qf)
+	}
+	if ctx.Unresolved != "" {
+		return nil
+	}
+
+	var items []Item
+	seen := make(map[string]bool)
+	scope := pkg.Scope().Innermost(pos)
+	if scope == nil {
+		scope = pkg.Scope()
+	}
+	for ; scope != nil; scope = scope.Parent() {
+		// the objects of the package, file and universe scopes are visible regardless of the position
+		local := scope != pkg.Scope() && scope != types.Universe && scope.Parent() != pkg.Scope()
+		for _, name := range scope.Names() {
+			obj := scope.Lookup(name)
+			if seen[name] || !strings.HasPrefix(name, ctx.Prefix) || name == "_" {
+				continue
+			}
+			if local && obj.Pos() > pos {
+				continue // declared after the position
+			}
+			seen[name] = true
+			items = append(items, newItem(obj, qf))
+		}
+	}
+	for _, kw := range keywords {
+		if strings.HasPrefix(kw, ctx.Prefix) && !seen[kw] {
+			items = append(items, Item{Word: kw, Kind: Keyword})
+		}
+	}
+
+	return items
+}
+
+// members returns the fields and the methods of the selector, or the exported members of the imported package.
+func (ctx *Context) members(pkg *types.Package, info *types.Info, qf types.Qualifier) []Item {
+	if id, ok := ctx.Selector.(*ast.Ident); ok {
+		if pkgName, ok := info.ObjectOf(id).(*types.PkgName); ok {
+			return PackageMembers(pkgName.Imported(), ctx.Prefix, "", qf)
+		}
+	}
+
+	tv, ok := info.Types[ctx.Selector]
+	if !ok || tv.Type == nil || tv.Type == types.Typ[types.Invalid] {
+		return nil
+	}
+
+	var items []Item
+	seen := make(map[string]bool)
+	add := func(obj types.Object) {
+		name := obj.Name()
+		if seen[name] || !strings.HasPrefix(name, ctx.Prefix) || (!obj.Exported() && obj.Pkg() != pkg) {
+			return
+		}
+		seen[name] = true
+		items = append(items, newItem(obj, qf))
+	}
+
+	typ := tv.Type
+	if !tv.IsType() {
+		if _, ok := typ.Underlying().(*types.Interface); !ok {
+			if _, ok := typ.(*types.Pointer); !ok {
+				typ = types.NewPointer(typ) // includes the methods of the pointer receiver of the addressable value
+			}
+		}
+	}
+	mset := types.NewMethodSet(typ)
+	for i := 0; i < mset.Len(); i++ {
+		add(mset.At(i).Obj())
+	}
+	if !tv.IsType() {
+		for _, field := range fields(tv.Type) {
+			add(field)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Word < items[j].Word })
+	return items
+}
+
+// fields returns the fields of the struct type, including the promoted fields of the embedded fields
+// in the breadth-first order.
+func fields(typ types.Type) []*types.Var {
+	var vars []*types.Var
+	seen := make(map[types.Type]bool)
+	queue := []types.Type{typ}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		if p, ok := t.(*types.Pointer); ok {
+			t = p.Elem()
+		}
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+
+		st, ok := t.Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			f := st.Field(i)
+			vars = append(vars, f)
+			if f.Embedded() {
+				queue = append(queue, f.Type())
+			}
+		}
+	}
+	return vars
+}
+
+// PackageMembers returns the exported members of pkg which have the prefix.
+// The Import of the items is importPath which is empty if pkg is already imported.
+func PackageMembers(pkg *types.Package, prefix, importPath string, qf types.Qualifier) []Item {
+	var items []Item
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		item := newItem(obj, qf)
+		item.Import = importPath
+		items = append(items, item)
+	}
+	return items
+}
+
+// newItem returns the completion candidate of obj.
+func newItem(obj types.Object, qf types.Qualifier) Item {
+	item := Item{Word: obj.Name()}
+	switch obj := obj.(type) {
+	case *types.PkgName:
+		item.Kind, item.Detail = Package, obj.Imported().Path()
+	case *types.TypeName:
+		item.Kind = Type
+		switch obj.Type().Underlying().(type) {
+		case *types.Struct:
+			item.Detail = "struct"
+		case *types.Interface:
+			item.Detail = "interface"
+		default:
+			item.Detail = types.TypeString(obj.Type().Underlying(), qf)
+		}
+	case *types.Const:
+		item.Kind, item.Detail = Const, types.TypeString(obj.Type(), qf)
+	case *types.Func:
+		item.Kind, item.Detail = Func, types.TypeString(obj.Type(), qf)
+		if sig, ok := obj.Type().(*types.Signature); ok && sig.Recv() != nil {
+			item.Kind = Method
+		}
+	case *types.Builtin:
+		item.Kind, item.Detail = Func, "builtin"
+	case *types.Var:
+		item.Kind, item.Detail = Var, types.TypeString(obj.Type(), qf)
+		if obj.IsField() {
+			item.Kind = Field
+		}
+	default: // nil
+		item.Kind = Var
+	}
+	return item
+}
+
+// ImportPathToName returns the package name which is assumed from the import path, such as "yaml" of "gopkg.in/yaml.v2".
+func ImportPathToName(importPath string) string {
+	base := path.Base(importPath)
+	if strings.HasPrefix(base, "v") && len(base) > 1 && strings.Trim(base[1:], "0123456789") == "" {
+		if dir := path.Dir(importPath); dir != "." {
+			base = path.Base(dir) // the major version suffix of the module
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_')
+	}); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
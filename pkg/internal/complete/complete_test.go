@@ -0,0 +1,148 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package complete
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// src is the test source, and the typed text of each test case is inserted at "@".
+const src = `package p
+
+import "strings"
+
+type Base struct{ ID int }
+
+func (b *Base) Reset() {}
+
+type T struct {
+	Base
+	name string
+}
+
+func (t T) Name() string { return strings.ToUpper(t.name) }
+
+func f(t T) {
+	local := 1
+	@
+	later := local
+	_ = later
+}
+`
+
+func TestItems(t *testing.T) {
+	tests := []struct {
+		name       string
+		typed      string
+		want       []string
+		unresolved string
+		noContext  bool
+	}{
+		{
+			name:  "Members",
+			typed: "t.",
+			want:  []string{"Base", "ID", "Name", "Reset", "name"},
+		},
+		{
+			name:  "MembersPrefix",
+			typed: "t.Na",
+			want:  []string{"Name"},
+		},
+		{
+			name:  "PackageMembers",
+			typed: "strings.HasP",
+			want:  []string{"HasPrefix"},
+		},
+		{
+			name:  "Local",
+			typed: "lo",
+			want:  []string{"local"},
+		},
+		{
+			name:  "DeclaredLater",
+			typed: "la",
+		},
+		{
+			name:  "BuiltinAndKeyword",
+			typed: "re",
+			want:  []string{"real", "recover", "return"},
+		},
+		{
+			name:       "Unresolved",
+			typed:      "json.Mar",
+			unresolved: "json",
+		},
+		{
+			name:      "Comment",
+			typed:     "// t",
+			noContext: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			prefix := tt.typed[strings.LastIndexAny(tt.typed, "./ ")+1:]
+			offset := strings.Index(src, "@") + len(tt.typed) - len(prefix)
+
+			fset := token.NewFileSet()
+			file, _ := parser.ParseFile(fset, "p.go", strings.Replace(src, "@", tt.typed, 1), parser.ParseComments)
+			info := &types.Info{
+				Types:  make(map[ast.Expr]types.TypeAndValue),
+				Defs:   make(map[*ast.Ident]types.Object),
+				Uses:   make(map[*ast.Ident]types.Object),
+				Scopes: make(map[ast.Node]*types.Scope),
+			}
+			conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil), Error: func(error) {}}
+			pkg, _ := conf.Check("p", fset, []*ast.File{file}, info)
+
+			pos := fset.File(file.Pos()).Pos(offset)
+			ctx, ok := NewContext(file, info, pos, prefix)
+			if ok == tt.noContext {
+				t.Fatalf("%s: got context %t, want %t", tt.name, ok, !tt.noContext)
+			}
+			if !ok {
+				return
+			}
+			if ctx.Unresolved != tt.unresolved {
+				t.Errorf("%s: got unresolved %q, want %q", tt.name, ctx.Unresolved, tt.unresolved)
+			}
+
+			var got []string
+			for _, item := range ctx.Items(pkg, info, pos) {
+				got = append(got, item.Word)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
+
+func TestImportPathToName(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "encoding/json", want: "json"},
+		{path: "gopkg.in/yaml.v2", want: "yaml"},
+		{path: "github.com/go-kit/kit/v2", want: "kit"},
+		{path: "github.com/mattn/go-isatty", want: "isatty"},
+	}
+	for _, tt := range tests {
+		if got := ImportPathToName(tt.path); got != tt.want {
+			t.Errorf("ImportPathToName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package godoc renders the Go documentation as the markdown for the Neovim buffers.
+package godoc
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/printer"
+	"go/token"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Markdown converts the doc comment text such as the result of ast.CommentGroup.Text to the markdown lines.
+// The preformatted blocks are fenced as the Go code, and the headings are prefixed with "###".
+func Markdown(text string) []string {
+	var lines []string
+	var para, code []string
+	flushPara := func() {
+		if len(para) == 0 {
+			return
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, para...)
+		para = nil
+	}
+	flushCode := func() {
+		for len(code) > 0 && code[len(code)-1] == "" {
+			code = code[:len(code)-1]
+		}
+		if len(code) == 0 {
+			return
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "```go")
+		lines = append(lines, unindent(code)...)
+		lines = append(lines, "```")
+		code = nil
+	}
+
+	blocks := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range blocks {
+		switch {
+		case line != "" && (line[0] == ' ' || line[0] == '\t'):
+			flushPara()
+			code = append(code, line)
+		case line == "":
+			if len(code) > 0 {
+				code = append(code, "") // the blank line in the preformatted block
+				continue
+			}
+			flushPara()
+		default:
+			flushCode()
+			// the heading is the single line paragraph which followed by the paragraph
+			if len(para) == 0 && len(lines) > 0 && i > 0 && blocks[i-1] == "" &&
+				i+2 < len(blocks) && blocks[i+1] == "" && blocks[i+2] != "" && isHeading(line) {
+				lines = append(lines, "", "### "+line)
+				continue
+			}
+			para = append(para, line)
+		}
+	}
+	flushPara()
+	flushCode()
+
+	return lines
+}
+
+// isHeading reports whether the line is the heading of the doc comment the same as go/doc.
+func isHeading(line string) bool {
+	r := []rune(line)
+	if !unicode.IsUpper(r[0]) || !(unicode.IsLetter(r[len(r)-1]) || unicode.IsDigit(r[len(r)-1])) {
+		return false
+	}
+	for _, c := range r {
+		if unicode.IsPunct(c) && !strings.ContainsRune("(),'", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// unindent removes the common indent of the lines.
+func unindent(lines []string) []string {
+	indent := ""
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		prefix := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if i == 0 || indent == "" || len(prefix) < len(indent) {
+			indent = prefix
+		}
+	}
+
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = strings.TrimPrefix(line, indent)
+	}
+	return out
+}
+
+// Decl returns the declaration source of the identifier at pos in file without the function body,
+// and the text of its doc comment. Decl returns false if pos is not the package-level declaration,
+// the struct field or the interface method.
+func Decl(fset *token.FileSet, file *ast.File, pos token.Pos) (decl, doc string, ok bool) {
+	path, _ := astutil.PathEnclosingInterval(file, pos, pos)
+
+	for i, node := range path {
+		switch node := node.(type) {
+		case *ast.FuncDecl:
+			return funcDecl(fset, node), node.Doc.Text(), true
+
+		case *ast.TypeSpec:
+			spec := *node
+			spec.Doc, spec.Comment = nil, nil
+			return "type " + nodeString(fset, &spec), specDoc(node.Doc, path[i+1:]), true
+
+		case *ast.ValueSpec:
+			spec := *node
+			spec.Doc, spec.Comment = nil, nil
+			tok := "var"
+			if gd, ok := path[i+1].(*ast.GenDecl); ok {
+				tok = gd.Tok.String()
+			}
+			doc := specDoc(node.Doc, path[i+1:])
+			if doc == "" {
+				doc = node.Comment.Text()
+			}
+			return tok + " " + nodeString(fset, &spec), doc, true
+
+		case *ast.Field:
+			if i+2 >= len(path) {
+				return "", "", false
+			}
+			switch path[i+2].(type) {
+			case *ast.StructType, *ast.InterfaceType:
+			default:
+				return "", "", false // the parameter or the result
+			}
+			var names []string
+			for _, name := range node.Names {
+				names = append(names, name.Name)
+			}
+			typ := nodeString(fset, node.Type)
+			if _, ok := node.Type.(*ast.FuncType); ok && len(names) > 0 {
+				decl = names[0] + strings.TrimPrefix(typ, "func")
+			} else {
+				decl = strings.TrimSpace(strings.Join(names, ", ") + " " + typ)
+			}
+			doc := node.Doc.Text()
+			if doc == "" {
+				doc = node.Comment.Text()
+			}
+			return decl, doc, true
+
+		case *ast.BlockStmt, *ast.FuncLit:
+			return "", "", false // the local declaration
+		}
+	}
+
+	return "", "", false
+}
+
+// specDoc returns the doc comment of the spec, or the GenDecl's one if the spec is not grouped.
+func specDoc(doc *ast.CommentGroup, parents []ast.Node) string {
+	if doc != nil {
+		return doc.Text()
+	}
+	if len(parents) > 0 {
+		if gd, ok := parents[0].(*ast.GenDecl); ok && !gd.Lparen.IsValid() {
+			return gd.Doc.Text()
+		}
+	}
+	return ""
+}
+
+// PackageDoc returns the package doc comment of the files.
+// The comments of all files are concatenated the same as go/doc if more than one file has the package comment.
+func PackageDoc(files []*ast.File) string {
+	var docs []string
+	for _, f := range files {
+		if text := f.Doc.Text(); text != "" {
+			docs = append(docs, text)
+		}
+	}
+	return strings.Join(docs, "\n")
+}
+
+// nodeString returns the source of node.
+func nodeString(fset *token.FileSet, node interface{}) string {
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := cfg.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// Page represents the rendered documentation of a package.
+type Page struct {
+	// Lines is the markdown lines of the documentation.
+	Lines []string
+	// Anchors is the 0-based line of the section of each declaration such as "Reader" and "Reader.Read".
+	Anchors map[string]int
+	// Index is the declaration of each 0-based line of the index.
+	Index map[int]string
+}
+
+// Render renders the documentation of pkg, which has the index, constants, variables, functions, types and examples.
+func Render(fset *token.FileSet, pkg *doc.Package) *Page {
+	p := &Page{Anchors: make(map[string]int), Index: make(map[int]string)}
+
+	p.add("# package "+pkg.Name, "", "```go", fmt.Sprintf("import %q", pkg.ImportPath), "```")
+	p.doc(pkg.Doc)
+	p.examples(fset, pkg.Examples)
+
+	p.add("", "## Index", "")
+	if len(pkg.Consts) > 0 {
+		p.index("Constants", "- Constants")
+	}
+	if len(pkg.Vars) > 0 {
+		p.index("Variables", "- Variables")
+	}
+	for _, f := range pkg.Funcs {
+		p.index(f.Name, "- "+funcDecl(fset, f.Decl))
+	}
+	for _, t := range pkg.Types {
+		p.index(t.Name, "- type "+t.Name)
+		for _, f := range t.Funcs {
+			p.index(f.Name, "  - "+funcDecl(fset, f.Decl))
+		}
+		for _, m := range t.Methods {
+			p.index(t.Name+"."+m.Name, "  - "+funcDecl(fset, m.Decl))
+		}
+	}
+
+	p.values(fset, "## Constants", "Constants", pkg.Consts)
+	p.values(fset, "## Variables", "Variables", pkg.Vars)
+	if len(pkg.Funcs) > 0 {
+		p.add("", "## Functions")
+	}
+	for _, f := range pkg.Funcs {
+		p.fn(fset, "### func "+f.Name, f.Name, f)
+	}
+	if len(pkg.Types) > 0 {
+		p.add("", "## Types")
+	}
+	for _, t := range pkg.Types {
+		p.Anchors[t.Name] = len(p.Lines) + 1
+		p.add("", "### type "+t.Name)
+		p.code(nodeString(fset, t.Decl))
+		p.doc(t.Doc)
+		p.examples(fset, t.Examples)
+		p.values(fset, "", "", t.Consts)
+		p.values(fset, "", "", t.Vars)
+		for _, f := range t.Funcs {
+			p.fn(fset, "#### func "+f.Name, f.Name, f)
+		}
+		for _, m := range t.Methods {
+			p.fn(fset, fmt.Sprintf("#### func (%s) %s", m.Recv, m.Name), t.Name+"."+m.Name, m)
+		}
+	}
+
+	return p
+}
+
+// add appends the lines.
+func (p *Page) add(lines ...string) {
+	p.Lines = append(p.Lines, lines...)
+}
+
+// index appends the index line of the declaration name.
+func (p *Page) index(name, line string) {
+	p.Index[len(p.Lines)] = name
+	p.add(line)
+}
+
+// code appends the fenced Go code.
+func (p *Page) code(src string) {
+	p.add("", "```go")
+	p.add(strings.Split(src, "\n")...)
+	p.add("```")
+}
+
+// doc appends the markdown of the doc comment text.
+func (p *Page) doc(text string) {
+	if md := Markdown(text); len(md) > 0 {
+		p.add("")
+		p.add(md...)
+	}
+}
+
+// values appends the section of the constants or variables.
+func (p *Page) values(fset *token.FileSet, heading, name string, values []*doc.Value) {
+	if len(values) == 0 {
+		return
+	}
+	if heading != "" {
+		p.Anchors[name] = len(p.Lines) + 1
+		p.add("", heading)
+	}
+	for _, v := range values {
+		for _, name := range v.Names {
+			p.Anchors[name] = len(p.Lines) + 1
+		}
+		p.code(nodeString(fset, v.Decl))
+		p.doc(v.Doc)
+	}
+}
+
+// fn appends the section of the function or method.
+func (p *Page) fn(fset *token.FileSet, heading, name string, f *doc.Func) {
+	p.Anchors[name] = len(p.Lines) + 1
+	p.add("", heading)
+	p.code(funcDecl(fset, f.Decl))
+	p.doc(f.Doc)
+	p.examples(fset, f.Examples)
+}
+
+// examples appends the code and the output of the examples.
+func (p *Page) examples(fset *token.FileSet, examples []*doc.Example) {
+	for _, ex := range examples {
+		heading := "#### Example"
+		if ex.Suffix != "" {
+			heading += " (" + ex.Suffix + ")"
+		}
+		p.Anchors["Example"+ex.Name] = len(p.Lines) + 1
+		p.add("", heading)
+		p.doc(ex.Doc)
+
+		var comments []*ast.CommentGroup
+		for _, cg := range ex.Comments {
+			if text := strings.ToLower(cg.Text()); !strings.HasPrefix(text, "output:") && !strings.HasPrefix(text, "unordered output:") {
+				comments = append(comments, cg) // the output comment is shown separately
+			}
+		}
+		src := nodeString(fset, &printer.CommentedNode{Node: ex.Code, Comments: comments})
+		if _, ok := ex.Code.(*ast.BlockStmt); ok {
+			// strip the braces of the function body
+			src = strings.TrimRight(strings.TrimSuffix(strings.TrimPrefix(src, "{\n"), "}"), "\n")
+			src = strings.Join(unindent(strings.Split(src, "\n")), "\n")
+		}
+		p.code(src)
+		if ex.Output != "" {
+			p.add("", "Output:", "", "```")
+			p.add(strings.Split(strings.TrimRight(ex.Output, "\n"), "\n")...)
+			p.add("```")
+		}
+	}
+}
+
+// funcDecl returns the declaration source of the function without the body and the doc comment.
+func funcDecl(fset *token.FileSet, fd *ast.FuncDecl) string {
+	decl := *fd
+	decl.Doc, decl.Body = nil, nil
+	return nodeString(fset, &decl)
+}
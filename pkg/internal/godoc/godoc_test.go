@@ -0,0 +1,255 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godoc
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMarkdown(t *testing.T) {
+	const text = `Package p implements the example.
+
+It has the code block:
+
+	x := p.New()
+
+	x.Run()
+
+Usage
+
+The usage of the package.
+`
+	want := []string{
+		"Package p implements the example.",
+		"",
+		"It has the code block:",
+		"",
+		"```go",
+		"x := p.New()",
+		"",
+		"x.Run()",
+		"```",
+		"",
+		"### Usage",
+		"",
+		"The usage of the package.",
+	}
+	if diff := cmp.Diff(want, Markdown(text)); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestDecl(t *testing.T) {
+	const src = `package p
+
+// Max is the max.
+const Max = 10
+
+var (
+	// Debug enables the debug.
+	Debug bool
+	Quiet bool // Quiet disables the log.
+)
+
+// T is the type.
+type T struct {
+	// N is the number.
+	N, M int
+}
+
+// Reader reads.
+type Reader interface {
+	// Read reads p.
+	Read(p []byte) (int, error)
+}
+
+// Run runs t.
+func (t *T) Run(n int) error {
+	x := n
+	return nil
+}
+`
+	tests := []struct {
+		name     string
+		ident    string // the source which starts with the identifier
+		wantDecl string
+		wantDoc  string
+		wantOK   bool
+	}{
+		{name: "Const", ident: "Max =", wantDecl: "const Max = 10", wantDoc: "Max is the max.\n", wantOK: true},
+		{name: "GroupedVar", ident: "Debug bool", wantDecl: "var Debug bool", wantDoc: "Debug enables the debug.\n", wantOK: true},
+		{name: "LineComment", ident: "Quiet bool", wantDecl: "var Quiet bool", wantDoc: "Quiet disables the log.\n", wantOK: true},
+		{name: "Type", ident: "T struct", wantDecl: "type T struct {\n\t// N is the number.\n\tN, M int\n}", wantDoc: "T is the type.\n", wantOK: true},
+		{name: "Field", ident: "M int", wantDecl: "N, M int", wantDoc: "N is the number.\n", wantOK: true},
+		{name: "InterfaceMethod", ident: "Read(p", wantDecl: "Read(p []byte) (int, error)", wantDoc: "Read reads p.\n", wantOK: true},
+		{name: "Method", ident: "Run(n", wantDecl: "func (t *T) Run(n int) error", wantDoc: "Run runs t.\n", wantOK: true},
+		{name: "Param", ident: "n int)", wantOK: false},
+		{name: "Local", ident: "x := n", wantOK: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pos := f.Pos() + token.Pos(strings.Index(src, tt.ident)) // the package keyword is at the offset 0
+			decl, doc, ok := Decl(fset, f, pos)
+			if ok != tt.wantOK {
+				t.Fatalf("Decl() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if diff := cmp.Diff(tt.wantDecl, decl); diff != "" {
+				t.Errorf("%s: decl: (-want +got)\n%s", tt.name, diff)
+			}
+			if diff := cmp.Diff(tt.wantDoc, doc); diff != "" {
+				t.Errorf("%s: doc: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	const src = `// Package p is the example.
+package p
+
+// Max is the max.
+const Max = 10
+
+// T is the type.
+type T struct{}
+
+// New returns the T.
+func New() *T { return &T{} }
+
+// Run runs t.
+func (t *T) Run() {}
+
+// Hello says hello.
+func Hello() string { return "hello" }
+`
+	const testSrc = `package p_test
+
+import (
+	"fmt"
+
+	"p"
+)
+
+func ExampleHello() {
+	fmt.Println(p.Hello())
+	// Output: hello
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tf, err := parser.ParseFile(fset, "p_test.go", testSrc, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := doc.NewFromFiles(fset, []*ast.File{f, tf}, "example.com/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := Render(fset, pkg)
+	want := []string{
+		"# package p",
+		"",
+		"```go",
+		`import "example.com/p"`,
+		"```",
+		"",
+		"Package p is the example.",
+		"",
+		"## Index",
+		"",
+		"- Constants",
+		"- func Hello() string",
+		"- type T",
+		"  - func New() *T",
+		"  - func (t *T) Run()",
+		"",
+		"## Constants",
+		"",
+		"```go",
+		"const Max = 10",
+		"```",
+		"",
+		"Max is the max.",
+		"",
+		"## Functions",
+		"",
+		"### func Hello",
+		"",
+		"```go",
+		"func Hello() string",
+		"```",
+		"",
+		"Hello says hello.",
+		"",
+		"#### Example",
+		"",
+		"```go",
+		"fmt.Println(p.Hello())",
+		"```",
+		"",
+		"Output:",
+		"",
+		"```",
+		"hello",
+		"```",
+		"",
+		"## Types",
+		"",
+		"### type T",
+		"",
+		"```go",
+		"type T struct{}",
+		"```",
+		"",
+		"T is the type.",
+		"",
+		"#### func New",
+		"",
+		"```go",
+		"func New() *T",
+		"```",
+		"",
+		"New returns the T.",
+		"",
+		"#### func (*T) Run",
+		"",
+		"```go",
+		"func (t *T) Run()",
+		"```",
+		"",
+		"Run runs t.",
+	}
+	if diff := cmp.Diff(want, page.Lines); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+
+	for name, idx := range map[string]int{"Constants": 10, "Hello": 11, "T": 12, "New": 13, "T.Run": 14} {
+		if got := page.Index[idx]; got != name {
+			t.Errorf("Index[%d] = %q, want %q", idx, got, name)
+		}
+		if got := page.Lines[page.Anchors[name]]; !strings.HasPrefix(got, "#") {
+			t.Errorf("the anchor of %s is %q, want the heading", name, got)
+		}
+	}
+}
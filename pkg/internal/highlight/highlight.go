@@ -0,0 +1,188 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package highlight classifies the identifiers of the type-checked Go file by the semantic role
+// for the semantic highlighting.
+package highlight
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// Kind represents the semantic role of the identifier.
+type Kind int
+
+// list of the semantic roles.
+const (
+	Package Kind = iota
+	Type
+	Interface
+	Field
+	Method
+	Parameter
+	Constant
+	Builtin
+	Shadowed
+	Unused
+)
+
+var groups = [...]string{
+	Package:   "GoSemanticPackage",
+	Type:      "GoSemanticType",
+	Interface: "GoSemanticInterface",
+	Field:     "GoSemanticField",
+	Method:    "GoSemanticMethod",
+	Parameter: "GoSemanticParameter",
+	Constant:  "GoSemanticConstant",
+	Builtin:   "GoSemanticBuiltin",
+	Shadowed:  "GoSemanticShadowed",
+	Unused:    "GoSemanticUnused",
+}
+
+// Group returns the highlight group name of the kind.
+func (k Kind) Group() string { return groups[k] }
+
+// Highlight represents the highlight of an identifier.
+// Line is the 0-based line number, and StartCol and EndCol are the 0-based byte columns.
+type Highlight struct {
+	Line     int
+	StartCol int
+	EndCol   int
+	Kind     Kind
+}
+
+// Highlights returns the highlights of the identifiers in the file in source order.
+// The identifiers which have no semantic role, such as the functions and the local variables, are not highlighted.
+func Highlights(fset *token.FileSet, file *ast.File, info *types.Info) []Highlight {
+	params := make(map[types.Object]bool)
+	used := make(map[types.Object]bool)
+	for _, obj := range info.Uses {
+		used[obj] = true
+	}
+
+	var hls []Highlight
+	add := func(node ast.Node, kind Kind) {
+		pos, end := fset.Position(node.Pos()), fset.Position(node.End())
+		hls = append(hls, Highlight{Line: pos.Line - 1, StartCol: pos.Column - 1, EndCol: end.Column - 1, Kind: kind})
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.File:
+			add(n.Name, Package)
+		case *ast.ImportSpec:
+			if n.Name == nil {
+				// the unnamed import has no identifier, so highlights the import path without the quotes
+				if obj, ok := info.Implicits[n]; ok && !used[obj] {
+					pos := fset.Position(n.Path.Pos())
+					hls = append(hls, Highlight{Line: pos.Line - 1, StartCol: pos.Column, EndCol: pos.Column - 1 + len(n.Path.Value) - 1, Kind: Unused})
+				}
+			}
+		case *ast.FuncDecl:
+			for _, fl := range []*ast.FieldList{n.Recv, n.Type.Params, n.Type.Results} {
+				markParams(info, fl, params)
+			}
+		case *ast.FuncLit:
+			markParams(info, n.Type.Params, params)
+			markParams(info, n.Type.Results, params)
+		case *ast.Ident:
+			if n.Name == "_" {
+				return false
+			}
+			_, def := info.Defs[n]
+			obj := info.ObjectOf(n)
+			if obj == nil {
+				return false // the package name, or the symbolic variable of the type switch
+			}
+			if kind, ok := classify(obj, def, used, params); ok {
+				add(n, kind)
+			}
+		}
+		return true
+	})
+
+	sort.SliceStable(hls, func(i, j int) bool {
+		if hls[i].Line != hls[j].Line {
+			return hls[i].Line < hls[j].Line
+		}
+		return hls[i].StartCol < hls[j].StartCol
+	})
+	return hls
+}
+
+// markParams marks the objects of the parameters in the field list.
+func markParams(info *types.Info, fl *ast.FieldList, params map[types.Object]bool) {
+	if fl == nil {
+		return
+	}
+	for _, field := range fl.List {
+		for _, name := range field.Names {
+			if obj := info.Defs[name]; obj != nil {
+				params[obj] = true
+			}
+		}
+	}
+}
+
+// classify returns the semantic role of obj. def reports whether the identifier is the definition of obj.
+func classify(obj types.Object, def bool, used, params map[types.Object]bool) (Kind, bool) {
+	universe := obj.Parent() == types.Universe
+
+	switch obj := obj.(type) {
+	case *types.PkgName:
+		if def && !used[obj] {
+			return Unused, true
+		}
+		return Package, true
+	case *types.TypeName:
+		if universe {
+			return Builtin, true
+		}
+		if types.IsInterface(obj.Type()) {
+			return Interface, true
+		}
+		return Type, true
+	case *types.Builtin, *types.Nil:
+		return Builtin, true
+	case *types.Const:
+		if universe {
+			return Builtin, true
+		}
+		return Constant, true
+	case *types.Func:
+		if sig, ok := obj.Type().(*types.Signature); ok && sig.Recv() != nil {
+			return Method, true
+		}
+	case *types.Var:
+		switch {
+		case obj.IsField():
+			return Field, true
+		case params[obj]:
+			return Parameter, true
+		case isLocal(obj) && def && !used[obj]:
+			return Unused, true
+		case isShadowing(obj):
+			return Shadowed, true
+		}
+	}
+	return 0, false
+}
+
+// isLocal reports whether v is the local variable.
+func isLocal(v *types.Var) bool {
+	return v.Parent() != nil && v.Pkg() != nil && v.Parent() != v.Pkg().Scope()
+}
+
+// isShadowing reports whether the local variable v shadows the variable in the outer scope.
+func isShadowing(v *types.Var) bool {
+	if !isLocal(v) || v.Parent().Parent() == nil {
+		return false
+	}
+	_, outer := v.Parent().Parent().LookupParent(v.Name(), v.Pos())
+	_, ok := outer.(*types.Var)
+	return ok
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package highlight
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const src = `package p
+
+import (
+	"io"
+	"os"
+)
+
+const Max = 10
+
+var level int
+
+type T struct {
+	n int
+}
+
+func (t *T) Read(p []byte) (int, error) {
+	var r io.Reader = t
+	level := len(p)
+	if level > Max {
+		return 0, nil
+	}
+	unused := t.n
+	return copy(p, nil), io.EOF
+}
+
+func f() { new(T).Read(nil) }
+`
+
+func TestHighlights(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Defs:      make(map[*ast.Ident]types.Object),
+		Uses:      make(map[*ast.Ident]types.Object),
+		Implicits: make(map[ast.Node]types.Object),
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil), Error: func(error) {}}
+	conf.Check("p", fset, []*ast.File{file}, info) // ignore the errors of the unused variables and import
+
+	lines := strings.Split(src, "\n")
+	var got []string
+	for _, hl := range Highlights(fset, file, info) {
+		got = append(got, fmt.Sprintf("%d:%s:%s", hl.Line+1, lines[hl.Line][hl.StartCol:hl.EndCol], hl.Kind.Group()))
+	}
+
+	want := []string{
+		"1:p:GoSemanticPackage",
+		"5:os:GoSemanticUnused",
+		"8:Max:GoSemanticConstant",
+		"10:int:GoSemanticBuiltin",
+		"12:T:GoSemanticType",
+		"13:n:GoSemanticField",
+		"13:int:GoSemanticBuiltin",
+		"16:t:GoSemanticParameter",
+		"16:T:GoSemanticType",
+		"16:Read:GoSemanticMethod",
+		"16:p:GoSemanticParameter",
+		"16:byte:GoSemanticBuiltin",
+		"16:int:GoSemanticBuiltin",
+		"16:error:GoSemanticBuiltin",
+		"17:r:GoSemanticUnused",
+		"17:io:GoSemanticPackage",
+		"17:Reader:GoSemanticInterface",
+		"17:t:GoSemanticParameter",
+		"18:level:GoSemanticShadowed",
+		"18:len:GoSemanticBuiltin",
+		"18:p:GoSemanticParameter",
+		"19:level:GoSemanticShadowed",
+		"19:Max:GoSemanticConstant",
+		"20:nil:GoSemanticBuiltin",
+		"22:unused:GoSemanticUnused",
+		"22:t:GoSemanticParameter",
+		"22:n:GoSemanticField",
+		"23:copy:GoSemanticBuiltin",
+		"23:p:GoSemanticParameter",
+		"23:nil:GoSemanticBuiltin",
+		"23:io:GoSemanticPackage",
+		"26:new:GoSemanticBuiltin",
+		"26:T:GoSemanticType",
+		"26:Read:GoSemanticMethod",
+		"26:nil:GoSemanticBuiltin",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,686 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pkgindex indexes the exported symbols of the Go packages in GOROOT, GOPATH and the module cache.
+// The index is saved on disk, and refreshed incrementally by re-reading only the changed package directories.
+package pkgindex
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/zchee/nvim-go/pkg/internal/gopathwalk"
+)
+
+// version is the version of the index file format, which discards the index file of the other version.
+const version = 1
+
+// Kind represents the kind of the exported symbol.
+type Kind int
+
+// list of the kinds of the exported symbol.
+const (
+	Func Kind = iota
+	Type
+	Var
+	Const
+)
+
+// Symbol represents an exported package-level declaration.
+type Symbol struct {
+	Name   string
+	Kind   Kind
+	Detail string // the signature of the function, or the type of the declaration
+}
+
+// Package represents an indexed package.
+type Package struct {
+	ImportPath string
+	Name       string
+	Dir        string
+	Version    string // the module version if the package is in the module cache
+	Std        bool   // the package of the standard library
+
+	// ModTime and Files are the latest modification time in nanoseconds and the number of the Go files in Dir,
+	// which invalidate the Symbols.
+	ModTime int64
+	Files   int
+
+	Symbols []Symbol
+}
+
+// Index represents the exported symbols index of the packages.
+type Index struct {
+	file string // the index file
+
+	mu      sync.RWMutex
+	dirs    map[string]*Package   // the packages keyed by the directory
+	paths   map[string]*Package   // the newest version of the packages keyed by the import path
+	exports map[string][]*Package // the packages which export the symbol, keyed by the symbol name
+
+	refreshMu sync.Mutex // serializes Refresh
+}
+
+// Root represents a root directory of the packages.
+type Root struct {
+	gopathwalk.Root
+
+	// ImportPath is the import path of the root directory, which is the module path for the root of a Go module.
+	// ImportPath is empty for GOROOT, GOPATH and the module cache, whose import paths are relative to the root.
+	ImportPath string
+}
+
+// indexFile represents the format of the index file.
+type indexFile struct {
+	Version  int
+	Packages []*Package
+}
+
+// New returns the new Index which is saved to file.
+func New(file string) *Index {
+	return &Index{
+		file:    file,
+		dirs:    make(map[string]*Package),
+		paths:   make(map[string]*Package),
+		exports: make(map[string][]*Package),
+	}
+}
+
+// DefaultFile returns the index file under $XDG_CACHE_HOME/nvim-go.
+func DefaultFile() string {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		home, _ := os.UserHomeDir()
+		cacheHome = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(cacheHome, "nvim-go", "pkgindex.json")
+}
+
+// DefaultRoots returns the roots of GOROOT, GOPATH and the module cache.
+func DefaultRoots() []Root {
+	var roots []Root
+	for _, root := range gopathwalk.SrcDirsRoots(&build.Default) {
+		roots = append(roots, Root{Root: root})
+	}
+
+	modCache := os.Getenv("GOMODCACHE")
+	if modCache == "" {
+		if gopaths := filepath.SplitList(build.Default.GOPATH); len(gopaths) > 0 {
+			modCache = filepath.Join(gopaths[0], "pkg", "mod")
+		}
+	}
+	if modCache != "" {
+		roots = append(roots, Root{Root: gopathwalk.Root{Path: modCache, Type: gopathwalk.RootModuleCache}})
+	}
+
+	return roots
+}
+
+// Load loads the index file. Load does nothing if the index file does not exist or has the other version.
+func (ix *Index) Load() error {
+	data, err := ioutil.ReadFile(ix.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+	var f indexFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return errors.Wrapf(err, "invalid index file %s", ix.file)
+	}
+	if f.Version != version {
+		return nil
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for _, pkg := range f.Packages {
+		ix.dirs[pkg.Dir] = pkg
+	}
+	ix.rebuild()
+
+	return nil
+}
+
+// Save saves the index to the index file atomically.
+func (ix *Index) Save() error {
+	ix.mu.RLock()
+	f := indexFile{Version: version, Packages: make([]*Package, 0, len(ix.dirs))}
+	for _, pkg := range ix.dirs {
+		f.Packages = append(f.Packages, pkg)
+	}
+	ix.mu.RUnlock()
+	sort.Slice(f.Packages, func(i, j int) bool { return f.Packages[i].Dir < f.Packages[j].Dir })
+
+	data, err := json.Marshal(&f)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.file), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	// write to the unique temporary file, because the other processes might save the same index file concurrently
+	tmp, err := ioutil.TempFile(filepath.Dir(ix.file), filepath.Base(ix.file)+".*.tmp")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return errors.WithStack(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return errors.WithStack(err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return errors.WithStack(err)
+	}
+	if err := os.Rename(tmp.Name(), ix.file); err != nil {
+		os.Remove(tmp.Name())
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Walk calls fn for each package directory under the roots, which is called concurrently.
+// The vendor directories, and the nested Go modules under the roots of the Go modules are skipped.
+func Walk(roots []Root, fn func(root Root, dir string)) {
+	groots := make([]gopathwalk.Root, len(roots))
+	byPath := make(map[string]Root, len(roots))
+	for i, root := range roots {
+		groots[i] = root.Root
+		byPath[root.Path] = root
+	}
+
+	gopathwalk.Walk(groots, func(groot gopathwalk.Root, dir string) {
+		root := byPath[groot.Path]
+		rel, err := filepath.Rel(root.Path, dir)
+		if err != nil || strings.Contains("/"+filepath.ToSlash(rel)+"/", "/vendor/") {
+			return
+		}
+		if root.ImportPath != "" && inNestedModule(root.Path, dir) {
+			return
+		}
+		fn(root, dir)
+	}, gopathwalk.Options{ModulesEnabled: true})
+}
+
+// inNestedModule reports whether dir is in the other Go module which is nested under the module root.
+func inNestedModule(root, dir string) bool {
+	for ; dir != root && len(dir) > len(root); dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Refresh walks the roots, and re-reads the package directories which are added or changed since the last refresh.
+// The packages under the roots which no longer exist are removed. Refresh saves the index if changed.
+func (ix *Index) Refresh(roots []Root) error {
+	ix.refreshMu.Lock()
+	defer ix.refreshMu.Unlock()
+
+	type found struct {
+		root Root
+		dir  string
+	}
+	var mu sync.Mutex
+	var dirs []found
+	Walk(roots, func(root Root, dir string) {
+		mu.Lock()
+		dirs = append(dirs, found{root: root, dir: dir})
+		mu.Unlock()
+	})
+
+	var changed bool
+	var wg sync.WaitGroup
+	sema := make(chan struct{}, runtime.NumCPU()) // counting semaphore to limit the parsing concurrency
+	seen := make(map[string]bool, len(dirs))
+	for _, d := range dirs {
+		seen[d.dir] = true
+		importPath, ver, ok := importPath(d.root, d.dir)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		sema <- struct{}{}
+		go func(root Root, dir string) {
+			defer func() { <-sema; wg.Done() }()
+			if ok, err := ix.update(importPath, ver, dir, root.Type == gopathwalk.RootGOROOT); err == nil && ok {
+				mu.Lock()
+				changed = true
+				mu.Unlock()
+			}
+		}(d.root, d.dir)
+	}
+	wg.Wait()
+
+	ix.mu.Lock()
+	for dir := range ix.dirs {
+		if seen[dir] {
+			continue
+		}
+		if !underRoots(roots, dir) {
+			if _, err := os.Stat(dir); err == nil {
+				continue // the package which is found by Lookup
+			}
+		}
+		delete(ix.dirs, dir)
+		changed = true
+	}
+	if changed {
+		ix.rebuild()
+	}
+	ix.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+	return ix.Save()
+}
+
+// Lookup returns the package in dir of importPath, and reads it if not indexed or changed since indexed.
+// Lookup is used for the packages which are not under the roots of Refresh, such as the packages of the current module.
+func (ix *Index) Lookup(importPath, dir string) (*Package, error) {
+	ver := moduleVersion(dir)
+	std := strings.HasPrefix(dir, filepath.Join(build.Default.GOROOT, "src")+string(filepath.Separator))
+
+	changed, err := ix.update(importPath, ver, dir, std)
+	if err != nil {
+		return nil, err
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if changed {
+		ix.rebuild()
+	}
+	pkg, ok := ix.dirs[dir]
+	if !ok {
+		return nil, errors.Errorf("no Go package in %s", dir)
+	}
+	return pkg, nil
+}
+
+// moduleVersion returns the module version of the package directory dir if it is in the module cache.
+func moduleVersion(dir string) string {
+	if i := strings.Index(dir, "@"); i >= 0 {
+		return strings.SplitN(dir[i+1:], string(filepath.Separator), 2)[0]
+	}
+	return ""
+}
+
+// Under returns the indexed packages in dir and its subdirectories sorted by the import path,
+// without checking the changes.
+func (ix *Index) Under(dir string) []*Package {
+	ix.mu.RLock()
+	var pkgs []*Package
+	for _, pkg := range ix.dirs {
+		if pkg.Dir == dir || strings.HasPrefix(pkg.Dir, dir+string(filepath.Separator)) {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	ix.mu.RUnlock()
+
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].ImportPath < pkgs[j].ImportPath })
+	return pkgs
+}
+
+// Dir returns the indexed package in dir without checking the changes.
+func (ix *Index) Dir(dir string) (*Package, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	pkg, ok := ix.dirs[dir]
+	return pkg, ok
+}
+
+// Packages returns the newest version of the indexed packages sorted by the import path.
+func (ix *Index) Packages() []*Package {
+	ix.mu.RLock()
+	pkgs := make([]*Package, 0, len(ix.paths))
+	for _, pkg := range ix.paths {
+		pkgs = append(pkgs, pkg)
+	}
+	ix.mu.RUnlock()
+
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].ImportPath < pkgs[j].ImportPath })
+	return pkgs
+}
+
+// Exports returns the packages which export the symbol name. The packages of the standard library come first,
+// and the others are sorted by the length of the import path.
+func (ix *Index) Exports(name string) []*Package {
+	ix.mu.RLock()
+	pkgs := append([]*Package(nil), ix.exports[name]...)
+	ix.mu.RUnlock()
+
+	SortPackages(pkgs)
+	return pkgs
+}
+
+// SortPackages sorts pkgs in the preferred order of the import candidates, which is the standard library first,
+// and then the shorter import path.
+func SortPackages(pkgs []*Package) {
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		if pkgs[i].Std != pkgs[j].Std {
+			return pkgs[i].Std
+		}
+		if len(pkgs[i].ImportPath) != len(pkgs[j].ImportPath) {
+			return len(pkgs[i].ImportPath) < len(pkgs[j].ImportPath)
+		}
+		return pkgs[i].ImportPath < pkgs[j].ImportPath
+	})
+}
+
+// Symbol returns the exported symbol name of pkg.
+func (pkg *Package) Symbol(name string) (Symbol, bool) {
+	i := sort.Search(len(pkg.Symbols), func(i int) bool { return pkg.Symbols[i].Name >= name })
+	if i < len(pkg.Symbols) && pkg.Symbols[i].Name == name {
+		return pkg.Symbols[i], true
+	}
+	return Symbol{}, false
+}
+
+// update re-reads the package in dir if not indexed or changed, and reports whether the index is changed.
+// update does not rebuild the lookup maps, so the caller must call rebuild if changed.
+func (ix *Index) update(importPath, ver, dir string, std bool) (bool, error) {
+	modTime, files, err := stat(dir)
+	if err != nil {
+		return false, err
+	}
+
+	ix.mu.RLock()
+	old, ok := ix.dirs[dir]
+	ix.mu.RUnlock()
+	if ok && old.ModTime == modTime && old.Files == files && old.ImportPath == importPath {
+		return false, nil
+	}
+
+	pkg, err := read(importPath, dir)
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if err != nil {
+		if ok {
+			delete(ix.dirs, dir) // no longer the Go package such as all files are removed
+			return true, nil
+		}
+		return false, err
+	}
+	pkg.Version, pkg.Std, pkg.ModTime, pkg.Files = ver, std, modTime, files
+	ix.dirs[dir] = pkg
+
+	return true, nil
+}
+
+// rebuild rebuilds the lookup maps from the packages. ix.mu must be locked.
+func (ix *Index) rebuild() {
+	ix.paths = make(map[string]*Package, len(ix.dirs))
+	for _, pkg := range ix.dirs {
+		if old, ok := ix.paths[pkg.ImportPath]; !ok || newer(pkg.Version, old.Version) {
+			ix.paths[pkg.ImportPath] = pkg
+		}
+	}
+
+	ix.exports = make(map[string][]*Package)
+	for _, pkg := range ix.paths {
+		for _, sym := range pkg.Symbols {
+			ix.exports[sym.Name] = append(ix.exports[sym.Name], pkg)
+		}
+	}
+}
+
+// underRoots reports whether dir is under one of the roots.
+func underRoots(roots []Root, dir string) bool {
+	for _, root := range roots {
+		if strings.HasPrefix(dir, root.Path+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// stat returns the latest modification time and the number of the Go files in dir.
+func stat(dir string) (modTime int64, files int, err error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+	for _, fi := range fis {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".go") {
+			continue
+		}
+		files++
+		if t := fi.ModTime().UnixNano(); t > modTime {
+			modTime = t
+		}
+	}
+	return modTime, files, nil
+}
+
+// read reads the package name and the exported symbols of the package in dir.
+// The test files and the files which do not match the build constraints are ignored.
+func read(importPath, dir string) (*Package, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	pkg := &Package{ImportPath: importPath, Dir: dir}
+	fset := token.NewFileSet()
+	for _, fi := range fis {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			continue
+		}
+		if pkg.Name == "" {
+			pkg.Name = f.Name.Name
+		}
+		if f.Name.Name != pkg.Name {
+			continue
+		}
+		pkg.Symbols = append(pkg.Symbols, symbols(fset, f)...)
+	}
+	if pkg.Name == "" || pkg.Name == "main" || pkg.Name == "documentation" {
+		return nil, errors.Errorf("no importable Go package in %s", dir)
+	}
+	sort.Slice(pkg.Symbols, func(i, j int) bool { return pkg.Symbols[i].Name < pkg.Symbols[j].Name })
+
+	return pkg, nil
+}
+
+// symbols returns the exported package-level declarations of f.
+func symbols(fset *token.FileSet, f *ast.File) []Symbol {
+	var syms []Symbol
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil && decl.Name.IsExported() {
+				syms = append(syms, Symbol{Name: decl.Name.Name, Kind: Func, Detail: nodeString(fset, decl.Type)})
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.IsExported() {
+						syms = append(syms, Symbol{Name: spec.Name.Name, Kind: Type, Detail: typeDetail(fset, spec.Type)})
+					}
+				case *ast.ValueSpec:
+					kind := Var
+					if decl.Tok == token.CONST {
+						kind = Const
+					}
+					var detail string
+					if spec.Type != nil {
+						detail = nodeString(fset, spec.Type)
+					}
+					for _, name := range spec.Names {
+						if name.IsExported() {
+							syms = append(syms, Symbol{Name: name.Name, Kind: kind, Detail: detail})
+						}
+					}
+				}
+			}
+		}
+	}
+	return syms
+}
+
+// typeDetail returns the detail of the type declaration, which omits the fields and the methods.
+func typeDetail(fset *token.FileSet, typ ast.Expr) string {
+	switch typ.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	}
+	return nodeString(fset, typ)
+}
+
+// nodeString returns the source of node.
+func nodeString(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// importPath returns the import path and the module version of the package directory dir under the root.
+func importPath(root Root, dir string) (importPath, ver string, ok bool) {
+	rel, err := filepath.Rel(root.Path, dir)
+	if err != nil {
+		return "", "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if root.ImportPath != "" {
+		// the internal packages of the Go module are importable from the same module
+		if strings.Contains("/"+rel+"/", "/vendor/") {
+			return "", "", false
+		}
+		return path.Join(root.ImportPath, rel), moduleVersion(dir), true
+	}
+	if rel == "." {
+		return "", "", false
+	}
+	if strings.Contains("/"+rel+"/", "/vendor/") || (strings.Contains("/"+rel+"/", "/internal/") && root.Type != gopathwalk.RootModuleCache) {
+		// the vendored packages and the internal packages of the standard library and GOPATH are not importable
+		// except from the same tree, which are found by Lookup
+		return "", "", false
+	}
+	if root.Type == gopathwalk.RootGOROOT && (rel == "cmd" || strings.HasPrefix(rel, "cmd/")) {
+		return "", "", false
+	}
+	if root.Type != gopathwalk.RootModuleCache {
+		return rel, "", true
+	}
+
+	// the module cache directory is "<escaped module path>@<version>/<package path>"
+	i := strings.Index(rel, "@")
+	if i < 0 {
+		return "", "", false
+	}
+	modPath, ver := rel[:i], rel[i+1:]
+	pkgPath := ""
+	if j := strings.Index(ver, "/"); j >= 0 {
+		ver, pkgPath = ver[:j], ver[j+1:]
+	}
+	modPath, ok = unescapePath(modPath)
+	if !ok {
+		return "", "", false
+	}
+	return path.Join(modPath, pkgPath), ver, true
+}
+
+// unescapePath decodes the escaped path of the module cache, such as "github.com/!burnt!sushi/toml".
+func unescapePath(escaped string) (string, bool) {
+	var buf strings.Builder
+	bang := false
+	for _, r := range escaped {
+		switch {
+		case bang:
+			if r < 'a' || 'z' < r {
+				return "", false
+			}
+			buf.WriteRune(unicode.ToUpper(r))
+			bang = false
+		case r == '!':
+			bang = true
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String(), !bang
+}
+
+// newer reports whether the semantic version a is newer than b. The empty version is the oldest.
+func newer(a, b string) bool {
+	if a == "" || b == "" {
+		return b == "" && a != ""
+	}
+	pa, pb := splitVersion(a), splitVersion(b)
+	for i := 0; i < 3; i++ {
+		if pa.nums[i] != pb.nums[i] {
+			return pa.nums[i] > pb.nums[i]
+		}
+	}
+	switch {
+	case pa.pre == pb.pre:
+		return false
+	case pa.pre == "":
+		return true // the release is newer than the pre-release
+	case pb.pre == "":
+		return false
+	}
+	return pa.pre > pb.pre
+}
+
+// semver represents the parsed semantic version.
+type semver struct {
+	nums [3]int
+	pre  string
+}
+
+// splitVersion parses the semantic version such as "v1.2.3-pre+meta". The invalid numbers are parsed as 0.
+func splitVersion(v string) semver {
+	var sv semver
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.Index(v, "+"); i >= 0 {
+		v = v[:i]
+	}
+	if i := strings.Index(v, "-"); i >= 0 {
+		v, sv.pre = v[:i], v[i+1:]
+	}
+	for i, n := range strings.SplitN(v, ".", 3) {
+		sv.nums[i], _ = strconv.Atoi(n)
+	}
+	return sv
+}
@@ -0,0 +1,215 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkgindex
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/zchee/nvim-go/pkg/internal/gopathwalk"
+	"github.com/zchee/nvim-go/pkg/internal/testutil"
+)
+
+func importPaths(pkgs []*Package) []string {
+	var paths []string
+	for _, pkg := range pkgs {
+		paths = append(paths, pkg.ImportPath)
+	}
+	return paths
+}
+
+func TestRefresh(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pkgindex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	testutil.WriteFile(t, filepath.Join(src, "example.com/a/a.go"), "package a\n\nfunc Parse(s string) error { return nil }\n\nfunc unexported() {}\n")
+	testutil.WriteFile(t, filepath.Join(src, "example.com/a/a_test.go"), "package a\n\nfunc TestOnly() {}\n")
+	testutil.WriteFile(t, filepath.Join(src, "example.com/b/b.go"), "package b\n\ntype Parser struct{}\n\nconst Parse = 1\n")
+	testutil.WriteFile(t, filepath.Join(src, "example.com/cmd/main.go"), "package main\n\nfunc Parse() {}\n")
+	testutil.WriteFile(t, filepath.Join(src, "example.com/a/internal/x/x.go"), "package x\n\nfunc Parse() {}\n")
+	roots := []Root{{Root: gopathwalk.Root{Path: src, Type: gopathwalk.RootGOPATH}}}
+
+	file := filepath.Join(dir, "pkgindex.json")
+	ix := New(file)
+	if err := ix.Refresh(roots); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"example.com/a", "example.com/b"}, importPaths(ix.Exports("Parse"))); diff != "" {
+		t.Errorf("Exports(Parse): (-want +got)\n%s", diff)
+	}
+	if got := ix.Exports("TestOnly"); len(got) != 0 {
+		t.Errorf("Exports(TestOnly) = %v, want the test files ignored", importPaths(got))
+	}
+	a, ok := ix.Dir(filepath.Join(src, "example.com/a"))
+	if !ok {
+		t.Fatal("example.com/a is not indexed")
+	}
+	want := []Symbol{{Name: "Parse", Kind: Func, Detail: "func(s string) error"}}
+	if diff := cmp.Diff(want, a.Symbols); diff != "" {
+		t.Errorf("Symbols: (-want +got)\n%s", diff)
+	}
+
+	// the added file and the removed package are found by the next refresh
+	testutil.WriteFile(t, filepath.Join(src, "example.com/a/format.go"), "package a\n\nfunc Format() string { return \"\" }\n")
+	if err := os.RemoveAll(filepath.Join(src, "example.com/b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Refresh(roots); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"example.com/a"}, importPaths(ix.Exports("Format"))); diff != "" {
+		t.Errorf("Exports(Format): (-want +got)\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"example.com/a"}, importPaths(ix.Packages())); diff != "" {
+		t.Errorf("Packages: (-want +got)\n%s", diff)
+	}
+
+	// the saved index is loaded by the other index
+	loaded := New(file)
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(ix.Packages(), loaded.Packages()); diff != "" {
+		t.Errorf("Load: (-want +got)\n%s", diff)
+	}
+}
+
+func TestRefresh_module(t *testing.T) {
+	root := testutil.TempModule(t, "example.com/foo", map[string]string{
+		"foo.go":                        "package foo\n\ntype Reader interface{ Read() }\n",
+		"internal/bar/bar.go":           "package bar\n\nfunc Bar() {}\n",
+		"cmd/foo/main.go":               "package main\n\nfunc main() {}\n",
+		"vendor/example.com/baz/baz.go": "package baz\n\nfunc Baz() {}\n",
+		"nested/go.mod":                 "module example.com/nested\n",
+		"nested/nested.go":              "package nested\n\nfunc Nested() {}\n",
+	})
+	roots := []Root{{Root: gopathwalk.Root{Path: root, Type: gopathwalk.RootCurrentModule}, ImportPath: "example.com/foo"}}
+
+	ix := New(filepath.Join(t.TempDir(), "pkgindex.json"))
+	if err := ix.Refresh(roots); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"example.com/foo", "example.com/foo/internal/bar"}, importPaths(ix.Under(root))); diff != "" {
+		t.Errorf("Under: (-want +got)\n%s", diff)
+	}
+
+	// the removed package of the module
+	if err := os.RemoveAll(filepath.Join(root, "internal")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Refresh(roots); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"example.com/foo"}, importPaths(ix.Under(root))); diff != "" {
+		t.Errorf("Under after removing: (-want +got)\n%s", diff)
+	}
+}
+
+func TestSaveConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	testutil.WriteFile(t, filepath.Join(src, "example.com/a/a.go"), "package a\n\nfunc Parse(s string) error { return nil }\n")
+	roots := []Root{{Root: gopathwalk.Root{Path: src, Type: gopathwalk.RootGOPATH}}}
+
+	// the indexes of the other processes save the same index file
+	file := filepath.Join(dir, "pkgindex.json")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		ix := New(file)
+		if err := ix.Refresh(roots); err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if err := ix.Save(); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	loaded := New(file)
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"example.com/a"}, importPaths(loaded.Packages())); diff != "" {
+		t.Errorf("Load: (-want +got)\n%s", diff)
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmps) > 0 {
+		t.Errorf("Save left the temporary files: %v", tmps)
+	}
+}
+
+func TestImportPath(t *testing.T) {
+	goroot := Root{Root: gopathwalk.Root{Path: "/goroot/src", Type: gopathwalk.RootGOROOT}}
+	gopath := Root{Root: gopathwalk.Root{Path: "/gopath/src", Type: gopathwalk.RootGOPATH}}
+	modcache := Root{Root: gopathwalk.Root{Path: "/gopath/pkg/mod", Type: gopathwalk.RootModuleCache}}
+	module := Root{Root: gopathwalk.Root{Path: "/home/foo", Type: gopathwalk.RootCurrentModule}, ImportPath: "example.com/foo"}
+	dep := Root{Root: gopathwalk.Root{Path: "/gopath/pkg/mod/example.com/bar@v1.2.0", Type: gopathwalk.RootModuleCache}, ImportPath: "example.com/bar"}
+
+	tests := []struct {
+		name       string
+		root       Root
+		dir        string
+		importPath string
+		ver        string
+		ok         bool
+	}{
+		{name: "Std", root: goroot, dir: "/goroot/src/encoding/json", importPath: "encoding/json", ok: true},
+		{name: "StdInternal", root: goroot, dir: "/goroot/src/internal/poll"},
+		{name: "Cmd", root: goroot, dir: "/goroot/src/cmd/go"},
+		{name: "GOPATH", root: gopath, dir: "/gopath/src/example.com/a", importPath: "example.com/a", ok: true},
+		{name: "Vendor", root: gopath, dir: "/gopath/src/example.com/a/vendor/example.com/b"},
+		{name: "ModuleCache", root: modcache, dir: "/gopath/pkg/mod/github.com/!burnt!sushi/toml@v0.3.1", importPath: "github.com/BurntSushi/toml", ver: "v0.3.1", ok: true},
+		{name: "ModuleCachePackage", root: modcache, dir: "/gopath/pkg/mod/golang.org/x/tools@v0.1.0/go/packages", importPath: "golang.org/x/tools/go/packages", ver: "v0.1.0", ok: true},
+		{name: "ModuleCacheInternal", root: modcache, dir: "/gopath/pkg/mod/golang.org/x/tools@v0.1.0/internal/imports", importPath: "golang.org/x/tools/internal/imports", ver: "v0.1.0", ok: true},
+		{name: "Module", root: module, dir: "/home/foo", importPath: "example.com/foo", ok: true},
+		{name: "ModuleInternal", root: module, dir: "/home/foo/internal/baz", importPath: "example.com/foo/internal/baz", ok: true},
+		{name: "ModuleVendor", root: module, dir: "/home/foo/vendor/example.com/bar"},
+		{name: "Dependency", root: dep, dir: "/gopath/pkg/mod/example.com/bar@v1.2.0/baz", importPath: "example.com/bar/baz", ver: "v1.2.0", ok: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			importPath, ver, ok := importPath(tt.root, filepath.FromSlash(tt.dir))
+			if importPath != tt.importPath || ver != tt.ver || ok != tt.ok {
+				t.Errorf("%s: got (%q, %q, %t), want (%q, %q, %t)", tt.name, importPath, ver, ok, tt.importPath, tt.ver, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "v1.2.0", b: "v1.10.0", want: false},
+		{a: "v1.10.0", b: "v1.2.0", want: true},
+		{a: "v1.0.0", b: "v1.0.0-rc.1", want: true},
+		{a: "v0.0.0-20200101000000-abcdef012345", b: "v0.1.0", want: false},
+		{a: "v1.0.0", b: "", want: true},
+		{a: "", b: "v1.0.0", want: false},
+	}
+	for _, tt := range tests {
+		if got := newer(tt.a, tt.b); got != tt.want {
+			t.Errorf("newer(%q, %q) = %t, want %t", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
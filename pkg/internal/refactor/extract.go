@@ -0,0 +1,407 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package refactor
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// ExtractFunc extracts the statements in the byte range [start, end) to the new function name,
+// and replaces the statements with the call of the function.
+//
+// The free variables of the statements become the parameters, and the variables which are
+// defined or modified by the statements and used after them become the results.
+func ExtractFunc(f *File, start, end int, name string) ([]byte, error) {
+	start, end = f.trim(start, end)
+	if start >= end {
+		return nil, errors.New("empty selection")
+	}
+	spos, epos := f.pos(start), f.pos(end)
+
+	path, _ := astutil.PathEnclosingInterval(f.File, spos, epos)
+	fd := enclosingFunc(path)
+	if fd == nil || fd.Body == nil {
+		return nil, errors.New("selection is not in a function")
+	}
+	stmts, err := selectStmts(path, spos, epos)
+	if err != nil {
+		return nil, err
+	}
+	first, last := stmts[0], stmts[len(stmts)-1]
+
+	if err := f.checkName(name, f.Info.Scopes[f.File], token.NoPos); err != nil {
+		return nil, err
+	}
+	if err := checkExtractable(stmts); err != nil {
+		return nil, err
+	}
+
+	inSel := func(pos token.Pos) bool { return first.Pos() <= pos && pos < last.End() }
+	isLocal := func(v *types.Var) bool { return fd.Pos() <= v.Pos() && v.Pos() < fd.End() }
+
+	// the free variables of the statements
+	var params []*types.Var
+	seen := make(map[*types.Var]bool)
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				if v, ok := f.Info.Uses[id].(*types.Var); ok && !v.IsField() && isLocal(v) && !inSel(v.Pos()) && !seen[v] {
+					seen[v] = true
+					params = append(params, v)
+				}
+			}
+			return true
+		})
+	}
+	modified := modifiedVars(f.Info, stmts...)
+
+	// the variables which are used after the statements
+	usedAfter := make(map[*types.Var]bool)
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Pos() >= last.End() {
+			if v, ok := f.Info.Uses[id].(*types.Var); ok {
+				usedAfter[v] = true
+			}
+		}
+		return true
+	})
+
+	// the results: the variables defined by the top-level statements and the modified parameters
+	var defined, results []*types.Var
+	for _, stmt := range stmts {
+		for _, v := range definedVars(f.Info, stmt) {
+			if usedAfter[v] {
+				defined = append(defined, v)
+				results = append(results, v)
+			}
+		}
+	}
+	for _, v := range params {
+		if modified[v] && usedAfter[v] {
+			results = append(results, v)
+		}
+	}
+
+	// the new function
+	var sig strings.Builder
+	fmt.Fprintf(&sig, "func %s(", name)
+	args := make([]string, len(params))
+	for i, v := range params {
+		if i > 0 {
+			sig.WriteString(", ")
+		}
+		fmt.Fprintf(&sig, "%s %s", v.Name(), f.typeString(v.Type()))
+		args[i] = v.Name()
+	}
+	sig.WriteString(")")
+	names := make([]string, len(results))
+	for i, v := range results {
+		names[i] = v.Name()
+	}
+	switch len(results) {
+	case 0:
+	case 1:
+		fmt.Fprintf(&sig, " %s", f.typeString(results[0].Type()))
+	default:
+		rtypes := make([]string, len(results))
+		for i, v := range results {
+			rtypes[i] = f.typeString(v.Type())
+		}
+		fmt.Fprintf(&sig, " (%s)", strings.Join(rtypes, ", "))
+	}
+
+	body := string(f.Src[f.offset(first.Pos()):f.offset(last.End())])
+	if len(results) > 0 {
+		body += "\nreturn " + strings.Join(names, ", ")
+	}
+	fn := fmt.Sprintf("\n\n%s {\n%s\n}", sig.String(), body)
+
+	// the call of the new function
+	call := fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+	switch {
+	case len(results) == 0:
+	case len(defined) == len(results):
+		call = strings.Join(names, ", ") + " := " + call
+	default:
+		// declare the defined variables, since the modified variables are assigned
+		indent := f.indent(first.Pos())
+		var decls strings.Builder
+		for _, v := range defined {
+			fmt.Fprintf(&decls, "var %s %s\n%s", v.Name(), f.typeString(v.Type()), indent)
+		}
+		call = decls.String() + strings.Join(names, ", ") + " = " + call
+	}
+
+	return f.apply(
+		edit{start: f.offset(first.Pos()), end: f.offset(last.End()), new: call},
+		edit{start: f.offset(fd.End()), end: f.offset(fd.End()), new: fn},
+	)
+}
+
+// selectStmts returns the statements of the innermost statement list which are in [start, end).
+func selectStmts(path []ast.Node, start, end token.Pos) ([]ast.Stmt, error) {
+	for _, n := range path {
+		list, ok := stmtList(n)
+		if !ok {
+			continue
+		}
+		var stmts []ast.Stmt
+		for _, stmt := range list {
+			switch {
+			case stmt.End() <= start || end <= stmt.Pos():
+				// out of the selection
+			case start <= stmt.Pos() && stmt.End() <= end:
+				stmts = append(stmts, stmt)
+			default:
+				return nil, errors.New("selection must be the whole statements")
+			}
+		}
+		if len(stmts) == 0 {
+			return nil, errors.New("selection does not contain any statement")
+		}
+		return stmts, nil
+	}
+	return nil, errors.New("selection is not in a statement list")
+}
+
+// checkExtractable returns the error if the statements change the control flow of the enclosing function.
+func checkExtractable(stmts []ast.Stmt) error {
+	var err error
+	var inspect func(n ast.Node, breakable, continuable bool) bool
+	inspect = func(n ast.Node, breakable, continuable bool) bool {
+		if err != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			err = errors.New("selection contains the return statement")
+		case *ast.DeferStmt:
+			err = errors.New("selection contains the defer statement")
+		case *ast.LabeledStmt:
+			err = errors.New("selection contains the labeled statement")
+		case *ast.BranchStmt:
+			switch {
+			case n.Label != nil, n.Tok == token.GOTO, n.Tok == token.FALLTHROUGH:
+				err = fmt.Errorf("selection contains the %s statement", n.Tok)
+			case n.Tok == token.BREAK && !breakable, n.Tok == token.CONTINUE && !continuable:
+				err = fmt.Errorf("selection contains the %s statement of the outer statement", n.Tok)
+			}
+		case *ast.ForStmt, *ast.RangeStmt:
+			ast.Inspect(n, func(m ast.Node) bool {
+				if m == n {
+					return true
+				}
+				return inspect(m, true, true)
+			})
+			return false
+		case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			ast.Inspect(n, func(m ast.Node) bool {
+				if m == n {
+					return true
+				}
+				return inspect(m, true, continuable)
+			})
+			return false
+		}
+		return err == nil
+	}
+
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool { return inspect(n, false, false) })
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// modifiedVars returns the variables which are assigned, incremented, addressed or
+// called the pointer receiver method in the statements.
+func modifiedVars(info *types.Info, stmts ...ast.Stmt) map[*types.Var]bool {
+	modified := make(map[*types.Var]bool)
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				// the new variables of the short variable declaration are not in info.Uses, only the redeclared ones are
+				for _, lhs := range n.Lhs {
+					markModified(info, lhs, modified)
+				}
+			case *ast.IncDecStmt:
+				markModified(info, n.X, modified)
+			case *ast.RangeStmt:
+				if n.Key != nil {
+					markModified(info, n.Key, modified)
+				}
+				if n.Value != nil {
+					markModified(info, n.Value, modified)
+				}
+			case *ast.UnaryExpr:
+				if n.Op == token.AND {
+					markModified(info, n.X, modified)
+				}
+			case *ast.SelectorExpr:
+				// the method call with the pointer receiver on the addressable value
+				if sel, ok := info.Selections[n]; ok && sel.Kind() == types.MethodVal {
+					if _, ok := sel.Recv().(*types.Pointer); !ok {
+						if sig, ok := sel.Obj().Type().(*types.Signature); ok && sig.Recv() != nil {
+							if _, ok := sig.Recv().Type().(*types.Pointer); ok {
+								markModified(info, n.X, modified)
+							}
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	return modified
+}
+
+// markModified marks the local variable of the expr as modified.
+func markModified(info *types.Info, expr ast.Expr, modified map[*types.Var]bool) {
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+			continue
+		case *ast.SelectorExpr:
+			// the field of the struct value
+			if _, ok := info.Selections[e]; ok {
+				if _, ok := info.TypeOf(e.X).Underlying().(*types.Pointer); !ok {
+					expr = e.X
+					continue
+				}
+			}
+		case *ast.IndexExpr:
+			// the element of the array value
+			if _, ok := info.TypeOf(e.X).Underlying().(*types.Array); ok {
+				expr = e.X
+				continue
+			}
+		case *ast.Ident:
+			if v, ok := info.Uses[e].(*types.Var); ok {
+				modified[v] = true
+			}
+		}
+		return
+	}
+}
+
+// definedVars returns the variables which are defined by the statement in the enclosing scope.
+func definedVars(info *types.Info, stmt ast.Stmt) []*types.Var {
+	var idents []*ast.Ident
+	switch stmt := stmt.(type) {
+	case *ast.AssignStmt:
+		if stmt.Tok == token.DEFINE {
+			for _, lhs := range stmt.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok {
+					idents = append(idents, id)
+				}
+			}
+		}
+	case *ast.DeclStmt:
+		if gd, ok := stmt.Decl.(*ast.GenDecl); ok && gd.Tok == token.VAR {
+			for _, spec := range gd.Specs {
+				idents = append(idents, spec.(*ast.ValueSpec).Names...)
+			}
+		}
+	}
+
+	var vars []*types.Var
+	for _, id := range idents {
+		if v, ok := info.Defs[id].(*types.Var); ok {
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
+
+// ExtractVar extracts the expression in the byte range [start, end) to the new local variable name,
+// which declared before the enclosing statement, and replaces the expression with the variable.
+func ExtractVar(f *File, start, end int, name string) ([]byte, error) {
+	start, end = f.trim(start, end)
+	if start >= end {
+		return nil, errors.New("empty selection")
+	}
+	spos, epos := f.pos(start), f.pos(end)
+
+	path, _ := astutil.PathEnclosingInterval(f.File, spos, epos)
+	expr, ok := path[0].(ast.Expr)
+	if !ok || expr.Pos() != spos || expr.End() != epos {
+		return nil, errors.New("selection must be an expression")
+	}
+	if tv, ok := f.Info.Types[expr]; !ok || !tv.IsValue() {
+		return nil, errors.New("selection is not a value")
+	} else if _, ok := tv.Type.(*types.Tuple); ok {
+		return nil, errors.New("selection is a multiple-value expression")
+	}
+
+	// find the statement in the statement list which encloses the expression
+	var stmt ast.Stmt
+	for i := 1; i < len(path)-1; i++ {
+		switch n := path[i].(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if lhs == path[i-1] {
+					return nil, errors.New("selection is the left-hand side of the assignment")
+				}
+			}
+		case *ast.BinaryExpr:
+			if (n.Op == token.LAND || n.Op == token.LOR) && n.Y == path[i-1] {
+				return nil, errors.New("selection is conditionally evaluated")
+			}
+		case *ast.ForStmt:
+			if n.Cond == path[i-1] || n.Post == path[i-1] {
+				return nil, errors.New("selection is evaluated in each iteration")
+			}
+		case *ast.IfStmt:
+			if _, ok := path[i+1].(*ast.IfStmt); ok {
+				return nil, errors.New("selection is in the else-if statement")
+			}
+		}
+		if s, ok := path[i].(ast.Stmt); ok {
+			if _, ok := stmtList(path[i+1]); ok {
+				stmt = s
+				break
+			}
+		}
+	}
+	if stmt == nil {
+		return nil, errors.New("selection is not in a statement")
+	}
+
+	// the variables used by the expression must be declared before the statement
+	var err error
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && err == nil {
+			if obj := f.Info.Uses[id]; obj != nil && stmt.Pos() <= obj.Pos() && obj.Pos() < stmt.End() {
+				err = fmt.Errorf("%s is declared in the enclosing statement", id.Name)
+			}
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := f.checkName(name, f.Pkg.Scope().Innermost(stmt.Pos()), stmt.Pos()); err != nil {
+		return nil, err
+	}
+
+	decl := fmt.Sprintf("%s := %s\n%s", name, f.text(expr), f.indent(stmt.Pos()))
+	return f.apply(
+		edit{start: f.offset(stmt.Pos()), end: f.offset(stmt.Pos()), new: decl},
+		edit{start: start, end: end, new: name},
+	)
+}
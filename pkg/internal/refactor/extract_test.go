@@ -0,0 +1,346 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package refactor
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// typeCheck parses and type-checks src as the single file package.
+func typeCheck(t *testing.T, src string) *File {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("p", fset, []*ast.File{file}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &File{Fset: fset, File: file, Pkg: pkg, Info: info, Src: []byte(src)}
+}
+
+// selection returns the byte range of sel in src.
+func selection(t *testing.T, src, sel string) (int, int) {
+	t.Helper()
+
+	start := strings.Index(src, sel)
+	if start < 0 {
+		t.Fatalf("%q is not found", sel)
+	}
+	return start, start + len(sel)
+}
+
+func TestExtractFunc(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		sel     string
+		fn      string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Results",
+			src: `package p
+
+func f(a int) int {
+	b := a + 1
+	c := b * 2
+	return c
+}
+`,
+			sel: "b := a + 1\n\tc := b * 2\n",
+			fn:  "g",
+			want: `package p
+
+func f(a int) int {
+	c := g(a)
+	return c
+}
+
+func g(a int) int {
+	b := a + 1
+	c := b * 2
+	return c
+}
+`,
+		},
+		{
+			name: "ModifiedParam",
+			src: `package p
+
+func f() int {
+	x := 0
+	x++
+	y := 1
+	return x + y
+}
+`,
+			sel: "x++\n\ty := 1",
+			fn:  "g",
+			want: `package p
+
+func f() int {
+	x := 0
+	var y int
+	y, x = g(x)
+	return x + y
+}
+
+func g(x int) (int, int) {
+	x++
+	y := 1
+	return y, x
+}
+`,
+		},
+		{
+			name: "NoResult",
+			src: `package p
+
+import "strings"
+
+func f(s []string, b *strings.Builder) {
+	for _, v := range s {
+		if v == "" {
+			continue
+		}
+		b.WriteString(v)
+	}
+}
+`,
+			sel: "for _, v := range s {\n\t\tif v == \"\" {\n\t\t\tcontinue\n\t\t}\n\t\tb.WriteString(v)\n\t}",
+			fn:  "write",
+			want: `package p
+
+import "strings"
+
+func f(s []string, b *strings.Builder) {
+	write(s, b)
+}
+
+func write(s []string, b *strings.Builder) {
+	for _, v := range s {
+		if v == "" {
+			continue
+		}
+		b.WriteString(v)
+	}
+}
+`,
+		},
+		{
+			name: "Return",
+			src: `package p
+
+func f(a int) int {
+	if a > 0 {
+		return a
+	}
+	return 0
+}
+`,
+			sel:     "if a > 0 {\n\t\treturn a\n\t}",
+			fn:      "g",
+			wantErr: true,
+		},
+		{
+			name: "OuterBreak",
+			src: `package p
+
+func f(a []int) {
+	for range a {
+		if len(a) > 0 {
+			break
+		}
+	}
+}
+`,
+			sel:     "if len(a) > 0 {\n\t\t\tbreak\n\t\t}",
+			fn:      "g",
+			wantErr: true,
+		},
+		{
+			name: "PartialStatement",
+			src: `package p
+
+func f(a int) int {
+	b := a + 1
+	return b
+}
+`,
+			sel:     "a + 1",
+			fn:      "g",
+			wantErr: true,
+		},
+		{
+			name: "Declared",
+			src: `package p
+
+func f(a int) {
+	a++
+}
+`,
+			sel:     "a++",
+			fn:      "f",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := typeCheck(t, tt.src)
+			start, end := selection(t, tt.src, tt.sel)
+			got, err := ExtractFunc(f, start, end, tt.fn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractFunc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
+
+func TestExtractVar(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		sel     string
+		v       string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Return",
+			src: `package p
+
+func f(a int) int {
+	return a*2 + 1
+}
+`,
+			sel: "a*2",
+			v:   "d",
+			want: `package p
+
+func f(a int) int {
+	d := a * 2
+	return d + 1
+}
+`,
+		},
+		{
+			name: "IfCond",
+			src: `package p
+
+func f(s string) bool {
+	if len(s) > 0 {
+		return true
+	}
+	return false
+}
+`,
+			sel: "len(s)",
+			v:   "n",
+			want: `package p
+
+func f(s string) bool {
+	n := len(s)
+	if n > 0 {
+		return true
+	}
+	return false
+}
+`,
+		},
+		{
+			name: "ConditionallyEvaluated",
+			src: `package p
+
+func f(s []int) bool {
+	return len(s) > 0 && s[0] == 1
+}
+`,
+			sel:     "s[0] == 1",
+			v:       "b",
+			wantErr: true,
+		},
+		{
+			name: "LeftHandSide",
+			src: `package p
+
+func f(s []int) {
+	s[0] = 1
+}
+`,
+			sel:     "s[0]",
+			v:       "e",
+			wantErr: true,
+		},
+		{
+			name: "NotExpression",
+			src: `package p
+
+func f(a int) int {
+	return a + 1
+}
+`,
+			sel:     "a +",
+			v:       "b",
+			wantErr: true,
+		},
+		{
+			name: "Declared",
+			src: `package p
+
+func f(a int) int {
+	return a + 1
+}
+`,
+			sel:     "a + 1",
+			v:       "a",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := typeCheck(t, tt.src)
+			start, end := selection(t, tt.src, tt.sel)
+			got, err := ExtractVar(f, start, end, tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractVar() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package refactor
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// FillStruct expands the struct composite literal at the byte offset into the keyed form,
+// and fills the missing fields with the zero values.
+//
+// The unexported fields are filled only if the struct is declared in the same package.
+// If recursive is true, the fields of the struct types are filled recursively.
+func FillStruct(f *File, offset int, recursive bool) ([]byte, error) {
+	pos := f.pos(offset)
+	path, _ := astutil.PathEnclosingInterval(f.File, pos, pos)
+
+	var lit *ast.CompositeLit
+	for _, n := range path {
+		if n, ok := n.(*ast.UnaryExpr); ok && lit == nil {
+			lit, _ = n.X.(*ast.CompositeLit)
+		}
+		if n, ok := n.(*ast.CompositeLit); ok {
+			lit = n
+			break
+		}
+	}
+	if lit == nil {
+		return nil, errors.New("no composite literal at the cursor")
+	}
+
+	typ := f.Info.TypeOf(lit)
+	if typ == nil {
+		return nil, errors.New("could not determine the type of the composite literal")
+	}
+	if ptr, ok := typ.(*types.Pointer); ok { // the elided &T of []*T{{}}
+		typ = ptr.Elem()
+	}
+	st, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct type", f.typeString(typ))
+	}
+
+	// the existing elements of each field
+	elts := make(map[string]string)
+	for i, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*ast.Ident); ok {
+				elts[key.Name] = f.text(kv.Value)
+				continue
+			}
+		} else if i < st.NumFields() {
+			elts[st.Field(i).Name()] = f.text(elt)
+			continue
+		}
+		return nil, fmt.Errorf("invalid element %s", f.text(elt))
+	}
+
+	im := f.newImportSet()
+	z := &zeroer{f: f, im: im, recursive: recursive}
+	var buf strings.Builder
+	buf.WriteString("{\n")
+	filled := false
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		value, ok := elts[field.Name()]
+		if !ok {
+			if !z.visible(field) {
+				continue
+			}
+			if value, ok = z.value(field.Type()); !ok {
+				continue
+			}
+			filled = true
+		}
+		fmt.Fprintf(&buf, "%s: %s,\n", field.Name(), value)
+	}
+	buf.WriteString("}")
+
+	keyed := true
+	for _, elt := range lit.Elts {
+		if _, ok := elt.(*ast.KeyValueExpr); !ok {
+			keyed = false
+		}
+	}
+	if !filled && keyed {
+		return nil, fmt.Errorf("all fields of %s are already filled", f.typeString(typ))
+	}
+
+	start, end := f.offset(lit.Lbrace), f.offset(lit.Rbrace)+1
+	edits := append(f.importEdits(im.missing), edit{start: start, end: end, new: buf.String()})
+	return f.apply(edits...)
+}
+
+// zeroer generates the zero value expressions of the types.
+type zeroer struct {
+	f         *File
+	im        *importSet
+	recursive bool
+}
+
+// visible reports whether the field can be set in the file.
+func (z *zeroer) visible(field *types.Var) bool {
+	return field.Exported() || field.Pkg() == z.f.Pkg
+}
+
+// value returns the zero value expression of typ, or false if the type cannot be written in the file.
+func (z *zeroer) value(typ types.Type) (string, bool) {
+	if named, ok := typ.(*types.Named); ok {
+		if obj := named.Obj(); obj.Pkg() != nil && obj.Pkg() != z.f.Pkg && !obj.Exported() {
+			return "", false
+		}
+	}
+
+	switch u := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false", true
+		case u.Info()&types.IsNumeric != 0:
+			return "0", true
+		case u.Info()&types.IsString != 0:
+			return `""`, true
+		case u.Kind() == types.UnsafePointer:
+			return "nil", true
+		}
+		return "", false
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return "nil", true
+	case *types.Array:
+		return types.TypeString(typ, z.im.qualifier) + "{}", true
+	case *types.Struct:
+		t := types.TypeString(typ, z.im.qualifier)
+		if !z.recursive {
+			return t + "{}", true
+		}
+
+		var buf strings.Builder
+		for i := 0; i < u.NumFields(); i++ {
+			field := u.Field(i)
+			if !z.visible(field) {
+				continue
+			}
+			if value, ok := z.value(field.Type()); ok {
+				fmt.Fprintf(&buf, "%s: %s,\n", field.Name(), value)
+			}
+		}
+		if buf.Len() == 0 {
+			return t + "{}", true
+		}
+		return t + "{\n" + buf.String() + "}", true
+	}
+	return "", false
+}
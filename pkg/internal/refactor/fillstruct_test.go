@@ -0,0 +1,213 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package refactor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFillStruct(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string // the cursor is at the first "@", which is removed from src
+		recursive bool
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "Local",
+			src: `package p
+
+type inner struct{ n int }
+
+type T struct {
+	a    int
+	B    string
+	ok   bool
+	p    *T
+	in   inner
+	arr  [2]byte
+	fn   func()
+	m    map[string]int
+}
+
+var _ = T{@}
+`,
+			want: `package p
+
+type inner struct{ n int }
+
+type T struct {
+	a   int
+	B   string
+	ok  bool
+	p   *T
+	in  inner
+	arr [2]byte
+	fn  func()
+	m   map[string]int
+}
+
+var _ = T{
+	a:   0,
+	B:   "",
+	ok:  false,
+	p:   nil,
+	in:  inner{},
+	arr: [2]byte{},
+	fn:  nil,
+	m:   nil,
+}
+`,
+		},
+		{
+			name: "Keyed",
+			src: `package p
+
+type T struct{ A, B, C int }
+
+var _ = &T{B: 1 + 2@}
+`,
+			want: `package p
+
+type T struct{ A, B, C int }
+
+var _ = &T{
+	A: 0,
+	B: 1 + 2,
+	C: 0,
+}
+`,
+		},
+		{
+			name: "Unkeyed",
+			src: `package p
+
+type T struct{ A, B int }
+
+var _ = []T{{1, @2}}
+`,
+			want: `package p
+
+type T struct{ A, B int }
+
+var _ = []T{{
+	A: 1,
+	B: 2,
+}}
+`,
+		},
+		{
+			name: "Unexported",
+			src: `package p
+
+import "net/url"
+
+var _ = @&url.Userinfo{}
+`,
+			wantErr: true,
+		},
+		{
+			name: "Imports",
+			src: `package p
+
+import "log/slog"
+
+var _ = slog.Record{@}
+`,
+			want: `package p
+
+import (
+	"log/slog"
+	"time"
+)
+
+var _ = slog.Record{
+	Time:    time.Time{},
+	Message: "",
+	Level:   0,
+	PC:      0,
+}
+`,
+		},
+		{
+			name: "Recursive",
+			src: `package p
+
+type Inner struct {
+	N    int
+	Deep struct{ S string }
+}
+
+type T struct {
+	In  Inner
+	Ptr *Inner
+}
+
+var _ = T{@}
+`,
+			recursive: true,
+			want: `package p
+
+type Inner struct {
+	N    int
+	Deep struct{ S string }
+}
+
+type T struct {
+	In  Inner
+	Ptr *Inner
+}
+
+var _ = T{
+	In: Inner{
+		N: 0,
+		Deep: struct{ S string }{
+			S: "",
+		},
+	},
+	Ptr: nil,
+}
+`,
+		},
+		{
+			name: "Filled",
+			src: `package p
+
+type T struct{ A int }
+
+var _ = T{A: @1}
+`,
+			wantErr: true,
+		},
+		{
+			name: "NotStruct",
+			src: `package p
+
+var _ = []int{@1}
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			offset := strings.Index(tt.src, "@")
+			f := typeCheck(t, strings.Replace(tt.src, "@", "", 1))
+			got, err := FillStruct(f, offset, tt.recursive)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FillStruct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
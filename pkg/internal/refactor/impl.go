@@ -0,0 +1,128 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package refactor
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Impl generates the stub methods of the interface iface which are not declared by the receiver type recv,
+// and inserts them after the declaration of the receiver type.
+//
+// recv is the receiver of the stub methods such as "r *T", "*T" or "T". The receiver name is the lower case
+// of the first letter of the type name if omitted.
+func Impl(f *File, recv string, iface *types.TypeName) ([]byte, error) {
+	recvName, pointer, typeName, err := parseReceiver(recv)
+	if err != nil {
+		return nil, err
+	}
+
+	tn, ok := f.Pkg.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("type %s is not declared in package %s", typeName, f.Pkg.Name())
+	}
+	named, ok := tn.Type().(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a named type", typeName)
+	}
+	if types.IsInterface(named) {
+		return nil, fmt.Errorf("%s is an interface type", typeName)
+	}
+	it, ok := iface.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an interface type", iface.Name())
+	}
+
+	// the declaration of the receiver type in the file
+	var decl *ast.GenDecl
+	for _, d := range f.File.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+			for _, spec := range gd.Specs {
+				if f.Info.Defs[spec.(*ast.TypeSpec).Name] == tn {
+					decl = gd
+				}
+			}
+		}
+	}
+	if decl == nil {
+		return nil, fmt.Errorf("type %s is not declared in the current file", typeName)
+	}
+
+	// the packages which are referred by the stub methods and not imported yet
+	im := f.newImportSet()
+	qualifier := im.qualifier
+
+	var stubs bytes.Buffer
+	for i := 0; i < it.NumMethods(); i++ {
+		m := it.Method(i)
+		sig := m.Type().(*types.Signature)
+
+		obj, _, _ := types.LookupFieldOrMethod(named, true, m.Pkg(), m.Name())
+		if fn, ok := obj.(*types.Func); ok && types.Identical(fn.Type(), sig) {
+			continue
+		} else if obj != nil {
+			return nil, fmt.Errorf("%s.%s conflicts with the method of %s", typeName, m.Name(), iface.Name())
+		}
+		if !m.Exported() && m.Pkg() != f.Pkg {
+			return nil, fmt.Errorf("%s has the unexported method %s", iface.Name(), m.Name())
+		}
+		for _, tuple := range []*types.Tuple{sig.Params(), sig.Results()} {
+			for j := 0; j < tuple.Len(); j++ {
+				if tuple.At(j).Name() == recvName {
+					return nil, fmt.Errorf("receiver name %s conflicts with the parameter of %s", recvName, m.Name())
+				}
+			}
+		}
+
+		fmt.Fprintf(&stubs, "\n\n// %s implements %s.\nfunc (%s ", m.Name(), types.TypeString(iface.Type(), qualifier), recvName)
+		if pointer {
+			stubs.WriteByte('*')
+		}
+		fmt.Fprintf(&stubs, "%s) %s", typeName, m.Name())
+		types.WriteSignature(&stubs, sig, qualifier)
+		stubs.WriteString(" {\n\tpanic(\"not implemented\") // TODO: Implement\n}")
+	}
+	if stubs.Len() == 0 {
+		return nil, fmt.Errorf("%s already implements %s", typeName, iface.Name())
+	}
+
+	end := f.offset(decl.End())
+	edits := append(f.importEdits(im.missing), edit{start: end, end: end, new: stubs.String()})
+	return f.apply(edits...)
+}
+
+// parseReceiver parses the receiver such as "r *T", and returns the name, whether it is pointer and the type name.
+func parseReceiver(recv string) (name string, pointer bool, typeName string, err error) {
+	fields := strings.Fields(recv)
+	switch len(fields) {
+	case 1:
+		typeName = fields[0]
+	case 2:
+		name, typeName = fields[0], fields[1]
+	default:
+		return "", false, "", fmt.Errorf("invalid receiver %q", recv)
+	}
+	if strings.HasPrefix(typeName, "*") {
+		pointer, typeName = true, strings.TrimPrefix(typeName, "*")
+	}
+	if !token.IsIdentifier(typeName) {
+		return "", false, "", fmt.Errorf("invalid receiver type %q", typeName)
+	}
+	if name == "" {
+		r, _ := utf8.DecodeRuneInString(typeName)
+		name = string(unicode.ToLower(r))
+	}
+	if !token.IsIdentifier(name) {
+		return "", false, "", fmt.Errorf("invalid receiver name %q", name)
+	}
+	return name, pointer, typeName, nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package refactor
+
+import (
+	"go/importer"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestImpl(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		recv    string
+		iface   string // the interface in the package scope of src, or "path.Name"
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Stubs",
+			src: `package p
+
+import "io"
+
+type Reader interface {
+	io.Reader
+	Reset(r io.Reader, n ...int) error
+}
+
+type T struct{}
+
+func (t *T) Read(p []byte) (int, error) { return 0, nil }
+`,
+			recv:  "*T",
+			iface: "Reader",
+			want: `package p
+
+import "io"
+
+type Reader interface {
+	io.Reader
+	Reset(r io.Reader, n ...int) error
+}
+
+type T struct{}
+
+// Reset implements Reader.
+func (t *T) Reset(r io.Reader, n ...int) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (t *T) Read(p []byte) (int, error) { return 0, nil }
+`,
+		},
+		{
+			name: "Imports",
+			src: `package p
+
+import "io"
+
+type T int
+
+var _ io.Reader
+`,
+			recv:  "x T",
+			iface: "net/http.Handler",
+			want: `package p
+
+import (
+	"io"
+	"net/http"
+)
+
+type T int
+
+// ServeHTTP implements http.Handler.
+func (x T) ServeHTTP(http.ResponseWriter, *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+var _ io.Reader
+`,
+		},
+		{
+			name: "Implemented",
+			src: `package p
+
+type Stringer interface{ String() string }
+
+type T int
+
+func (T) String() string { return "" }
+`,
+			recv:    "T",
+			iface:   "Stringer",
+			wantErr: true,
+		},
+		{
+			name: "Conflict",
+			src: `package p
+
+type Stringer interface{ String() string }
+
+type T int
+
+func (T) String() int { return 0 }
+`,
+			recv:    "T",
+			iface:   "Stringer",
+			wantErr: true,
+		},
+		{
+			name: "ReceiverConflict",
+			src: `package p
+
+type Writer interface{ Write(t []byte) error }
+
+type T int
+`,
+			recv:    "t T",
+			iface:   "Writer",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := typeCheck(t, tt.src)
+			scope, name := f.Pkg.Scope(), tt.iface
+			if i := strings.LastIndex(tt.iface, "."); i >= 0 {
+				pkg, err := importer.ForCompiler(token.NewFileSet(), "source", nil).Import(tt.iface[:i])
+				if err != nil {
+					t.Fatal(err)
+				}
+				scope, name = pkg.Scope(), tt.iface[i+1:]
+			}
+			iface := scope.Lookup(name).(*types.TypeName)
+			got, err := Impl(f, tt.recv, iface)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Impl() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
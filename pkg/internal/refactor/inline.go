@@ -0,0 +1,413 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package refactor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// inlineMaxStmts is the maximum number of the statements of the function body which can be inlined.
+const inlineMaxStmts = 8
+
+// Inline inlines the local variable or the function call of the identifier at the byte offset.
+//
+// The local variable is replaced by its initializer expression in every use, and the declaration is removed.
+// The call of the small unexported function is replaced by the function body.
+func Inline(f *File, offset int) ([]byte, error) {
+	pos := f.pos(offset)
+	path, _ := astutil.PathEnclosingInterval(f.File, pos, pos)
+	id, ok := path[0].(*ast.Ident)
+	if !ok {
+		return nil, errors.New("no identifier at the cursor")
+	}
+
+	obj := f.Info.ObjectOf(id)
+	switch obj := obj.(type) {
+	case *types.Var:
+		if obj.IsField() || obj.Parent() == nil || obj.Parent() == f.Pkg.Scope() {
+			return nil, fmt.Errorf("%s is not a local variable", id.Name)
+		}
+		return inlineVar(f, obj)
+	case *types.Func:
+		call, ok := path[1].(*ast.CallExpr)
+		if !ok || call.Fun != id {
+			return nil, fmt.Errorf("%s is not the call of the function", id.Name)
+		}
+		return inlineCall(f, obj, call, path[2])
+	}
+	return nil, fmt.Errorf("cannot inline %s", id.Name)
+}
+
+// inlineVar replaces the uses of the local variable v with its initializer expression.
+func inlineVar(f *File, v *types.Var) ([]byte, error) {
+	path, _ := astutil.PathEnclosingInterval(f.File, v.Pos(), v.Pos())
+	fd := enclosingFunc(path)
+	if fd == nil || fd.Body == nil {
+		return nil, fmt.Errorf("%s is not declared in a function", v.Name())
+	}
+
+	// the declaration statement and the initializer expression
+	var decl ast.Stmt
+	var init ast.Expr
+	for _, n := range path {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE && len(n.Lhs) == 1 && len(n.Rhs) == 1 {
+				decl, init = n, n.Rhs[0]
+			}
+		case *ast.DeclStmt:
+			if gd := n.Decl.(*ast.GenDecl); len(gd.Specs) == 1 {
+				if spec, ok := gd.Specs[0].(*ast.ValueSpec); ok && len(spec.Names) == 1 && len(spec.Values) == 1 {
+					decl, init = n, spec.Values[0]
+				}
+			}
+		default:
+			continue
+		}
+		break
+	}
+	if decl == nil {
+		return nil, fmt.Errorf("%s is not declared by the single variable declaration with the initializer", v.Name())
+	}
+	if _, ok := stmtList(path[indexOf(path, decl)+1]); !ok {
+		return nil, fmt.Errorf("%s is declared in the header of the statement", v.Name())
+	}
+	if err := f.checkPure(init); err != nil {
+		return nil, err
+	}
+
+	// the variable and the variables used by the initializer must not be modified
+	modified := modifiedVars(f.Info, fd.Body)
+	if modified[v] {
+		return nil, fmt.Errorf("%s is assigned more than once", v.Name())
+	}
+	deps := f.usedObjects(init)
+	for obj := range deps {
+		if w, ok := obj.(*types.Var); ok && modified[w] {
+			return nil, fmt.Errorf("%s is assigned after the declaration of %s", w.Name(), v.Name())
+		}
+	}
+
+	edits := []edit{f.deleteStmt(decl)}
+	var uses []*ast.Ident
+	for id, obj := range f.Info.Uses {
+		if obj == v {
+			uses = append(uses, id)
+		}
+	}
+	sort.Slice(uses, func(i, j int) bool { return uses[i].Pos() < uses[j].Pos() })
+	for _, id := range uses {
+		if err := f.checkVisible(deps, id.Pos()); err != nil {
+			return nil, err
+		}
+		path, _ := astutil.PathEnclosingInterval(f.File, id.Pos(), id.End())
+		edits = append(edits, edit{start: f.offset(id.Pos()), end: f.offset(id.End()), new: f.replacement(path[1], id, init)})
+	}
+
+	return f.apply(edits...)
+}
+
+// inlineCall replaces the call of the function fn with the function body.
+func inlineCall(f *File, fn *types.Func, call *ast.CallExpr, parent ast.Node) ([]byte, error) {
+	if ast.IsExported(fn.Name()) {
+		return nil, fmt.Errorf("%s is exported", fn.Name())
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Recv() != nil {
+		return nil, fmt.Errorf("%s is a method", fn.Name())
+	}
+	if sig.Variadic() {
+		return nil, fmt.Errorf("%s is variadic", fn.Name())
+	}
+	if sig.Params().Len() != len(call.Args) {
+		return nil, fmt.Errorf("the arguments of %s are the multiple-value expression", fn.Name())
+	}
+
+	var fd *ast.FuncDecl
+	for _, decl := range f.File.Decls {
+		if d, ok := decl.(*ast.FuncDecl); ok && f.Info.Defs[d.Name] == fn {
+			fd = d
+			break
+		}
+	}
+	if fd == nil || fd.Body == nil {
+		return nil, fmt.Errorf("the declaration of %s is not in the current file", fn.Name())
+	}
+	if len(fd.Body.List) > inlineMaxStmts {
+		return nil, fmt.Errorf("%s has more than %d statements", fn.Name(), inlineMaxStmts)
+	}
+	if fd.Pos() <= call.Pos() && call.End() <= fd.End() {
+		return nil, fmt.Errorf("%s is recursive", fn.Name())
+	}
+	if results := fd.Type.Results; results != nil && len(results.List) > 0 && len(results.List[0].Names) > 0 {
+		return nil, fmt.Errorf("%s has the named results", fn.Name())
+	}
+
+	// the arguments which substitute the parameters
+	params := make(map[types.Object]ast.Expr)
+	uses := make(map[types.Object]int)
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			if obj := f.Info.Uses[id]; obj != nil {
+				uses[obj]++
+			}
+		}
+		return true
+	})
+	impure := 0
+	for i, arg := range call.Args {
+		p := sig.Params().At(i)
+		if err := f.checkPure(arg); err != nil {
+			// the impure argument is evaluated once at the same point only if it is used once
+			if impure++; impure > 1 || uses[p] != 1 {
+				return nil, err
+			}
+		}
+		params[p] = arg
+	}
+	modified := modifiedVars(f.Info, fd.Body)
+	for p := range params {
+		if modified[p.(*types.Var)] {
+			return nil, fmt.Errorf("the parameter %s is assigned in %s", p.Name(), fn.Name())
+		}
+	}
+
+	// the free identifiers of the body must refer the same objects at the call, and the local declarations
+	// of the body must not conflict with the declarations at the call or in the same block after the call
+	inBody := func(obj types.Object) bool { return fd.Body.Pos() <= obj.Pos() && obj.Pos() < fd.Body.End() }
+	free := f.usedObjects(fd.Body)
+	for obj := range free {
+		if _, ok := params[obj]; ok || inBody(obj) {
+			delete(free, obj)
+		}
+	}
+	if err := f.checkVisible(free, call.Pos()); err != nil {
+		return nil, err
+	}
+	scope := f.Pkg.Scope().Innermost(call.Pos())
+	for id, obj := range f.Info.Defs {
+		if obj == nil || !inBody(obj) || id.Name == "_" {
+			continue
+		}
+		o := scope.Lookup(id.Name)
+		if o == nil {
+			_, o = scope.LookupParent(id.Name, call.Pos())
+		}
+		if o != nil && o.Parent() != types.Universe {
+			return nil, fmt.Errorf("%s declared in %s conflicts with the declaration at %s", id.Name, fn.Name(), f.Fset.Position(o.Pos()))
+		}
+	}
+
+	switch parent := parent.(type) {
+	case *ast.ExprStmt:
+		if err := checkExtractable(fd.Body.List); err != nil {
+			return nil, fmt.Errorf("cannot inline %s: %v", fn.Name(), err)
+		}
+		if len(fd.Body.List) == 0 {
+			return f.apply(f.deleteStmt(parent))
+		}
+		first, last := fd.Body.List[0], fd.Body.List[len(fd.Body.List)-1]
+		body := f.substitute(first.Pos(), last.End(), params)
+		return f.apply(edit{start: f.offset(parent.Pos()), end: f.offset(parent.End()), new: body})
+
+	case *ast.GoStmt, *ast.DeferStmt:
+		return nil, errors.New("cannot inline the call of the go or defer statement")
+
+	default:
+		if len(fd.Body.List) != 1 {
+			return nil, fmt.Errorf("%s is not the single return statement", fn.Name())
+		}
+		ret, ok := fd.Body.List[0].(*ast.ReturnStmt)
+		if !ok || len(ret.Results) != 1 {
+			return nil, fmt.Errorf("%s does not return the single value", fn.Name())
+		}
+		expr := f.substitute(ret.Results[0].Pos(), ret.Results[0].End(), params)
+		if needParens(parent, call, ret.Results[0]) {
+			expr = "(" + expr + ")"
+		}
+		return f.apply(edit{start: f.offset(call.Pos()), end: f.offset(call.End()), new: expr})
+	}
+}
+
+// checkPure returns the error if the expression may have the side effects, or the value may be changed
+// without assigning to the local variables.
+func (f *File) checkPure(expr ast.Expr) error {
+	var err error
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.Ident:
+			if v, ok := f.Info.Uses[n].(*types.Var); ok && v.Pkg() != nil && v.Parent() == v.Pkg().Scope() {
+				err = fmt.Errorf("%s is the package-level variable", n.Name)
+			}
+		case *ast.BasicLit, *ast.ParenExpr, *ast.BinaryExpr:
+		case *ast.UnaryExpr:
+			if n.Op == token.AND || n.Op == token.ARROW {
+				err = fmt.Errorf("%s has the side effects", f.text(expr))
+			}
+		case *ast.SelectorExpr:
+			if sel, ok := f.Info.Selections[n]; ok && (sel.Kind() != types.FieldVal || sel.Indirect()) {
+				err = fmt.Errorf("%s may not be the same value", f.text(n))
+			}
+		case *ast.IndexExpr:
+			switch f.Info.TypeOf(n.X).Underlying().(type) {
+			case *types.Array, *types.Basic:
+			default:
+				err = fmt.Errorf("%s may not be the same value", f.text(n))
+			}
+		case *ast.CallExpr:
+			if tv, ok := f.Info.Types[n.Fun]; ok && tv.IsType() {
+				// conversion
+				break
+			}
+			if id, ok := astutil.Unparen(n.Fun).(*ast.Ident); ok {
+				if b, ok := f.Info.Uses[id].(*types.Builtin); ok && (b.Name() == "len" || b.Name() == "cap") {
+					break
+				}
+			}
+			err = fmt.Errorf("%s has the function call", f.text(expr))
+		default:
+			if _, ok := n.(ast.Expr); ok {
+				if tv, ok := f.Info.Types[n.(ast.Expr)]; ok && tv.IsType() {
+					return false
+				}
+				err = fmt.Errorf("%s may have the side effects", f.text(expr))
+			}
+		}
+		return err == nil
+	})
+	return err
+}
+
+// usedObjects returns the objects which are referred by the unqualified identifiers in n.
+func (f *File) usedObjects(n ast.Node) map[types.Object]bool {
+	objs := make(map[types.Object]bool)
+	ast.Inspect(n, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			if obj := f.Info.Uses[id]; obj != nil && obj.Parent() != nil && (obj.Pkg() == nil || obj.Pkg() == f.Pkg) {
+				objs[obj] = true
+			}
+		}
+		return true
+	})
+	return objs
+}
+
+// checkVisible returns the error if any of objs is shadowed at pos.
+func (f *File) checkVisible(objs map[types.Object]bool, pos token.Pos) error {
+	scope := f.Pkg.Scope().Innermost(pos)
+	for obj := range objs {
+		if _, o := scope.LookupParent(obj.Name(), pos); o != obj {
+			return fmt.Errorf("%s is not visible at %s", obj.Name(), f.Fset.Position(pos))
+		}
+	}
+	return nil
+}
+
+// substitute returns the source text of [start, end) which replaced the identifiers of params with the expressions.
+func (f *File) substitute(start, end token.Pos, params map[types.Object]ast.Expr) string {
+	var edits []edit
+	path, _ := astutil.PathEnclosingInterval(f.File, start, end)
+	ast.Inspect(path[0], func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || id.Pos() < start || end <= id.Pos() {
+			return true
+		}
+		if arg, ok := params[f.Info.Uses[id]]; ok {
+			p, _ := astutil.PathEnclosingInterval(f.File, id.Pos(), id.End())
+			edits = append(edits, edit{start: f.offset(id.Pos()), end: f.offset(id.End()), new: f.replacement(p[1], id, arg)})
+		}
+		return true
+	})
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var buf bytes.Buffer
+	last := f.offset(start)
+	for _, e := range edits {
+		buf.Write(f.Src[last:e.start])
+		buf.WriteString(e.new)
+		last = e.end
+	}
+	buf.Write(f.Src[last:f.offset(end)])
+	return buf.String()
+}
+
+// replacement returns the source text of the expression repl which replaces old in parent.
+func (f *File) replacement(parent ast.Node, old, repl ast.Expr) string {
+	if needParens(parent, old, repl) {
+		return "(" + f.text(repl) + ")"
+	}
+	return f.text(repl)
+}
+
+// needParens reports whether the expression repl which replaces old in parent needs the parentheses.
+func needParens(parent ast.Node, old, repl ast.Expr) bool {
+	var prec int
+	switch repl := repl.(type) {
+	case *ast.BinaryExpr:
+		prec = repl.Op.Precedence()
+	case *ast.UnaryExpr, *ast.StarExpr:
+		prec = token.UnaryPrec
+	default:
+		return false
+	}
+
+	switch p := parent.(type) {
+	case *ast.BinaryExpr:
+		if p.X == old {
+			return p.Op.Precedence() > prec
+		}
+		return p.Op.Precedence() >= prec
+	case *ast.UnaryExpr, *ast.StarExpr:
+		return true
+	case *ast.SelectorExpr:
+		return p.X == old
+	case *ast.IndexExpr:
+		return p.X == old
+	case *ast.SliceExpr:
+		return p.X == old
+	case *ast.TypeAssertExpr:
+		return p.X == old
+	case *ast.CallExpr:
+		return p.Fun == old
+	}
+	return false
+}
+
+// deleteStmt returns the edit which deletes the statement, including the line if the statement is the only one in it.
+func (f *File) deleteStmt(stmt ast.Stmt) edit {
+	start, end := f.offset(stmt.Pos()), f.offset(stmt.End())
+	lstart := bytes.LastIndexByte(f.Src[:start], '\n') + 1
+	lend := bytes.IndexByte(f.Src[end:], '\n')
+	if lend < 0 {
+		lend = len(f.Src)
+	} else {
+		lend += end + 1
+	}
+	if len(bytes.TrimSpace(f.Src[lstart:start])) == 0 && len(bytes.TrimSpace(f.Src[end:lend])) == 0 {
+		start, end = lstart, lend
+	}
+	return edit{start: start, end: end}
+}
+
+// indexOf returns the index of n in path, or -1.
+func indexOf(path []ast.Node, n ast.Node) int {
+	for i, m := range path {
+		if m == n {
+			return i
+		}
+	}
+	return -1
+}
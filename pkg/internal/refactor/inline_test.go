@@ -0,0 +1,253 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package refactor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInline(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		at      string // the cursor is at the first "@", which is removed from src
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Var",
+			src: `package p
+
+func f(a, b int) int {
+	@c := a + b
+	return c * c
+}
+`,
+			want: `package p
+
+func f(a, b int) int {
+	return (a + b) * (a + b)
+}
+`,
+		},
+		{
+			name: "VarUse",
+			src: `package p
+
+func f(s string) int {
+	var n = len(s)
+	if n > 0 {
+		return -@n
+	}
+	return n
+}
+`,
+			want: `package p
+
+func f(s string) int {
+	if len(s) > 0 {
+		return -len(s)
+	}
+	return len(s)
+}
+`,
+		},
+		{
+			name: "VarAssigned",
+			src: `package p
+
+func f(a int) int {
+	@c := a
+	c++
+	return c
+}
+`,
+			wantErr: true,
+		},
+		{
+			name: "VarDependencyAssigned",
+			src: `package p
+
+func f(a int) int {
+	@c := a
+	a = 2
+	return c
+}
+`,
+			wantErr: true,
+		},
+		{
+			name: "VarSideEffects",
+			src: `package p
+
+func g() int { return 1 }
+
+func f() int {
+	@c := g()
+	return c
+}
+`,
+			wantErr: true,
+		},
+		{
+			name: "VarShadowed",
+			src: `package p
+
+func f(a int) int {
+	@c := a
+	for a := 0; a < 1; a++ {
+		return c
+	}
+	return 0
+}
+`,
+			wantErr: true,
+		},
+		{
+			name: "FuncExpr",
+			src: `package p
+
+func add(x, y int) int {
+	return x + y
+}
+
+func f(a int) int {
+	return 2 * @add(a, 1)
+}
+`,
+			want: `package p
+
+func add(x, y int) int {
+	return x + y
+}
+
+func f(a int) int {
+	return 2 * (a + 1)
+}
+`,
+		},
+		{
+			name: "FuncStmt",
+			src: `package p
+
+import "fmt"
+
+func show(s string) {
+	fmt.Println("value:", s)
+	fmt.Println(len(s))
+}
+
+func f(v string) {
+	@show(v + "!")
+}
+`,
+			want: `package p
+
+import "fmt"
+
+func show(s string) {
+	fmt.Println("value:", s)
+	fmt.Println(len(s))
+}
+
+func f(v string) {
+	fmt.Println("value:", v+"!")
+	fmt.Println(len(v + "!"))
+}
+`,
+		},
+		{
+			name: "FuncImpureArgUsedTwice",
+			src: `package p
+
+func g() int { return 1 }
+
+func twice(x int) int {
+	return x + x
+}
+
+func f() int {
+	return @twice(g())
+}
+`,
+			wantErr: true,
+		},
+		{
+			name: "FuncImpureArgUsedOnce",
+			src: `package p
+
+func g() int { return 1 }
+
+func inc(x int) int {
+	return x + 1
+}
+
+func f() int {
+	return @inc(g())
+}
+`,
+			want: `package p
+
+func g() int { return 1 }
+
+func inc(x int) int {
+	return x + 1
+}
+
+func f() int {
+	return g() + 1
+}
+`,
+		},
+		{
+			name: "FuncExported",
+			src: `package p
+
+func Add(x, y int) int {
+	return x + y
+}
+
+func f(a int) int {
+	return @Add(a, 1)
+}
+`,
+			wantErr: true,
+		},
+		{
+			name: "FuncConflict",
+			src: `package p
+
+func set(x int) {
+	y := x
+	_ = y
+}
+
+func f(y int) {
+	@set(y)
+}
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			offset := strings.Index(tt.src, "@")
+			f := typeCheck(t, strings.Replace(tt.src, "@", "", 1))
+			got, err := Inline(f, offset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Inline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
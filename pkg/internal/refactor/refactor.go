@@ -0,0 +1,216 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package refactor implements the source code refactorings of the type-checked Go file.
+//
+// Each refactoring returns the whole refactored source which formatted by go/format,
+// so that the caller can apply the minimal update of the buffer.
+package refactor
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// File represents a type-checked Go source file to refactor.
+type File struct {
+	Fset *token.FileSet
+	File *ast.File
+	Pkg  *types.Package
+	Info *types.Info
+	Src  []byte
+}
+
+// offset returns the byte offset of pos in the file.
+func (f *File) offset(pos token.Pos) int {
+	return f.Fset.File(f.File.Pos()).Offset(pos)
+}
+
+// pos returns the token.Pos of the byte offset in the file.
+func (f *File) pos(offset int) token.Pos {
+	return f.Fset.File(f.File.Pos()).Pos(offset)
+}
+
+// text returns the source text of the node.
+func (f *File) text(n ast.Node) string {
+	return string(f.Src[f.offset(n.Pos()):f.offset(n.End())])
+}
+
+// trim returns the byte range [start, end) which trimmed the leading and trailing white spaces.
+func (f *File) trim(start, end int) (int, int) {
+	if end > len(f.Src) {
+		end = len(f.Src)
+	}
+	for start < end && unicode.IsSpace(rune(f.Src[start])) {
+		start++
+	}
+	for end > start && unicode.IsSpace(rune(f.Src[end-1])) {
+		end--
+	}
+	return start, end
+}
+
+// indent returns the leading white spaces of the line which contains pos.
+func (f *File) indent(pos token.Pos) string {
+	offset := f.offset(pos)
+	start := bytes.LastIndexByte(f.Src[:offset], '\n') + 1
+	end := start
+	for end < offset && (f.Src[end] == ' ' || f.Src[end] == '\t') {
+		end++
+	}
+	return string(f.Src[start:end])
+}
+
+// qualifier returns the package name of pkg which used in the file, or empty if pkg is the current package.
+func (f *File) qualifier(pkg *types.Package) string {
+	if pkg == f.Pkg {
+		return ""
+	}
+	for _, imp := range f.File.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path == pkg.Path() {
+			if imp.Name != nil {
+				return imp.Name.Name
+			}
+			break
+		}
+	}
+	return pkg.Name()
+}
+
+// typeString returns the string of typ which qualified by the package names used in the file.
+func (f *File) typeString(typ types.Type) string {
+	return types.TypeString(typ, f.qualifier)
+}
+
+// checkName returns the error if name is not a valid identifier, or already declared in the scope at pos.
+func (f *File) checkName(name string, scope *types.Scope, pos token.Pos) error {
+	if !token.IsIdentifier(name) || name == "_" {
+		return fmt.Errorf("%q is not a valid identifier", name)
+	}
+	if _, obj := scope.LookupParent(name, pos); obj != nil {
+		return fmt.Errorf("%q is already declared at %s", name, f.Fset.Position(obj.Pos()))
+	}
+	return nil
+}
+
+// edit represents a replacement of the byte range [start, end) of the source.
+type edit struct {
+	start, end int
+	new        string
+}
+
+// apply applies the edits to the source, and returns the formatted source.
+func (f *File) apply(edits ...edit) ([]byte, error) {
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var buf bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		if e.start < last {
+			return nil, fmt.Errorf("overlapped edits at offset %d", e.start)
+		}
+		buf.Write(f.Src[last:e.start])
+		buf.WriteString(e.new)
+		last = e.end
+	}
+	buf.Write(f.Src[last:])
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("could not format the refactored source: %v", err)
+	}
+	return out, nil
+}
+
+// enclosingFunc returns the outermost function declaration in path.
+func enclosingFunc(path []ast.Node) *ast.FuncDecl {
+	for i := len(path) - 1; i >= 0; i-- {
+		if fd, ok := path[i].(*ast.FuncDecl); ok {
+			return fd
+		}
+	}
+	return nil
+}
+
+// stmtList returns the statement list of the block-like node.
+func stmtList(n ast.Node) ([]ast.Stmt, bool) {
+	switch n := n.(type) {
+	case *ast.BlockStmt:
+		return n.List, true
+	case *ast.CaseClause:
+		return n.Body, true
+	case *ast.CommClause:
+		return n.Body, true
+	}
+	return nil, false
+}
+
+// importSet collects the packages which are referred by the generated code and not imported by the file yet.
+type importSet struct {
+	f        *File
+	imported map[string]bool
+	missing  []*types.Package
+}
+
+// newImportSet returns the importSet of the file.
+func (f *File) newImportSet() *importSet {
+	imported := make(map[string]bool)
+	for _, imp := range f.File.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		imported[path] = true
+	}
+	return &importSet{f: f, imported: imported}
+}
+
+// qualifier is the types.Qualifier which records the package if it is not imported yet.
+func (im *importSet) qualifier(pkg *types.Package) string {
+	if pkg != im.f.Pkg && !im.imported[pkg.Path()] {
+		im.imported[pkg.Path()] = true
+		im.missing = append(im.missing, pkg)
+	}
+	return im.f.qualifier(pkg)
+}
+
+// importEdits returns the edits which add the imports of pkgs to the file.
+func (f *File) importEdits(pkgs []*types.Package) []edit {
+	if len(pkgs) == 0 {
+		return nil
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Path() < pkgs[j].Path() })
+
+	var specs strings.Builder
+	for _, pkg := range pkgs {
+		if pkg.Name() != path.Base(pkg.Path()) {
+			fmt.Fprintf(&specs, "%s ", pkg.Name())
+		}
+		fmt.Fprintf(&specs, "%q\n", pkg.Path())
+	}
+
+	var last *ast.GenDecl
+	for _, decl := range f.File.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			last = gd
+		}
+	}
+	switch {
+	case last == nil:
+		end := f.offset(f.File.Name.End())
+		return []edit{{start: end, end: end, new: "\n\nimport (\n" + specs.String() + ")"}}
+	case last.Lparen.IsValid():
+		rparen := f.offset(last.Rparen)
+		return []edit{{start: rparen, end: rparen, new: specs.String()}}
+	default:
+		start, end := f.offset(last.Specs[0].Pos()), f.offset(last.Specs[0].End())
+		return []edit{{start: start, end: end, new: "(\n" + string(f.Src[start:end]) + "\n" + specs.String() + ")"}}
+	}
+}
@@ -0,0 +1,409 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// licence that can be found in the LICENSE file.
+
+// This file contains the implementation of the 'gomvpkg' command
+// whose main function is in golang.org/x/tools/cmd/gomvpkg.
+
+package rename
+
+// TODO(matloob):
+// - think about what happens if the package is moving across version control systems.
+// - dot imports are not supported. Make sure it's clearly documented.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/token"
+	"go/types"
+	"log"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/refactor/importgraph"
+)
+
+// MoveResult represents a result of the package moving.
+type MoveResult struct {
+	Result
+	// From, To source and destination import paths of the package.
+	From, To string
+	// FromDir, ToDir source and destination directories of the package.
+	// The caller moves FromDir to ToDir after applying the Edits.
+	FromDir, ToDir string
+}
+
+// Move, given a package path and a destination package path, computes
+// the edits of moving the given package to the new path. The Move function
+// will first check for any conflicts preventing the move, such as a
+// package already existing at the destination package path. If the
+// move can proceed, it builds an import graph to find all imports of
+// the packages whose paths need to be renamed. This includes uses of
+// the subpackages of the package to be moved as those packages will
+// also need to be moved.
+//
+// The packages are resolved through the GOPATH src directories of ctxt, so Move does not support
+// the packages of the modules outside GOPATH.
+//
+// Unlike the original, Move neither rewrites the files nor moves the directory.
+// The importers are limited to the packages which match the scope patterns
+// (e.g. "foo/...", or "-foo/vendor/..." to exclude) if scope is not empty.
+func Move(ctxt *build.Context, from, to string, scope []string) (*MoveResult, error) {
+	srcDir, err := srcDir(ctxt, from)
+	if err != nil {
+		return nil, err
+	}
+
+	// This should be the only place in the program that constructs
+	// file paths.
+	fromDir := buildutil.JoinPath(ctxt, srcDir, filepath.FromSlash(from))
+	toDir := buildutil.JoinPath(ctxt, srcDir, filepath.FromSlash(to))
+
+	// Build the import graph and figure out which packages to update.
+	_, rev, errors := importgraph.Build(ctxt)
+	if len(errors) > 0 {
+		// With a large GOPATH tree, errors are inevitable.
+		// Report them but proceed.
+		log.Printf("While scanning Go workspace:")
+		for path, err := range errors {
+			log.Printf("Package %q: %s.", path, err)
+		}
+	}
+
+	// Determine the affected packages---the set of packages whose import
+	// statements need updating.
+	affectedPackages := map[string]bool{from: true}
+	destinations := make(map[string]string) // maps old import path to new import path
+	subs, err := subpackages(ctxt, srcDir, from)
+	if err != nil {
+		return nil, err
+	}
+	for pkg := range subs {
+		for r := range rev[pkg] {
+			if inScope(scope, r) {
+				affectedPackages[r] = true
+			}
+		}
+		destinations[pkg] = strings.Replace(pkg, from, to, 1)
+	}
+
+	// Load all the affected packages.
+	iprog, err := loadProgram(ctxt, affectedPackages)
+	if err != nil {
+		return nil, err
+	}
+
+	m := mover{
+		res: &MoveResult{
+			From:    from,
+			To:      to,
+			FromDir: fromDir,
+			ToDir:   toDir,
+		},
+		ctxt:             ctxt,
+		rev:              rev,
+		iprog:            iprog,
+		from:             from,
+		to:               to,
+		fromDir:          fromDir,
+		toDir:            toDir,
+		affectedPackages: affectedPackages,
+		destinations:     destinations,
+		seen:             make(map[token.Position]bool),
+	}
+
+	if err := m.checkValid(); err != nil {
+		return nil, err
+	}
+
+	if err := m.move(); err != nil {
+		return nil, err
+	}
+
+	return m.res, nil
+}
+
+// inScope reports whether the pkg matches the scope patterns.
+func inScope(scope []string, pkg string) bool {
+	match := func(pattern string) bool {
+		if strings.HasSuffix(pattern, "/...") {
+			root := strings.TrimSuffix(pattern, "/...")
+			return pkg == root || strings.HasPrefix(pkg, root+"/")
+		}
+		return pkg == pattern
+	}
+
+	included := true
+	for _, pattern := range scope {
+		if !strings.HasPrefix(pattern, "-") {
+			included = false
+			break
+		}
+	}
+	for _, pattern := range scope {
+		if strings.HasPrefix(pattern, "-") {
+			if match(strings.TrimPrefix(pattern, "-")) {
+				return false
+			}
+			continue
+		}
+		if match(pattern) {
+			included = true
+		}
+	}
+	return included
+}
+
+// srcDir returns the absolute path of the srcdir containing pkg.
+func srcDir(ctxt *build.Context, pkg string) (string, error) {
+	for _, srcDir := range ctxt.SrcDirs() {
+		path := buildutil.JoinPath(ctxt, srcDir, pkg)
+		if buildutil.IsDir(ctxt, path) {
+			return srcDir, nil
+		}
+	}
+	return "", fmt.Errorf("src dir not found for package: %s", pkg)
+}
+
+// subpackages returns the set of packages in the given srcDir whose
+// import path equals to root, or has "root/" as the prefix.
+func subpackages(ctxt *build.Context, srcDir string, root string) (map[string]bool, error) {
+	var subs = make(map[string]bool)
+	var firstErr error
+	buildutil.ForEachPackage(ctxt, func(pkg string, err error) {
+		if firstErr != nil {
+			return
+		}
+		if err != nil {
+			firstErr = fmt.Errorf("unexpected error in ForEachPackage: %v", err)
+			return
+		}
+
+		// Only process the package root, or a sub-package of it.
+		if !(strings.HasPrefix(pkg, root) &&
+			(len(pkg) == len(root) || pkg[len(root)] == '/')) {
+			return
+		}
+
+		p, err := ctxt.Import(pkg, "", build.FindOnly)
+		if err != nil {
+			firstErr = fmt.Errorf("unexpected: package %s can not be located by build context: %s", pkg, err)
+			return
+		}
+		if p.SrcRoot == "" {
+			firstErr = fmt.Errorf("unexpected: could not determine srcDir for package %s: %s", pkg, err)
+			return
+		}
+		if p.SrcRoot != srcDir {
+			return
+		}
+
+		subs[pkg] = true
+	})
+	return subs, firstErr
+}
+
+type mover struct {
+	res *MoveResult
+	// iprog contains all packages whose contents need to be updated
+	// with new package names or import paths.
+	iprog *loader.Program
+	ctxt  *build.Context
+	// rev is the reverse import graph.
+	rev importgraph.Graph
+	// from and to are the source and destination import
+	// paths. fromDir and toDir are the source and destination
+	// absolute paths that package source files will be moved between.
+	from, to, fromDir, toDir string
+	// affectedPackages is the set of all packages whose contents need
+	// to be updated to reflect new package names or import paths.
+	affectedPackages map[string]bool
+	// destinations maps each subpackage to be moved to its
+	// destination path.
+	destinations map[string]string
+	// seen positions of the edits, since a file may be parsed more than once.
+	seen map[token.Position]bool
+}
+
+func (m *mover) checkValid() error {
+	const prefix = "invalid move destination"
+
+	match, err := regexp.MatchString("^[_\\pL][_\\pL\\p{Nd}]*$", path.Base(m.to))
+	if err != nil {
+		panic("regexp.MatchString failed")
+	}
+	if !match {
+		return fmt.Errorf("%s: %s; gomvpkg does not support move destinations "+
+			"whose base names are not valid go identifiers", prefix, m.to)
+	}
+
+	if buildutil.FileExists(m.ctxt, m.toDir) {
+		return fmt.Errorf("%s: %s conflicts with file %s", prefix, m.to, m.toDir)
+	}
+	if buildutil.IsDir(m.ctxt, m.toDir) {
+		return fmt.Errorf("%s: %s conflicts with directory %s", prefix, m.to, m.toDir)
+	}
+
+	for _, toSubPkg := range m.destinations {
+		if _, err := m.ctxt.Import(toSubPkg, "", build.FindOnly); err == nil {
+			return fmt.Errorf("%s: %s; package or subpackage %s already exists",
+				prefix, m.to, toSubPkg)
+		}
+	}
+
+	return nil
+}
+
+// addEdit adds the edit which replaces the old text at pos with the new.
+func (m *mover) addEdit(pos token.Pos, old, new string) {
+	posn := m.iprog.Fset.Position(pos)
+	if m.seen[posn] {
+		return
+	}
+	m.seen[posn] = true
+	m.res.Edits = append(m.res.Edits, Edit{
+		Pos: posn,
+		End: posn.Offset + len(old),
+		Old: old,
+		New: new,
+	})
+}
+
+func (m *mover) move() error {
+	packages := make(map[string]bool)
+
+	// Change the moved package's "package" declaration to its new base name.
+	pkg, ok := m.iprog.Imported[m.from]
+	if !ok {
+		return fmt.Errorf("unexpected: package %s is not in import map", m.from)
+	}
+	newName := path.Base(m.to)
+	for _, f := range pkg.Files {
+		// Update all import comments.
+		for _, cg := range f.Comments {
+			c := cg.List[0]
+			if c.Slash >= f.Name.End() &&
+				sameLine(m.iprog.Fset, c.Slash, f.Name.End()) &&
+				(f.Decls == nil || c.Slash < f.Decls[0].Pos()) {
+				if strings.HasPrefix(c.Text, `// import "`) {
+					m.addEdit(c.Slash, c.Text, `// import "`+m.to+`"`)
+					break
+				}
+				if strings.HasPrefix(c.Text, `/* import "`) {
+					m.addEdit(c.Slash, c.Text, `/* import "`+m.to+`" */`)
+					break
+				}
+			}
+		}
+		m.addEdit(f.Name.Pos(), f.Name.Name, newName) // change package decl
+		packages[m.from] = true
+	}
+
+	// Look through the external test packages (m.iprog.Created contains the external test packages).
+	for _, info := range m.iprog.Created {
+		// Change the "package" declaration of the external test package.
+		if info.Pkg.Path() == m.from+"_test" {
+			for _, f := range info.Files {
+				m.addEdit(f.Name.Pos(), f.Name.Name, newName+"_test") // change package decl
+				packages[info.Pkg.Path()] = true
+			}
+		}
+
+		// Mark all the loaded external test packages, which import the "from" package,
+		// as affected packages.
+		for _, imp := range info.Pkg.Imports() {
+			if imp.Path() == m.from {
+				m.affectedPackages[info.Pkg.Path()] = true
+				m.iprog.Imported[info.Pkg.Path()] = info
+			}
+		}
+	}
+
+	// Update import paths for all imports by affected packages.
+	// None of the subpackages will change their name---only the from package
+	// itself will, so the uses of the from package are renamed to the new name.
+	for ap := range m.affectedPackages {
+		info, ok := m.iprog.Imported[ap]
+		if !ok {
+			return fmt.Errorf("unexpected: package %s is not in import map", ap)
+		}
+		for _, f := range info.Files {
+			for _, imp := range f.Imports {
+				importPath, _ := strconv.Unquote(imp.Path.Value)
+				newPath, ok := m.destinations[importPath]
+				if !ok {
+					continue
+				}
+				packages[ap] = true
+
+				newValue := strconv.Quote(newPath)
+				oldName, newName := path.Base(importPath), path.Base(newPath)
+				if imp.Name == nil && oldName != newName && !m.importName(info, imp, newName) {
+					// keep the existing name, since the renaming would lead to a conflict
+					newValue = oldName + " " + newValue
+				}
+				m.addEdit(imp.Path.Pos(), imp.Path.Value, newValue)
+			}
+		}
+	}
+	m.res.Packages = len(packages)
+
+	sort.Slice(m.res.Edits, func(i, j int) bool {
+		x, y := m.res.Edits[i].Pos, m.res.Edits[j].Pos
+		if x.Filename != y.Filename {
+			return x.Filename < y.Filename
+		}
+		return x.Offset < y.Offset
+	})
+
+	return nil
+}
+
+// importName renames the uses of implicit import name of imp to the to.
+// importName reports false if the renaming would lead to a conflict.
+func (m *mover) importName(info *loader.PackageInfo, imp *ast.ImportSpec, to string) bool {
+	from := info.Implicits[imp]
+	if from == nil {
+		return true
+	}
+
+	r := renamer{
+		res:          new(Result),
+		force:        true,
+		iprog:        m.iprog,
+		objsToUpdate: make(map[types.Object]bool),
+		to:           to,
+		packages:     map[*types.Package]*loader.PackageInfo{info.Pkg: info},
+	}
+	r.check(from)
+	if r.hadConflicts {
+		return false
+	}
+	if err := r.update(); err != nil {
+		return false
+	}
+	start, end := m.iprog.Fset.Position(imp.Pos()), m.iprog.Fset.Position(imp.End())
+	for _, e := range r.res.Edits {
+		// the ImportSpec itself is updated by the mover
+		if e.Pos.Filename == start.Filename && start.Offset <= e.Pos.Offset && e.Pos.Offset < end.Offset {
+			continue
+		}
+		if !m.seen[e.Pos] {
+			m.seen[e.Pos] = true
+			m.res.Edits = append(m.res.Edits, e)
+		}
+	}
+	return true
+}
+
+// sameLine reports whether two positions in the same file are on the same line.
+func sameLine(fset *token.FileSet, x, y token.Pos) bool {
+	return fset.Position(x).Line == fset.Position(y).Line
+}
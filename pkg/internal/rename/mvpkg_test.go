@@ -0,0 +1,125 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rename
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/go/buildutil"
+)
+
+func TestMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		pkgs     map[string]map[string]string
+		from, to string
+		scope    []string
+		want     map[string]string
+		wantErr  bool
+	}{
+		{
+			name: "Rename",
+			pkgs: map[string]map[string]string{
+				"foo":  {"0.go": "package foo // import \"foo\"\n\ntype T int\n"},
+				"main": {"0.go": "package main\n\nimport \"foo\"\n\nvar _ foo.T\n"},
+			},
+			from: "foo", to: "bar",
+			want: map[string]string{
+				"/go/src/foo/0.go":  "package bar // import \"bar\"\n\ntype T int\n",
+				"/go/src/main/0.go": "package main\n\nimport \"bar\"\n\nvar _ bar.T\n",
+			},
+		},
+		{
+			name: "Conflict",
+			pkgs: map[string]map[string]string{
+				"foo":  {"0.go": "package foo\n\ntype T int\n"},
+				"main": {"0.go": "package main\n\nimport \"foo\"\n\nvar bar foo.T\n"},
+			},
+			from: "foo", to: "bar",
+			want: map[string]string{
+				"/go/src/foo/0.go":  "package bar\n\ntype T int\n",
+				"/go/src/main/0.go": "package main\n\nimport foo \"bar\"\n\nvar bar foo.T\n",
+			},
+		},
+		{
+			name: "Subpackage",
+			pkgs: map[string]map[string]string{
+				"foo":     {"0.go": "package foo\n\nimport \"foo/sub\"\n\nvar _ sub.T\n"},
+				"foo/sub": {"0.go": "package sub\n\ntype T int\n"},
+				"main":    {"0.go": "package main\n\nimport \"foo/sub\"\n\nvar _ sub.T\n"},
+			},
+			from: "foo", to: "x/bar",
+			want: map[string]string{
+				"/go/src/foo/0.go":  "package bar\n\nimport \"x/bar/sub\"\n\nvar _ sub.T\n",
+				"/go/src/main/0.go": "package main\n\nimport \"x/bar/sub\"\n\nvar _ sub.T\n",
+			},
+		},
+		{
+			name: "OutOfScope",
+			pkgs: map[string]map[string]string{
+				"foo":  {"0.go": "package foo\n\ntype T int\n"},
+				"main": {"0.go": "package main\n\nimport \"foo\"\n\nvar _ foo.T\n"},
+			},
+			from: "foo", to: "bar",
+			scope: []string{"foo/..."},
+			want: map[string]string{
+				"/go/src/foo/0.go": "package bar\n\ntype T int\n",
+			},
+		},
+		{
+			name: "Exists",
+			pkgs: map[string]map[string]string{
+				"foo": {"0.go": "package foo\n"},
+				"bar": {"0.go": "package bar\n"},
+			},
+			from: "foo", to: "bar",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctxt := buildutil.FakeContext(tt.pkgs)
+			res, err := Move(ctxt, tt.from, tt.to, tt.scope)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Move() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, applyEdits(t, ctxt, res.Edits)); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
+
+func TestInScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope []string
+		pkg   string
+		want  bool
+	}{
+		{name: "Empty", pkg: "foo", want: true},
+		{name: "Wildcard", scope: []string{"foo/..."}, pkg: "foo/bar", want: true},
+		{name: "WildcardRoot", scope: []string{"foo/..."}, pkg: "foo", want: true},
+		{name: "NotMatch", scope: []string{"foo/..."}, pkg: "foobar", want: false},
+		{name: "Exclude", scope: []string{"foo/...", "-foo/vendor/..."}, pkg: "foo/vendor/bar", want: false},
+		{name: "ExcludeOnly", scope: []string{"-foo/vendor/..."}, pkg: "bar", want: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := inScope(tt.scope, tt.pkg); got != tt.want {
+				t.Errorf("inScope(%v, %s) = %v, want %v", tt.scope, tt.pkg, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,506 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rename is a fork of golang.org/x/tools/refactor/rename.
+//
+// Unlike the original, Rename does not rewrite the files.
+// It returns the structured edits of the renaming, and does not use the package-level state,
+// so that it is safe to call from multiple goroutines.
+package rename
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/types/typeutil"
+	"golang.org/x/tools/refactor/importgraph"
+	"golang.org/x/tools/refactor/satisfy"
+)
+
+// ---- TODO ----
+
+// Correctness:
+// - handle dot imports correctly
+// - document limitations (reflection, 'implements' algorithm).
+// - sketch a proof of exhaustiveness.
+
+// Features:
+// - support running on packages specified as *.go files on the command line
+// - support running on programs containing errors (loader.Config.AllowErrors)
+// - allow users to specify a scope other than "global" (to avoid being
+//   stuck by neglected packages in $GOPATH that don't build).
+// - support renaming the package clause (no object)
+// - support renaming an import path (no ident or object)
+//   (requires filesystem + SCM updates).
+// - detect and reject edits to autogenerated files (cgo, protobufs)
+//   and optionally $GOROOT packages.
+// - report all conflicts, or at least all qualitatively distinct ones.
+//   Sometimes we stop to avoid redundancy, but
+//   it may give a disproportionate sense of safety in -force mode.
+// - support renaming all instances of a pattern, e.g.
+//   all receiver vars of a given type,
+//   all local variables of a given type,
+//   all PkgNames for a given package.
+// - emit JSON output for other editors and tools.
+
+// ConflictError is returned by Rename when it aborts the renaming due to conflicts.
+// (It is distinguished because the interesting errors are the conflicts themselves.)
+var ConflictError = errors.New("renaming aborted due to conflicts")
+
+// Edit represents a replacement of the identifier or doc comment text.
+type Edit struct {
+	// Pos start position of the replacement.
+	Pos token.Position
+	// End end offset of the replacement.
+	End int
+	// Old replaced text.
+	Old string
+	// New replacement text.
+	New string
+	// Comment reports whether the edit is in the doc comment.
+	Comment bool
+}
+
+// Conflict represents a conflict of the renaming.
+type Conflict struct {
+	Pos     token.Position
+	Message string
+}
+
+// Result represents a result of the renaming.
+type Result struct {
+	// Edits edits of the renaming which sorted by the filename and offset.
+	Edits []Edit
+	// Conflicts reported conflicts.
+	Conflicts []Conflict
+	// Packages number of the updated packages.
+	Packages int
+}
+
+// Files returns the sorted list of filenames which edited by the renaming.
+func (res *Result) Files() []string {
+	var files []string
+	for i, e := range res.Edits {
+		if i == 0 || res.Edits[i-1].Pos.Filename != e.Pos.Filename {
+			files = append(files, e.Pos.Filename)
+		}
+	}
+	return files
+}
+
+// Message returns the summary message of the renaming.
+func (res *Result) Message() string {
+	var nidents, nfiles int
+	for i, e := range res.Edits {
+		if !e.Comment {
+			nidents++
+		}
+		if i == 0 || res.Edits[i-1].Pos.Filename != e.Pos.Filename {
+			nfiles++
+		}
+	}
+	return fmt.Sprintf("Renamed %d occurrence%s in %d file%s in %d package%s.",
+		nidents, plural(nidents), nfiles, plural(nfiles), res.Packages, plural(res.Packages))
+}
+
+type renamer struct {
+	res                *Result
+	force              bool
+	iprog              *loader.Program
+	objsToUpdate       map[types.Object]bool
+	hadConflicts       bool
+	from, to           string
+	satisfyConstraints map[satisfy.Constraint]bool
+	packages           map[*types.Package]*loader.PackageInfo // subset of iprog.AllPackages to inspect
+	msets              typeutil.MethodSetCache
+	changeMethods      bool
+	// importSpecs ImportSpecs of the renaming PkgNames, which need to add or remove the Name.
+	importSpecs map[*ast.ImportSpec]*types.PkgName
+	// idents identifiers to update which are neither in Defs nor Uses, such as type switch variables.
+	idents []*ast.Ident
+}
+
+// reportError reports the conflict at posn.
+func (r *renamer) reportError(posn token.Position, message string) {
+	r.res.Conflicts = append(r.res.Conflicts, Conflict{Pos: posn, Message: message})
+}
+
+// Rename computes the edits of renaming the object specified by offsetFlag or fromFlag to the to.
+// Rename returns the Result with ConflictError if the conflicts were reported and force is false.
+func Rename(ctxt *build.Context, offsetFlag, fromFlag, to string, force bool) (*Result, error) {
+	// -- Parse the -from or -offset specifier ----------------------------
+
+	if (offsetFlag == "") == (fromFlag == "") {
+		return nil, fmt.Errorf("exactly one of the -from and -offset flags must be specified")
+	}
+
+	if !isValidIdentifier(to) {
+		return nil, fmt.Errorf("-to %q: not a valid identifier", to)
+	}
+
+	var spec *spec
+	var err error
+	if fromFlag != "" {
+		spec, err = parseFromFlag(ctxt, fromFlag)
+	} else {
+		spec, err = parseOffsetFlag(ctxt, offsetFlag)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.fromName == to {
+		return nil, fmt.Errorf("the old and new names are the same: %s", to)
+	}
+
+	// -- Load the program consisting of the initial package  -------------
+
+	iprog, err := loadProgram(ctxt, map[string]bool{spec.pkg: true})
+	if err != nil {
+		return nil, err
+	}
+
+	fromObjects, err := findFromObjects(iprog, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// -- Load a larger program, for global renamings ---------------------
+
+	if requiresGlobalRename(fromObjects, to) {
+		// For a local refactoring, we needn't load more
+		// packages, but if the renaming affects the package's
+		// API, we we must load all packages that depend on the
+		// package defining the object, plus their tests.
+
+		// Scan the workspace and build the import graph.
+		_, rev, errors := importgraph.Build(ctxt)
+		if len(errors) > 0 {
+			// With a large GOPATH tree, errors are inevitable.
+			// Report them but proceed.
+			log.Printf("While scanning Go workspace:")
+			for path, err := range errors {
+				log.Printf("Package %q: %s.", path, err)
+			}
+		}
+
+		// Enumerate the set of potentially affected packages.
+		affectedPackages := make(map[string]bool)
+		for _, obj := range fromObjects {
+			// External test packages are never imported,
+			// so they will never appear in the graph.
+			for path := range rev.Search(obj.Pkg().Path()) {
+				affectedPackages[path] = true
+			}
+		}
+
+		// TODO(adonovan): allow the user to specify the scope,
+		// or -ignore patterns?  Computing the scope when we
+		// don't (yet) support inputs containing errors can make
+		// the tool rather brittle.
+
+		// Re-load the larger program.
+		iprog, err = loadProgram(ctxt, affectedPackages)
+		if err != nil {
+			return nil, err
+		}
+
+		fromObjects, err = findFromObjects(iprog, spec)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// -- Do the renaming -------------------------------------------------
+
+	r := renamer{
+		res:          new(Result),
+		force:        force,
+		iprog:        iprog,
+		objsToUpdate: make(map[types.Object]bool),
+		from:         spec.fromName,
+		to:           to,
+		packages:     make(map[*types.Package]*loader.PackageInfo),
+	}
+
+	// A renaming initiated at an interface method indicates the
+	// intention to rename abstract and concrete methods as needed
+	// to preserve assignability.
+	for _, obj := range fromObjects {
+		if obj, ok := obj.(*types.Func); ok {
+			recv := obj.Type().(*types.Signature).Recv()
+			if recv != nil && isInterface(recv.Type().Underlying()) {
+				r.changeMethods = true
+				break
+			}
+		}
+	}
+
+	// Only the initially imported packages (iprog.Imported) and
+	// their external tests (iprog.Created) should be inspected or
+	// modified, as only they have type-checked functions bodies.
+	// The rest are just dependencies, needed only for package-level
+	// type information.
+	for _, info := range iprog.Imported {
+		r.packages[info.Pkg] = info
+	}
+	for _, info := range iprog.Created { // (tests)
+		r.packages[info.Pkg] = info
+	}
+
+	for _, from := range fromObjects {
+		r.check(from)
+	}
+	if r.hadConflicts && !force {
+		return r.res, ConflictError
+	}
+	if err := r.update(); err != nil {
+		return r.res, err
+	}
+	return r.res, nil
+}
+
+// loadProgram loads the specified set of packages (plus their tests)
+// and all their dependencies, from source, through the specified build
+// context.  Only packages in pkgs will have their functions bodies typechecked.
+func loadProgram(ctxt *build.Context, pkgs map[string]bool) (*loader.Program, error) {
+	conf := loader.Config{
+		Build:      ctxt,
+		ParserMode: parser.ParseComments,
+
+		// TODO(adonovan): enable this.  Requires making a lot of code more robust!
+		AllowErrors: false,
+	}
+	// Optimization: don't type-check the bodies of functions in our
+	// dependencies, since we only need exported package members.
+	conf.TypeCheckFuncBodies = func(p string) bool {
+		return pkgs[p] || pkgs[strings.TrimSuffix(p, "_test")]
+	}
+
+	for pkg := range pkgs {
+		conf.ImportWithTests(pkg)
+	}
+
+	// Ideally we would just return conf.Load() here, but go/types
+	// reports certain "soft" errors that gc does not (Go issue 14596).
+	// As a workaround, we set AllowErrors=true and then duplicate
+	// the loader's error checking but allow soft errors.
+	// It would be nice if the loader API permitted "AllowErrors: soft".
+	conf.AllowErrors = true
+	prog, err := conf.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var errpkgs []string
+	// Report hard errors in indirectly imported packages.
+	for _, info := range prog.AllPackages {
+		if containsHardErrors(info.Errors) {
+			errpkgs = append(errpkgs, info.Pkg.Path())
+		}
+	}
+	if errpkgs != nil {
+		var more string
+		if len(errpkgs) > 3 {
+			more = fmt.Sprintf(" and %d more", len(errpkgs)-3)
+			errpkgs = errpkgs[:3]
+		}
+		return nil, fmt.Errorf("couldn't load packages due to errors: %s%s",
+			strings.Join(errpkgs, ", "), more)
+	}
+	return prog, nil
+}
+
+func containsHardErrors(errors []error) bool {
+	for _, err := range errors {
+		if err, ok := err.(types.Error); ok && err.Soft {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// requiresGlobalRename reports whether this renaming could potentially
+// affect other packages in the Go workspace.
+func requiresGlobalRename(fromObjects []types.Object, to string) bool {
+	var tfm bool
+	for _, from := range fromObjects {
+		if from.Exported() {
+			return true
+		}
+		switch objectKind(from) {
+		case "type", "field", "method":
+			tfm = true
+		}
+	}
+	if ast.IsExported(to) && tfm {
+		// A global renaming may be necessary even if we're
+		// exporting a previous unexported name, since if it's
+		// the name of a type, field or method, this could
+		// change selections in other packages.
+		// (We include "type" in this list because a type
+		// used as an embedded struct field entails a field
+		// renaming.)
+		return true
+	}
+	return false
+}
+
+// update computes the edits of the input files.
+func (r *renamer) update() error {
+	// We use token.File, not filename, since a file may appear to
+	// belong to multiple packages and be parsed more than once.
+	// token.File captures this distinction; filename does not.
+
+	var filesToUpdate = make(map[*token.File]bool)
+	seen := make(map[token.Position]bool) // a file may be parsed more than once
+	addEdit := func(pos token.Pos, old string, comment bool) {
+		posn := r.iprog.Fset.Position(pos)
+		if seen[posn] {
+			return
+		}
+		seen[posn] = true
+		r.res.Edits = append(r.res.Edits, Edit{
+			Pos:     posn,
+			End:     posn.Offset + len(old),
+			Old:     old,
+			New:     r.to,
+			Comment: comment,
+		})
+	}
+
+	// Add or remove the ImportSpec.Name as needed.
+	for spec, pkgname := range r.importSpecs {
+		switch {
+		case spec.Name != nil && pkgname.Imported().Name() == r.to:
+			// ImportSpec.Name not needed
+			posn := r.iprog.Fset.Position(spec.Name.Pos())
+			seen[posn] = true
+			r.res.Edits = append(r.res.Edits, Edit{
+				Pos: posn,
+				End: r.iprog.Fset.Position(spec.Path.Pos()).Offset,
+				Old: spec.Name.Name + " ",
+			})
+			filesToUpdate[r.iprog.Fset.File(spec.Pos())] = true
+		case spec.Name == nil && pkgname.Imported().Name() != r.to:
+			// ImportSpec.Name needed
+			posn := r.iprog.Fset.Position(spec.Path.Pos())
+			r.res.Edits = append(r.res.Edits, Edit{
+				Pos: posn,
+				End: posn.Offset,
+				New: r.to + " ",
+			})
+			filesToUpdate[r.iprog.Fset.File(spec.Pos())] = true
+		}
+	}
+	for _, id := range r.idents {
+		addEdit(id.Pos(), id.Name, false)
+		filesToUpdate[r.iprog.Fset.File(id.Pos())] = true
+	}
+
+	docRegexp := regexp.MustCompile(`\b` + r.from + `\b`)
+	for _, info := range r.packages {
+		// Note the identifiers and filenames.
+		for id, obj := range info.Defs {
+			if r.objsToUpdate[obj] {
+				addEdit(id.Pos(), id.Name, false)
+				filesToUpdate[r.iprog.Fset.File(id.Pos())] = true
+				// Perform the rename in doc comments too.
+				if doc := r.docComment(id); doc != nil && r.from != "" {
+					for _, comment := range doc.List {
+						for _, loc := range docRegexp.FindAllStringIndex(comment.Text, -1) {
+							addEdit(comment.Slash+token.Pos(loc[0]), comment.Text[loc[0]:loc[1]], true)
+						}
+					}
+				}
+			}
+		}
+
+		for id, obj := range info.Uses {
+			if r.objsToUpdate[obj] {
+				addEdit(id.Pos(), id.Name, false)
+				filesToUpdate[r.iprog.Fset.File(id.Pos())] = true
+			}
+		}
+	}
+
+	// Renaming not supported if cgo files are affected.
+	var generatedFileNames []string
+	for _, info := range r.packages {
+		first := true
+		for _, f := range info.Files {
+			tokenFile := r.iprog.Fset.File(f.Pos())
+			if !filesToUpdate[tokenFile] {
+				continue
+			}
+			if first {
+				r.res.Packages++
+				first = false
+			}
+			if generated(f, tokenFile) {
+				generatedFileNames = append(generatedFileNames, tokenFile.Name())
+			}
+		}
+	}
+	if !r.force && len(generatedFileNames) > 0 {
+		return fmt.Errorf("refusing to modify generated file%s containing DO NOT EDIT marker: %v", plural(len(generatedFileNames)), generatedFileNames)
+	}
+
+	sort.Slice(r.res.Edits, func(i, j int) bool {
+		x, y := r.res.Edits[i].Pos, r.res.Edits[j].Pos
+		if x.Filename != y.Filename {
+			return x.Filename < y.Filename
+		}
+		return x.Offset < y.Offset
+	})
+
+	return nil
+}
+
+// docComment returns the doc for an identifier.
+func (r *renamer) docComment(id *ast.Ident) *ast.CommentGroup {
+	_, nodes, _ := r.iprog.PathEnclosingInterval(id.Pos(), id.End())
+	for _, node := range nodes {
+		switch decl := node.(type) {
+		case *ast.FuncDecl:
+			return decl.Doc
+		case *ast.Field:
+			return decl.Doc
+		case *ast.GenDecl:
+			return decl.Doc
+		// For {Type,Value}Spec, if the doc on the spec is absent,
+		// search for the enclosing GenDecl
+		case *ast.TypeSpec:
+			if decl.Doc != nil {
+				return decl.Doc
+			}
+		case *ast.ValueSpec:
+			if decl.Doc != nil {
+				return decl.Doc
+			}
+		case *ast.Ident:
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func plural(n int) string {
+	if n != 1 {
+		return "s"
+	}
+	return ""
+}
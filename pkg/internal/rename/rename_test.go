@@ -0,0 +1,124 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rename
+
+import (
+	"go/build"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/go/buildutil"
+)
+
+// applyEdits applies the edits to the files of ctxt, and returns the changed files.
+func applyEdits(t *testing.T, ctxt *build.Context, edits []Edit) map[string]string {
+	t.Helper()
+
+	// apply the edits from the end of file, so that the offsets are not shifted
+	sorted := append([]Edit(nil), edits...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Pos.Offset > sorted[j].Pos.Offset })
+
+	files := make(map[string]string)
+	for _, e := range sorted {
+		src, ok := files[e.Pos.Filename]
+		if !ok {
+			rc, err := ctxt.OpenFile(e.Pos.Filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf []byte
+			b := make([]byte, 1024)
+			for {
+				n, err := rc.Read(b)
+				buf = append(buf, b[:n]...)
+				if err != nil {
+					break
+				}
+			}
+			rc.Close()
+			src = string(buf)
+		}
+		files[e.Pos.Filename] = src[:e.Pos.Offset] + e.New + src[e.End:]
+	}
+
+	return files
+}
+
+func TestRename(t *testing.T) {
+	tests := []struct {
+		name    string
+		pkgs    map[string]map[string]string
+		offset  string
+		from    string
+		to      string
+		want    map[string]string
+		wantErr error
+	}{
+		{
+			name: "Func",
+			pkgs: map[string]map[string]string{
+				"foo":  {"0.go": "package foo\n\n// Bar does bar.\nfunc Bar() int { return 1 }\n"},
+				"main": {"0.go": "package main\n\nimport \"foo\"\n\nvar _ = foo.Bar()\n"},
+			},
+			from: `"foo".Bar`, to: "Baz",
+			want: map[string]string{
+				"/go/src/foo/0.go":  "package foo\n\n// Baz does bar.\nfunc Baz() int { return 1 }\n",
+				"/go/src/main/0.go": "package main\n\nimport \"foo\"\n\nvar _ = foo.Baz()\n",
+			},
+		},
+		{
+			name: "AddImportName",
+			pkgs: map[string]map[string]string{
+				"foo":  {"0.go": "package foo\n\ntype T int\n"},
+				"main": {"0.go": "package main\n\nimport \"foo\"\n\nvar _ foo.T\n"},
+			},
+			offset: "/go/src/main/0.go:#34", to: "bar",
+			want: map[string]string{
+				"/go/src/main/0.go": "package main\n\nimport bar \"foo\"\n\nvar _ bar.T\n",
+			},
+		},
+		{
+			name: "RemoveImportName",
+			pkgs: map[string]map[string]string{
+				"foo":  {"0.go": "package foo\n\ntype T int\n"},
+				"main": {"0.go": "package main\n\nimport bar \"foo\"\n\nvar _ bar.T\n"},
+			},
+			from: `"main"::bar`, to: "foo",
+			want: map[string]string{
+				"/go/src/main/0.go": "package main\n\nimport \"foo\"\n\nvar _ foo.T\n",
+			},
+		},
+		{
+			name: "Conflict",
+			pkgs: map[string]map[string]string{
+				"main": {"0.go": "package main\n\nfunc foo() {}\n\nvar bar int\n"},
+			},
+			from: `"main".foo`, to: "bar",
+			wantErr: ConflictError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctxt := buildutil.FakeContext(tt.pkgs)
+			res, err := Rename(ctxt, tt.offset, tt.from, tt.to, false)
+			if err != tt.wantErr {
+				t.Fatalf("Rename() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(res.Conflicts) == 0 {
+					t.Errorf("Rename() returns no conflicts")
+				}
+				return
+			}
+			if diff := cmp.Diff(tt.want, applyEdits(t, ctxt, res.Edits)); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
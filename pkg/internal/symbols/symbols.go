@@ -0,0 +1,346 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package symbols indexes the top-level declarations and the methods of the Go files for the workspace symbol search.
+// The package directories are walked by pkgindex.Walk, and the index is refreshed incrementally
+// by re-parsing only the changed files.
+package symbols
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/zchee/nvim-go/pkg/internal/pkgindex"
+)
+
+// Kind represents the kind of the symbol.
+type Kind int
+
+// list of the kinds of the symbol.
+const (
+	Func Kind = iota
+	Method
+	Type
+	Var
+	Const
+)
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case Func:
+		return "func"
+	case Method:
+		return "method"
+	case Type:
+		return "type"
+	case Var:
+		return "var"
+	case Const:
+		return "const"
+	}
+	return ""
+}
+
+// Symbol represents a top-level declaration or a method.
+type Symbol struct {
+	Name string
+	Recv string // the receiver type name of the method
+	Kind Kind
+	File string
+	Line int
+	Col  int
+}
+
+// QualifiedName returns the name of the symbol which is qualified by the receiver type name if the method.
+func (s Symbol) QualifiedName() string {
+	if s.Recv != "" {
+		return s.Recv + "." + s.Name
+	}
+	return s.Name
+}
+
+// file represents the indexed Go file.
+type file struct {
+	modTime int64
+	size    int64
+	symbols []Symbol
+}
+
+// Index represents the symbols of the Go files under the root directories.
+type Index struct {
+	mu    sync.RWMutex
+	files map[string]*file
+
+	refreshMu sync.Mutex // serializes Refresh
+}
+
+// New returns the empty Index.
+func New() *Index {
+	return &Index{files: make(map[string]*file)}
+}
+
+// Refresh walks the package directories under the roots by pkgindex.Walk, and re-parses the Go files
+// which are added or changed since the last refresh.
+// The files under the roots which are no longer found are removed, and the files under the other roots are kept.
+func (ix *Index) Refresh(roots []pkgindex.Root) error {
+	ix.refreshMu.Lock()
+	defer ix.refreshMu.Unlock()
+
+	var mu sync.Mutex
+	var files []string
+	var walkErr error
+	pkgindex.Walk(roots, func(_ pkgindex.Root, dir string) {
+		fis, err := ioutil.ReadDir(dir)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if walkErr == nil && !os.IsNotExist(err) {
+				walkErr = errors.WithStack(err)
+			}
+			return
+		}
+		for _, fi := range fis {
+			if fi.Mode().IsRegular() && strings.HasSuffix(fi.Name(), ".go") {
+				files = append(files, filepath.Join(dir, fi.Name()))
+			}
+		}
+	})
+	if walkErr != nil {
+		return walkErr
+	}
+
+	var wg sync.WaitGroup
+	sema := make(chan struct{}, runtime.NumCPU()) // counting semaphore to limit the parsing concurrency
+	seen := make(map[string]bool, len(files))
+	for _, path := range files {
+		seen[path] = true
+		wg.Add(1)
+		sema <- struct{}{}
+		go func(path string) {
+			defer func() { <-sema; wg.Done() }()
+			ix.update(path)
+		}(path)
+	}
+	wg.Wait()
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for path := range ix.files {
+		if !seen[path] && underRoots(roots, path) {
+			delete(ix.files, path)
+		}
+	}
+
+	return nil
+}
+
+// underRoots reports whether the file of path is under one of the roots.
+func underRoots(roots []pkgindex.Root, path string) bool {
+	for _, root := range roots {
+		if strings.HasPrefix(path, root.Path+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// update re-parses the Go file of path if not indexed or changed.
+func (ix *Index) update(path string) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	modTime, size := fi.ModTime().UnixNano(), fi.Size()
+
+	ix.mu.RLock()
+	f, ok := ix.files[path]
+	ix.mu.RUnlock()
+	if ok && f.modTime == modTime && f.size == size {
+		return
+	}
+
+	fset := token.NewFileSet()
+	// use the partial AST even if the file has the syntax errors
+	astFile, _ := parser.ParseFile(fset, path, nil, 0)
+	if astFile == nil {
+		return
+	}
+
+	ix.mu.Lock()
+	ix.files[path] = &file{modTime: modTime, size: size, symbols: declSymbols(fset, astFile)}
+	ix.mu.Unlock()
+}
+
+// declSymbols returns the top-level declarations and the methods of f.
+func declSymbols(fset *token.FileSet, f *ast.File) []Symbol {
+	var syms []Symbol
+	add := func(id *ast.Ident, recv string, kind Kind) {
+		if id.Name == "_" {
+			return
+		}
+		pos := fset.Position(id.Pos())
+		syms = append(syms, Symbol{Name: id.Name, Recv: recv, Kind: kind, File: pos.Filename, Line: pos.Line, Col: pos.Column})
+	}
+
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil || len(decl.Recv.List) == 0 {
+				add(decl.Name, "", Func)
+				continue
+			}
+			add(decl.Name, recvName(decl.Recv.List[0].Type), Method)
+
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					add(spec.Name, "", Type)
+				case *ast.ValueSpec:
+					kind := Var
+					if decl.Tok == token.CONST {
+						kind = Const
+					}
+					for _, name := range spec.Names {
+						add(name, "", kind)
+					}
+				}
+			}
+		}
+	}
+	return syms
+}
+
+// recvName returns the type name of the receiver type expression.
+func recvName(expr ast.Expr) string {
+	for {
+		switch x := expr.(type) {
+		case *ast.StarExpr:
+			expr = x.X
+		case *ast.ParenExpr:
+			expr = x.X
+		case *ast.IndexExpr: // the generic type
+			expr = x.X
+		case *ast.Ident:
+			return x.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// Match represents the symbol which matches the query.
+type Match struct {
+	Symbol
+	Score int
+}
+
+// Search returns the symbols which fuzzily match the query in the descending order of the score.
+// The query is matched to the qualified name of the symbols, so that "T.M" matches the method M of the type T.
+// Search returns at most limit matches if limit is positive.
+func (ix *Index) Search(query string, limit int) []Match {
+	var matches []Match
+	ix.mu.RLock()
+	for _, f := range ix.files {
+		for _, sym := range f.symbols {
+			if score, ok := Score(query, sym.QualifiedName()); ok {
+				matches = append(matches, Match{Symbol: sym, Score: score})
+			}
+		}
+	}
+	ix.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if an, bn := a.QualifiedName(), b.QualifiedName(); an != bn {
+			if len(an) != len(bn) {
+				return len(an) < len(bn)
+			}
+			return an < bn
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// list of the scores of the fuzzy matching.
+const (
+	scoreMatch       = 1  // the matched character
+	scoreCase        = 1  // the matched character of the same case
+	scoreConsecutive = 4  // the matched character which follows the previous matched character
+	scoreBoundary    = 8  // the matched character at the start of the word
+	scorePrefix      = 16 // the candidate which starts with the query
+	scoreExact       = 32 // the candidate which equals the query ignoring case
+)
+
+// Score reports whether all characters of the query appear in the candidate in order ignoring case,
+// and returns the score of the match. The matches at the start of the words and the consecutive matches score higher.
+func Score(query, candidate string) (int, bool) {
+	q, c := []rune(query), []rune(candidate)
+	if len(q) == 0 {
+		return 0, true
+	}
+
+	score, prev := 0, -2
+	i := 0
+	for j := 0; j < len(c) && i < len(q); j++ {
+		if unicode.ToLower(q[i]) != unicode.ToLower(c[j]) {
+			continue
+		}
+		score += scoreMatch
+		if q[i] == c[j] {
+			score += scoreCase
+		}
+		if j == prev+1 {
+			score += scoreConsecutive
+		}
+		if isBoundary(c, j) {
+			score += scoreBoundary
+		}
+		prev = j
+		i++
+	}
+	if i < len(q) {
+		return 0, false
+	}
+
+	if strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(query)) {
+		score += scorePrefix
+		if len(q) == len(c) {
+			score += scoreExact
+		}
+	}
+	return score, true
+}
+
+// isBoundary reports whether c[j] is the start of the word, such as the first character, the character after
+// '.' or '_', or the upper case character after the lower case character.
+func isBoundary(c []rune, j int) bool {
+	if j == 0 {
+		return true
+	}
+	prev := c[j-1]
+	return prev == '.' || prev == '_' || unicode.IsLower(prev) && unicode.IsUpper(c[j])
+}
@@ -0,0 +1,163 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbols
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/zchee/nvim-go/pkg/internal/gopathwalk"
+	"github.com/zchee/nvim-go/pkg/internal/pkgindex"
+	"github.com/zchee/nvim-go/pkg/internal/testutil"
+)
+
+func names(matches []Match) []string {
+	var names []string
+	for _, m := range matches {
+		names = append(names, m.Kind.String()+" "+m.QualifiedName())
+	}
+	return names
+}
+
+func TestRefresh(t *testing.T) {
+	root, err := ioutil.TempDir("", "symbols")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	testutil.WriteFile(t, filepath.Join(root, "server.go"), `package p
+
+type Server struct{}
+
+func (s *Server) Serve() error { return nil }
+
+func NewServer() *Server { return nil }
+
+const (
+	DefaultPort = 8080
+	_           = 0
+)
+`)
+	testutil.WriteFile(t, filepath.Join(root, "sub/handler.go"), "package sub\n\nvar Handler func()\n")
+	testutil.WriteFile(t, filepath.Join(root, "vendor/example.com/v/v.go"), "package v\n\nfunc Serve() {}\n")
+	testutil.WriteFile(t, filepath.Join(root, "testdata/x.go"), "package x\n\nfunc Serve() {}\n")
+	testutil.WriteFile(t, filepath.Join(root, "nested/go.mod"), "module example.com/nested\n")
+	testutil.WriteFile(t, filepath.Join(root, "nested/n.go"), "package nested\n\nfunc Serve() {}\n")
+
+	roots := []pkgindex.Root{{Root: gopathwalk.Root{Path: root, Type: gopathwalk.RootCurrentModule}, ImportPath: "example.com/p"}}
+	ix := New()
+	if err := ix.Refresh(roots); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"type Server", "method Server.Serve", "func NewServer"}, names(ix.Search("serve", 0))); diff != "" {
+		t.Errorf("Search(serve): (-want +got)\n%s", diff)
+	}
+	want := []Symbol{{Name: "Handler", Kind: Var, File: filepath.Join(root, "sub/handler.go"), Line: 3, Col: 5}}
+	if got := ix.Search("Handler", 0); len(got) != 1 || !cmp.Equal(want[0], got[0].Symbol) {
+		t.Errorf("Search(Handler): got %v, want %v", got, want)
+	}
+
+	// the changed file and the removed file are found by the next refresh
+	testutil.WriteFile(t, filepath.Join(root, "server.go"), "package p\n\nfunc Serve() {}\n\nfunc Listen() {}\n")
+	if err := os.Remove(filepath.Join(root, "sub/handler.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Refresh(roots); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"func Serve"}, names(ix.Search("serve", 0))); diff != "" {
+		t.Errorf("Search(serve): (-want +got)\n%s", diff)
+	}
+	if got := ix.Search("Handler", 0); len(got) != 0 {
+		t.Errorf("Search(Handler): got %v, want the removed file excluded", names(got))
+	}
+}
+
+func TestRefresh_roots(t *testing.T) {
+	dir := t.TempDir()
+	root := func(name string) pkgindex.Root {
+		return pkgindex.Root{Root: gopathwalk.Root{Path: filepath.Join(dir, name), Type: gopathwalk.RootCurrentModule}, ImportPath: "example.com/" + name}
+	}
+	testutil.WriteFiles(t, dir, map[string]string{
+		"a/a.go": "package a\n\nfunc ServeA() {}\n",
+		"b/b.go": "package b\n\nfunc ServeB() {}\n",
+	})
+
+	ix := New()
+	if err := ix.Refresh([]pkgindex.Root{root("a"), root("b")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// refreshing a root keeps the files of the other roots
+	if err := os.Remove(filepath.Join(dir, "a/a.go")); err != nil {
+		t.Fatal(err)
+	}
+	testutil.WriteFile(t, filepath.Join(dir, "a/a2.go"), "package a\n\nfunc ServeA2() {}\n")
+	if err := ix.Refresh([]pkgindex.Root{root("a")}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"func ServeB", "func ServeA2"}, names(ix.Search("serve", 0))); diff != "" {
+		t.Errorf("Search(serve): (-want +got)\n%s", diff)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ix := New()
+	ix.files["p.go"] = &file{symbols: []Symbol{
+		{Name: "NewServer", Kind: Func},
+		{Name: "Serve", Recv: "Server", Kind: Method},
+		{Name: "ServeHTTP", Recv: "Handler", Kind: Method},
+		{Name: "Server", Kind: Type},
+		{Name: "observer", Kind: Var},
+	}}
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{
+			name:  "Prefix",
+			query: "server",
+			want:  []string{"type Server", "method Server.Serve", "func NewServer", "var observer"},
+		},
+		{
+			name:  "Qualified",
+			query: "h.serve",
+			want:  []string{"method Handler.ServeHTTP"},
+		},
+		{
+			name:  "WordBoundary",
+			query: "shttp",
+			want:  []string{"method Handler.ServeHTTP"},
+		},
+		{
+			name:  "Limit",
+			query: "serve",
+			limit: 2,
+			want:  []string{"type Server", "method Server.Serve"},
+		},
+		{
+			name:  "NoMatch",
+			query: "client",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.want, names(ix.Search(tt.query, tt.limit))); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// WriteFile writes data to the file of path, and creates the parent directories of it.
+func WriteFile(tb testing.TB, path, data string) {
+	tb.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		tb.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// WriteFiles writes the files keyed by the slash-separated path relative to dir.
+func WriteFiles(tb testing.TB, dir string, files map[string]string) {
+	tb.Helper()
+
+	for name, data := range files {
+		WriteFile(tb, filepath.Join(dir, filepath.FromSlash(name)), data)
+	}
+}
+
+// TempModule writes the go.mod file of the module modPath and the files to the new temporary directory,
+// and returns the directory which is removed when the test finishes.
+func TempModule(tb testing.TB, modPath string, files map[string]string) string {
+	tb.Helper()
+
+	dir := tb.TempDir()
+	WriteFile(tb, filepath.Join(dir, "go.mod"), "module "+modPath+"\n")
+	WriteFiles(tb, dir, files)
+	return dir
+}
@@ -62,3 +62,15 @@ func matchFile(files []os.FileInfo, filename string) []string {
 	}
 	return filelist
 }
+
+// CompleteItem represents an item of the complete-items which is returned by the 'omnifunc' or 'completefunc'.
+type CompleteItem struct {
+	Word     string `msgpack:"word"`
+	Abbr     string `msgpack:"abbr,omitempty"`
+	Menu     string `msgpack:"menu,omitempty"`
+	Info     string `msgpack:"info,omitempty"`
+	Kind     string `msgpack:"kind,omitempty"`
+	Icase    int    `msgpack:"icase"`
+	Dup      int    `msgpack:"dup"`
+	UserData string `msgpack:"user_data,omitempty"`
+}
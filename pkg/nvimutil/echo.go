@@ -5,6 +5,7 @@
 package nvimutil
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -51,7 +52,7 @@ type stackTracer interface {
 
 // ErrorWrap splits the errors.Wrap's cause and error messages,
 // and provide the vim 'echo' message with 'echohl' highlighting to cause text.
-func ErrorWrap(v *nvim.Nvim, err error) error {
+func ErrorWrap(ctx context.Context, v *nvim.Nvim, err error) error {
 	if err == nil {
 		return nil
 	}
@@ -61,7 +62,7 @@ func ErrorWrap(v *nvim.Nvim, err error) error {
 		st := err.StackTrace()
 		// "%n" verb is function name
 		funcName = fmt.Sprintf("%n", st[0])
-		if config.DebugEnable {
+		if config.FromContext(ctx).Debug.Enable {
 			log.Printf("Error stack%+v", st[:])
 		}
 	}
@@ -0,0 +1,260 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nvimutil
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+)
+
+// ErrStaleEdit is returned when the byte range of an edit no longer contains the expected text.
+var ErrStaleEdit = errors.New("edit range does not contain the expected text")
+
+// TextEdit represents a replacement of the byte range [Start, End) of the file content.
+// If Old is not nil, the range must contain Old.
+type TextEdit struct {
+	Start int
+	End   int
+	Old   []byte
+	New   []byte
+}
+
+// FileEdits represents the edits of each file keyed by the file name.
+type FileEdits map[string][]TextEdit
+
+// ApplyEdits returns the copy of src which applied the edits.
+// The edits must not overlap each other.
+// ApplyEdits returns ErrStaleEdit as the cause if the range of an edit does not contain its Old.
+func ApplyEdits(src []byte, edits []TextEdit) ([]byte, error) {
+	edits = append([]TextEdit(nil), edits...)
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].Start < edits[j].Start })
+
+	var buf bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		if e.Start < last || e.End < e.Start || e.End > len(src) {
+			return nil, errors.Errorf("invalid edit range [%d, %d)", e.Start, e.End)
+		}
+		if e.Old != nil && !bytes.Equal(src[e.Start:e.End], e.Old) {
+			return nil, errors.Wrapf(ErrStaleEdit, "[%d, %d) want %q", e.Start, e.End, e.Old)
+		}
+		buf.Write(src[last:e.Start])
+		buf.Write(e.New)
+		last = e.End
+	}
+	buf.Write(src[last:])
+
+	return buf.Bytes(), nil
+}
+
+// DiffLines returns the minimum replacement of the line range [start, end) of in which makes out.
+// DiffLines returns false if in and out are the same.
+func DiffLines(in, out [][]byte) (start, end int, repl [][]byte, ok bool) {
+	// Find matching head lines.
+	n := len(out)
+	if len(in) < len(out) {
+		n = len(in)
+	}
+	head := 0
+	for ; head < n; head++ {
+		if !bytes.Equal(in[head], out[head]) {
+			break
+		}
+	}
+
+	// Nothing to do?
+	if head == len(in) && head == len(out) {
+		return 0, 0, nil, false
+	}
+
+	// Find matching tail lines.
+	n -= head
+	tail := 0
+	for ; tail < n; tail++ {
+		if !bytes.Equal(in[len(in)-tail-1], out[len(out)-tail-1]) {
+			break
+		}
+	}
+
+	return head, len(in) - tail, out[head : len(out)-tail], true
+}
+
+// LoadedBuffers returns the loaded buffers keyed by the full path of buffer name.
+func LoadedBuffers(v *nvim.Nvim) (map[string]nvim.Buffer, error) {
+	bufs, err := v.Buffers()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	loaded := make(map[string]nvim.Buffer)
+	for _, b := range bufs {
+		if ok, err := v.IsBufferLoaded(b); err != nil || !ok {
+			continue
+		}
+		name, err := v.BufferName(b)
+		if err != nil || name == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(name); err == nil {
+			name = abs
+		}
+		loaded[name] = b
+	}
+
+	return loaded, nil
+}
+
+// ModifiedBuffers returns the contents of modified buffers keyed by the full path of buffer name,
+// which can be used as the overlay of buildutil.OverlayContext.
+func ModifiedBuffers(v *nvim.Nvim) (map[string][]byte, error) {
+	loaded, err := LoadedBuffers(v)
+	if err != nil {
+		return nil, err
+	}
+
+	overlay := make(map[string][]byte)
+	for name, b := range loaded {
+		var modified bool
+		if err := v.BufferOption(b, BufOptionModified, &modified); err != nil || !modified {
+			continue
+		}
+		lines, err := v.BufferLines(b, 0, -1, true)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		overlay[name] = ToByteSlice(lines)
+	}
+
+	return overlay, nil
+}
+
+// ApplyFileEdits applies the edits to the loaded buffers through SetBufferLines,
+// and writes the files which are not loaded.
+// All buffer changes are applied in one atomic call before writing the files, so that the changes can be undone as a single step
+// and no file is written if the buffer changes fail.
+// ApplyFileEdits returns the list of changed buffers, and the error reports the already written files if failed to write a file.
+func ApplyFileEdits(v *nvim.Nvim, edits FileEdits) ([]nvim.Buffer, error) {
+	loaded, err := LoadedBuffers(v)
+	if err != nil {
+		return nil, err
+	}
+
+	filenames := make([]string, 0, len(edits))
+	for filename := range edits {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	// compute all changes at first, so that nothing is changed if any edit is invalid
+	type bufferChange struct {
+		buffer     nvim.Buffer
+		start, end int
+		repl       [][]byte
+	}
+	var changes []bufferChange
+	files := make(map[string][]byte)
+	for _, filename := range filenames {
+		if b, ok := loaded[filename]; ok {
+			lines, err := v.BufferLines(b, 0, -1, true)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			out, err := ApplyEdits(ToByteSlice(lines), edits[filename])
+			if err != nil {
+				return nil, errors.Wrap(err, filename)
+			}
+			if start, end, repl, ok := DiffLines(lines, ToBufferLines(out)); ok {
+				changes = append(changes, bufferChange{buffer: b, start: start, end: end, repl: repl})
+			}
+			continue
+		}
+
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		out, err := ApplyEdits(src, edits[filename])
+		if err != nil {
+			return nil, errors.Wrap(err, filename)
+		}
+		files[filename] = out
+	}
+
+	// apply the buffer changes before writing the files, because the buffer changes can be undone but the files can not
+	changed := make([]nvim.Buffer, 0, len(changes))
+	if len(changes) > 0 {
+		batch := v.NewBatch()
+		for _, c := range changes {
+			batch.SetBufferLines(c.buffer, c.start, c.end, true, c.repl)
+			changed = append(changed, c.buffer)
+		}
+		if err := batch.Execute(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	var written []string
+	for _, filename := range filenames {
+		if out, ok := files[filename]; ok {
+			if err := ioutil.WriteFile(filename, out, 0644); err != nil {
+				if len(written) > 0 {
+					return changed, errors.Wrapf(err, "already written files: %s", strings.Join(written, ", "))
+				}
+				return changed, errors.WithStack(err)
+			}
+			written = append(written, filename)
+		}
+	}
+
+	return changed, nil
+}
+
+// MoveBuffers replaces the loaded buffers of the files under fromDir with the buffers of
+// the moved files under toDir, keeping the windows which display them.
+// The buffer contents must be saved before moving the files.
+func MoveBuffers(v *nvim.Nvim, fromDir, toDir string) error {
+	loaded, err := LoadedBuffers(v)
+	if err != nil {
+		return err
+	}
+	wins, err := v.Windows()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for name, b := range loaded {
+		rel, err := filepath.Rel(fromDir, name)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		var nb nvim.Buffer
+		if err := v.Call("bufadd", &nb, filepath.Join(toDir, rel)); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := v.Call("bufload", nil, nb); err != nil {
+			return errors.WithStack(err)
+		}
+		for _, w := range wins {
+			if wb, err := v.WindowBuffer(w); err == nil && wb == b {
+				if err := v.Call("nvim_win_set_buf", nil, w, nb); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+		}
+		if err := v.Command(fmt.Sprintf("silent! bwipeout! %d", b)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
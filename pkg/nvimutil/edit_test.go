@@ -0,0 +1,151 @@
+// Copyright 2020 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nvimutil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApplyEdits(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		edits   []TextEdit
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Empty",
+			src:  "var foo = 1\n",
+			want: "var foo = 1\n",
+		},
+		{
+			name: "Unsorted",
+			src:  "foo := 1\nbar(foo)\n",
+			edits: []TextEdit{
+				{Start: 13, End: 16, New: []byte("baz")},
+				{Start: 0, End: 3, New: []byte("baz")},
+			},
+			want: "baz := 1\nbar(baz)\n",
+		},
+		{
+			name: "Overlap",
+			src:  "foo := 1\n",
+			edits: []TextEdit{
+				{Start: 0, End: 3, New: []byte("bar")},
+				{Start: 2, End: 4, New: []byte("baz")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Old",
+			src:  "foo := 1\nbar(foo)\n",
+			edits: []TextEdit{
+				{Start: 0, End: 3, Old: []byte("foo"), New: []byte("baz")},
+				{Start: 13, End: 16, Old: []byte("foo"), New: []byte("baz")},
+			},
+			want: "baz := 1\nbar(baz)\n",
+		},
+		{
+			name: "Stale",
+			src:  "qux := 1\nbar(qux)\n",
+			edits: []TextEdit{
+				{Start: 0, End: 3, Old: []byte("foo"), New: []byte("baz")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "OutOfRange",
+			src:  "foo",
+			edits: []TextEdit{
+				{Start: 0, End: 4, New: []byte("bar")},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ApplyEdits([]byte(tt.src), tt.edits)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyEdits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	lines := func(s ...string) [][]byte {
+		b := make([][]byte, len(s))
+		for i := range s {
+			b[i] = []byte(s[i])
+		}
+		return b
+	}
+
+	tests := []struct {
+		name      string
+		in, out   [][]byte
+		wantStart int
+		wantEnd   int
+		wantRepl  [][]byte
+		wantOK    bool
+	}{
+		{
+			name:   "Same",
+			in:     lines("a", "b", "c"),
+			out:    lines("a", "b", "c"),
+			wantOK: false,
+		},
+		{
+			name:      "Middle",
+			in:        lines("a", "b", "c"),
+			out:       lines("a", "x", "y", "c"),
+			wantStart: 1,
+			wantEnd:   2,
+			wantRepl:  lines("x", "y"),
+			wantOK:    true,
+		},
+		{
+			name:      "Delete",
+			in:        lines("a", "b", "c"),
+			out:       lines("a", "c"),
+			wantStart: 1,
+			wantEnd:   2,
+			wantRepl:  lines(),
+			wantOK:    true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			start, end, repl, ok := DiffLines(tt.in, tt.out)
+			if ok != tt.wantOK {
+				t.Fatalf("DiffLines() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("DiffLines() = [%d, %d), want [%d, %d)", start, end, tt.wantStart, tt.wantEnd)
+			}
+			if diff := cmp.Diff(tt.wantRepl, repl); diff != "" {
+				t.Errorf("%s: (-want +got)\n%s", tt.name, diff)
+			}
+		})
+	}
+}
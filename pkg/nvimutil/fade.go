@@ -5,6 +5,7 @@
 package nvimutil
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -40,17 +41,17 @@ func NewFader(n *nvim.Nvim, buffer nvim.Buffer, hlGroup string, startLine, endLi
 }
 
 // SetHighlight sets the highlight to at once.
-func (f *Fade) SetHighlight() error {
+func (f *Fade) SetHighlight(ctx context.Context) error {
 	if f.startLine == f.endLine {
 		if _, err := f.n.AddBufferHighlight(f.buffer, 0, f.hlGroup, f.startLine, f.startCol, f.endCol); err != nil {
-			return ErrorWrap(f.n, errors.WithStack(err))
+			return ErrorWrap(ctx, f.n, errors.WithStack(err))
 		}
 		return nil
 	}
 
 	for i := f.startLine; i < f.endLine; i++ {
 		if _, err := f.n.AddBufferHighlight(f.buffer, 0, f.hlGroup, f.startLine, f.startCol, f.endCol); err != nil {
-			return ErrorWrap(f.n, errors.WithStack(err))
+			return ErrorWrap(ctx, f.n, errors.WithStack(err))
 		}
 	}
 	return nil
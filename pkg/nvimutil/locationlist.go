@@ -5,6 +5,7 @@
 package nvimutil
 
 import (
+	"context"
 	"fmt"
 	"go/token"
 	"path/filepath"
@@ -29,14 +30,6 @@ func SetLoclist(v *nvim.Nvim, loclist []*nvim.QuickfixError) error {
 	return nil
 }
 
-var (
-	listtype     ErrorListType
-	openlistCmd  func() error
-	closelistCmd func() error
-	clearlistCmd func() error
-	setlistCmd   func(errlist []*nvim.QuickfixError) error
-)
-
 // ErrorListType represents a neovim error list type.
 type ErrorListType string
 
@@ -47,38 +40,48 @@ const (
 	LocationList = "locationlist"
 )
 
-func getListCmd(v *nvim.Nvim) {
-	listtype = ErrorListType(config.ErrorListType)
-	switch listtype {
+// errorList represents a Neovim error list commands of the ErrorListType.
+type errorList struct {
+	open  func() error
+	close func() error
+	clear func() error
+	set   func(errlist []*nvim.QuickfixError) error
+}
+
+// newErrorList returns the errorList of the config.Global.ErrorListType which associated with ctx.
+func newErrorList(ctx context.Context, v *nvim.Nvim) *errorList {
+	switch ErrorListType(config.FromContext(ctx).Global.ErrorListType) {
 	case Quickfix:
-		openlistCmd = func() error { return v.Command("copen") }
-		closelistCmd = func() error { return v.Command("cclose") }
-		clearlistCmd = func() error { return v.Command("cgetexpr ''") }
-		setlistCmd = func(errlist []*nvim.QuickfixError) error { return v.Call("setqflist", nil, errlist, "r") }
-	case LocationList:
-		openlistCmd = func() error { return v.Command("lopen") }
-		closelistCmd = func() error { return v.Command("lclose") }
-		clearlistCmd = func() error { return v.Command("lgetexpr ''") }
-		setlistCmd = func(errlist []*nvim.QuickfixError) error { return v.Call("setloclist", nil, 0, errlist, "r") }
+		return &errorList{
+			open:  func() error { return v.Command("copen") },
+			close: func() error { return v.Command("cclose") },
+			clear: func() error { return v.Command("cgetexpr ''") },
+			set:   func(errlist []*nvim.QuickfixError) error { return v.Call("setqflist", nil, errlist, "r") },
+		}
+	default:
+		return &errorList{
+			open:  func() error { return v.Command("lopen") },
+			close: func() error { return v.Command("lclose") },
+			clear: func() error { return v.Command("lgetexpr ''") },
+			set:   func(errlist []*nvim.QuickfixError) error { return v.Call("setloclist", nil, 0, errlist, "r") },
+		}
 	}
 }
 
 // ErrorList merges the errlist map items and open the locationlist window.
-func ErrorList(v *nvim.Nvim, errors map[string][]*nvim.QuickfixError, keep bool) error {
-	if listtype == "" {
-		getListCmd(v)
-	}
+func ErrorList(ctx context.Context, v *nvim.Nvim, errors map[string][]*nvim.QuickfixError, keep bool) error {
+	list := newErrorList(ctx, v)
 
 	if errors == nil || len(errors) == 0 {
-		defer clearlistCmd()
-		return closelistCmd()
+		defer list.clear()
+		return list.close()
 	}
 
 	var errlist []*nvim.QuickfixError
 	for _, err := range errors {
 		errlist = append(errlist, err...)
 	}
-	if err := SetErrorlist(v, errlist); err != nil {
+	if err := list.set(errlist); err != nil {
 		return err
 	}
 
@@ -89,28 +92,22 @@ func ErrorList(v *nvim.Nvim, errors map[string][]*nvim.QuickfixError, keep bool)
 		}
 		defer v.SetCurrentWindow(w)
 	}
-	return openlistCmd()
+	return list.open()
 }
 
 // SetErrorlist set the error results data to Neovim error list.
-func SetErrorlist(v *nvim.Nvim, errlist []*nvim.QuickfixError) error {
-	if setlistCmd == nil {
-		getListCmd(v)
-	}
-
-	return setlistCmd(errlist)
+func SetErrorlist(ctx context.Context, v *nvim.Nvim, errlist []*nvim.QuickfixError) error {
+	return newErrorList(ctx, v).set(errlist)
 }
 
 // ClearErrorlist clear the Neovim error list.
-func ClearErrorlist(v *nvim.Nvim, close bool) error {
-	if clearlistCmd == nil {
-		getListCmd(v)
-	}
+func ClearErrorlist(ctx context.Context, v *nvim.Nvim, close bool) error {
+	list := newErrorList(ctx, v)
 
 	if close {
-		defer closelistCmd()
+		defer list.close()
 	}
-	return clearlistCmd()
+	return list.clear()
 }
 
 // OpenLoclist open or close the current buffer's locationlist window.
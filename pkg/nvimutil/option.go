@@ -47,6 +47,10 @@ const (
 	WinOptionRelativenumber = "relativenumber" // bool
 	// WinOptionWinfixheight represents a winfixheight.
 	WinOptionWinfixheight = "winfixheight" // bool
+	// WinOptionWinfixwidth represents a winfixwidth.
+	WinOptionWinfixwidth = "winfixwidth" // bool
+	// WinOptionWrap represents a wrap.
+	WinOptionWrap = "wrap" // bool
 )
 
 const (
@@ -89,6 +93,14 @@ const (
 	FiletypeSh = "sh"
 	// FiletypeTerminal represents a terminal filetype.
 	FiletypeTerminal = "terminal"
+	// FiletypeMarkdown represents a markdown filetype.
+	FiletypeMarkdown = "markdown"
 	// FiletypeGoTerminal represents a go-terminal filetype.
 	FiletypeGoTerminal = "goterminal"
+	// FiletypeGoConfig represents a go-config filetype.
+	FiletypeGoConfig = "goconfig"
+	// FiletypeGoRename represents a go-rename filetype.
+	FiletypeGoRename = "gorename"
+	// FiletypeGoAnalyze represents a go-analyze filetype.
+	FiletypeGoAnalyze = "goanalyze"
 )
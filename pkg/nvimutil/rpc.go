@@ -6,10 +6,8 @@ package nvimutil
 
 import (
 	"github.com/neovim/go-client/nvim"
-
-	"github.com/zchee/nvim-go/pkg/config"
 )
 
 func Notify(n *nvim.Nvim, method string, args ...string) error {
-	return n.Call("rpcnotify", nil, config.ChannelID, method, args)
+	return n.Call("rpcnotify", nil, n.ChannelID(), method, args)
 }
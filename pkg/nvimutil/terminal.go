@@ -5,8 +5,10 @@
 package nvimutil
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -27,6 +29,8 @@ type Terminal struct {
 	Name string
 	// Dir specifies the working directory of the command on terminal.
 	Dir string
+	// Env specifies the additional environment variables of the command on terminal, in the form "key=value".
+	Env []string
 	// Size open the terminal window size.
 	Size int
 
@@ -52,7 +56,10 @@ func NewTerminal(n *nvim.Nvim, name string, command []string, mode string) *Term
 }
 
 // Create creats the new Neovim terminal buffer.
-func (t *Terminal) Create() (err error) {
+// The terminal window size and position are the config.Terminal which associated with ctx.
+func (t *Terminal) Create(ctx context.Context) (err error) {
+	cfg := config.FromContext(ctx).Terminal
+
 	t.cw, err = t.Nvim.CurrentWindow()
 	if err != nil {
 		return err
@@ -63,18 +70,20 @@ func (t *Terminal) Create() (err error) {
 
 	switch {
 	case t.mode == "split":
-		t.Size = t.getSplitWindowSize(config.TerminalHeight, t.Nvim.WindowHeight)
+		t.Size = t.getSplitWindowSize(cfg.Height, t.Nvim.WindowHeight)
 		t.Buffer.Height = t.Size
 	case t.mode == "vsplit":
-		t.Size = t.getSplitWindowSize(config.TerminalWidth, t.Nvim.WindowWidth)
+		t.Size = t.getSplitWindowSize(cfg.Width, t.Nvim.WindowWidth)
 		t.Buffer.Width = t.Size
 	default:
 		// nothing to do
 	}
 
 	option := t.setTerminalOption()
-	name := fmt.Sprintf("| terminal %s", strings.Join(t.cmd, " "))
-	mode := fmt.Sprintf("%s %d%s", config.TerminalPosition, t.Size, t.mode)
+	// open the terminal by termopen() instead of ":terminal" to run the command with the Dir and Env,
+	// without changing the working directory and the environment variables of Neovim and nvim-go
+	name := fmt.Sprintf("| enew | call termopen(%s, %s)", vimLiteral(t.cmd), vimLiteral(t.jobOptions()))
+	mode := fmt.Sprintf("%s %d%s", cfg.Position, t.Size, t.mode)
 
 	t.Buffer.Create(name, FiletypeTerminal, mode, option)
 	t.Buffer.Name = t.Name
@@ -95,25 +104,18 @@ func (t *Terminal) Create() (err error) {
 }
 
 // Run runs the command in the terminal buffer.
-func (t *Terminal) Run(cmd []string) error {
-	if t.Dir != "" {
-		defer fs.Chdir(t.Nvim, t.Dir)()
-	}
-
+func (t *Terminal) Run(ctx context.Context, cmd []string) error {
 	if t.Buffer != nil && IsBufferValid(t.Nvim, t.buffer) {
 		defer t.switchFocus()()
 
 		t.Nvim.SetBufferOption(t.buffer, BufOptionModified, false)
-		t.Nvim.Call("termopen", nil, cmd)
+		t.Nvim.Call("termopen", nil, cmd, t.jobOptions())
 		t.Nvim.SetBufferName(t.buffer, t.Buffer.Name)
-		if fs.IsExist(filepath.Join(fs.FindVCSRoot(t.Dir), "go.mod")) {
-			os.Setenv("GO111MODULE", "on")
-		}
 	} else {
-		t.Create()
+		t.Create(ctx)
 	}
 	// Workaround for "autocmd BufEnter term://* startinsert"
-	if config.TerminalStopInsert {
+	if config.FromContext(ctx).Terminal.StopInsert {
 		t.Nvim.Command("stopinsert")
 	}
 
@@ -125,6 +127,39 @@ func (t *Terminal) Run(cmd []string) error {
 	return errors.WithStack(t.Nvim.SetWindowCursor(t.Window, [2]int{lines, 0}))
 }
 
+// jobOptions returns the options of termopen() which specifies the working directory and the environment variables.
+func (t *Terminal) jobOptions() map[string]interface{} {
+	opts := make(map[string]interface{})
+
+	env := make(map[string]string)
+	for _, kv := range t.Env {
+		if i := strings.Index(kv, "="); i > 0 {
+			env[kv[:i]] = kv[i+1:]
+		}
+	}
+	if t.Dir != "" {
+		opts["cwd"] = t.Dir
+		if fs.IsExist(filepath.Join(fs.FindVCSRoot(t.Dir), "go.mod")) {
+			env["GO111MODULE"] = "on"
+		}
+	}
+	if len(env) > 0 {
+		opts["env"] = env
+	}
+
+	return opts
+}
+
+// vimLiteral returns the Vim script literal of v.
+// The JSON values of strings, lists and dictionaries are also valid Vim script literals.
+func vimLiteral(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return strings.TrimSpace(buf.String())
+}
+
 // getSplitWindowSize return the one third of window (height|width) size if cfg is 0
 func (t *Terminal) getSplitWindowSize(cfg int64, f func(nvim.Window) (int, error)) int {
 	if cfg == 0 {
@@ -5,9 +5,14 @@
 package nvimutil
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/neovim/go-client/nvim"
+
+	"github.com/zchee/nvim-go/pkg/internal/testutil"
 )
 
 func TestTerminal_getSplitWindowSize(t *testing.T) {
@@ -74,3 +79,24 @@ func TestTerminal_getSplitWindowSize(t *testing.T) {
 		})
 	}
 }
+
+func TestTerminal_jobOptions(t *testing.T) {
+	dir := testutil.TempModule(t, "example.com/foo", nil)
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	term := &Terminal{Dir: dir, Env: []string{"GOPATH=/gb:/gb/vendor"}}
+	want := map[string]interface{}{
+		"cwd": dir,
+		"env": map[string]string{"GOPATH": "/gb:/gb/vendor", "GO111MODULE": "on"},
+	}
+	if diff := cmp.Diff(want, term.jobOptions()); diff != "" {
+		t.Errorf("jobOptions: (-want +got)\n%s", diff)
+	}
+
+	wantLiteral := `{"cwd":"` + dir + `","env":{"GO111MODULE":"on","GOPATH":"/gb:/gb/vendor"}}`
+	if got := vimLiteral(term.jobOptions()); got != wantLiteral {
+		t.Errorf("vimLiteral = %s, want %s", got, wantLiteral)
+	}
+}
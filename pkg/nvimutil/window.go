@@ -5,10 +5,139 @@
 package nvimutil
 
 import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
 )
 
 // WindowContext represents a Neovim window context.
 type WindowContext struct {
 	nvim.Window
 }
+
+// IsWindowValid wrapper of nvim.IsWindowValid function.
+func IsWindowValid(n *nvim.Nvim, w nvim.Window) bool {
+	res, err := n.IsWindowValid(w)
+	if err != nil {
+		return false
+	}
+	return res
+}
+
+// list of the maximum size of the floating window.
+const (
+	floatMaxWidth  = 80
+	floatMaxHeight = 20
+)
+
+// Float represents a floating window which is opened at the cursor.
+type Float struct {
+	Window nvim.Window
+	Buffer nvim.Buffer
+}
+
+// OpenFloat opens the scratch floating window of lines below the cursor.
+// The floating window is closed by the closeEvents autocmd of the current buffer, such as "CursorMoved".
+func OpenFloat(n *nvim.Nvim, lines [][]byte, filetype string, closeEvents ...string) (*Float, error) {
+	return openFloat(n, lines, filetype, false, closeEvents)
+}
+
+// OpenFloatAbove is like OpenFloat, but opens the floating window above the cursor
+// so as not to hide the completion menu.
+func OpenFloatAbove(n *nvim.Nvim, lines [][]byte, filetype string, closeEvents ...string) (*Float, error) {
+	return openFloat(n, lines, filetype, true, closeEvents)
+}
+
+func openFloat(n *nvim.Nvim, lines [][]byte, filetype string, above bool, closeEvents []string) (*Float, error) {
+	source, err := n.CurrentBuffer()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	b, err := n.CreateBuffer(false, true)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	f := &Float{Buffer: b}
+
+	batch := n.NewBatch()
+	batch.SetBufferLines(b, 0, -1, true, lines)
+	batch.SetBufferOption(b, BufOptionBufhidden, BufhiddenWipe)
+	batch.SetBufferOption(b, BufOptionFiletype, filetype)
+	batch.SetBufferOption(b, BufOptionModifiable, false)
+	if err := batch.Execute(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	width, height := floatSize(lines)
+	config := &nvim.WindowConfig{
+		Relative: "cursor",
+		Row:      1,
+		Width:    width,
+		Height:   height,
+		Style:    "minimal",
+	}
+	if above {
+		config.Anchor, config.Row = "SW", 0
+	}
+	f.Window, err = n.OpenWindow(b, false, config)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(closeEvents) > 0 {
+		autocmd := fmt.Sprintf("autocmd %s <buffer=%d> ++once silent! call nvim_win_close(%d, v:true)", strings.Join(closeEvents, ","), source, f.Window)
+		if err := n.Command(autocmd); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return f, nil
+}
+
+// SetLines replaces the lines of the floating window, and resizes the window to fit the lines.
+func (f *Float) SetLines(n *nvim.Nvim, lines [][]byte) error {
+	width, height := floatSize(lines)
+
+	batch := n.NewBatch()
+	batch.SetBufferOption(f.Buffer, BufOptionModifiable, true)
+	batch.SetBufferLines(f.Buffer, 0, -1, true, lines)
+	batch.SetBufferOption(f.Buffer, BufOptionModifiable, false)
+	batch.SetWindowWidth(f.Window, width)
+	batch.SetWindowHeight(f.Window, height)
+	batch.SetWindowCursor(f.Window, [2]int{1, 0})
+	if err := batch.Execute(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// floatSize returns the size of the floating window which shows lines with wrapping.
+func floatSize(lines [][]byte) (width, height int) {
+	for _, line := range lines {
+		if w := utf8.RuneCount(line); w > width {
+			width = w
+		}
+	}
+	if width > floatMaxWidth {
+		width = floatMaxWidth
+	}
+	if width == 0 {
+		width = 1
+	}
+
+	for _, line := range lines {
+		height += 1 + (utf8.RuneCount(line)-1)/width // the empty line is also counted as 1
+	}
+	if height > floatMaxHeight {
+		height = floatMaxHeight
+	}
+	if height == 0 {
+		height = 1
+	}
+
+	return width, height
+}
@@ -23,6 +23,14 @@ else
   let s:plugin_cmd = [s:plugin_root . '/bin/' . s:plugin_name]
 endif
 
+" forward the requests to the nvim-go daemon which shared with other Neovim instances
+if get(g:, 'go#daemon', 0)
+  let s:plugin_cmd += ['-client']
+  if !empty(get(g:, 'go#daemon#socket', ''))
+    let s:plugin_cmd += ['-socket', g:go#daemon#socket]
+  endif
+endif
+
 function! s:JobStart(host) abort
   try
     return jobstart(s:plugin_cmd, {'rpc': v:true, 'detach': v:false})
@@ -38,34 +46,90 @@ endfunction
 
 call remote#host#Register(s:plugin_name, '', function('s:JobStart'))
 call remote#host#RegisterPlugin('nvim-go', '0', [
-\ {'type': 'autocmd', 'name': 'BufEnter', 'sync': 0, 'opts': {'eval': '{''BufNr'': bufnr(''%''), ''WinID'': win_getid(), ''Dir'': expand(''%:p:h''), ''Cfg'': {''Global'': {''ServerName'': v:servername, ''ErrorListType'': get(g:, ''go#global#errorlisttype'', ''locationlist'')}, ''Build'': {''Appengine'': get(g:, ''go#build#appengine'', v:false), ''Autosave'': get(g:, ''go#build#autosave'', v:false), ''Force'': get(g:, ''go#build#force'', v:false), ''Flags'': get(g:, ''go#build#flags'', []), ''IsNotGb'': get(g:, ''go#build#is_not_gb'', v:false)}, ''Cover'': {''Flags'': get(g:, ''go#cover#flags'', []), ''Mode'': get(g:, ''go#cover#mode'', ''atomic'')}, ''Fmt'': {''Autosave'': get(g:, ''go#fmt#autosave'', v:false), ''Mode'': get(g:, ''go#fmt#mode'', ''goimports''), ''GoImportsLocal'': get(g:, ''go#fmt#goimports_local'', [])}, ''Generate'': {''TestAllFuncs'': get(g:, ''go#generate#test#allfuncs'', v:true), ''TestExclFuncs'': get(g:, ''go#generate#test#exclude'', ''''), ''TestExportedFuncs'': get(g:, ''go#generate#test#exportedfuncs'', v:false), ''TestSubTest'': get(g:, ''go#generate#test#subtest'', v:true), ''TestParallel'': get(g:, ''go#generate#test#parallel'', v:true), ''TestTemplateDir'': get(g:, ''go#generate#test#template_dir'', ''''), ''TemplateParamsPath'': get(g:, ''go#generate#test#template_params_path'', '''')}, ''Guru'': {''Reflection'': get(g:, ''go#guru#reflection'', v:false), ''KeepCursor'': get(g:, ''go#guru#keep_cursor'', {''callees'':v:false,''callers'':v:false,''callstack'':v:false,''definition'':v:false,''describe'':v:false,''freevars'':v:false,''implements'':v:false,''peers'':v:false,''pointsto'':v:false,''referrers'':v:false,''whicherrs'':v:false}), ''JumpFirst'': get(g:, ''go#guru#jump_first'', v:false)}, ''Iferr'': {''Autosave'': get(g:, ''go#iferr#autosave'', v:false)}, ''Lint'': {''GolintAutosave'': get(g:, ''go#lint#golint#autosave'', v:false), ''GolintIgnore'': get(g:, ''go#lint#golint#ignore'', []), ''GolintMinConfidence'': get(g:, ''go#lint#golint#min_confidence'', 0.8), ''GolintMode'': get(g:, ''go#lint#golint#mode'', ''current''), ''GoVetAutosave'': get(g:, ''go#lint#govet#autosave'', v:false), ''GoVetFlags'': get(g:, ''go#lint#govet#flags'', []), ''GoVetIgnore'': get(g:, ''go#lint#govet#ignore'', []), ''MetalinterAutosave'': get(g:, ''go#lint#metalinter#autosave'', v:false), ''MetalinterAutosaveTools'': get(g:, ''go#lint#metalinter#autosave#tools'', [''vet'', ''golint'']), ''MetalinterTools'': get(g:, ''go#lint#metalinter#tools'', [''vet'', ''golint'']), ''MetalinterDeadline'': get(g:, ''go#lint#metalinter#deadline'', ''5s''), ''MetalinterSkipDir'': get(g:, ''go#lint#metalinter#skip_dir'', [])}, ''Rename'': {''Prefill'': get(g:, ''go#rename#prefill'', v:false)}, ''Terminal'': {''Mode'': get(g:, ''go#terminal#mode'', ''vsplit''), ''Position'': get(g:, ''go#terminal#position'', ''belowright''), ''Height'': get(g:, ''go#terminal#height'', 0), ''Width'': get(g:, ''go#terminal#width'', 0), ''StopInsert'': get(g:, ''go#terminal#stop_insert'', v:true)}, ''Test'': {''AllPackage'': get(g:, ''go#test#all_package'', v:false), ''Autosave'': get(g:, ''go#test#autosave'', v:false), ''Flags'': get(g:, ''go#test#flags'', [])}, ''Debug'': {''Enable'': get(g:, ''go#debug'', v:false), ''Pprof'': get(g:, ''go#debug#pprof'', v:false)}}}', 'group': 'nvim-go', 'pattern': '*.go'}},
+\ {'type': 'autocmd', 'name': 'BufEnter', 'sync': 0, 'opts': {'eval': '{''BufNr'': bufnr(''%''), ''WinID'': win_getid(), ''Dir'': expand(''%:p:h''), ''File'': expand(''%:p''), ''Cfg'': {''Global'': {''ServerName'': v:servername, ''ErrorListType'': get(g:, ''go#global#errorlisttype'', ''locationlist'')}, ''Build'': {''Appengine'': get(g:, ''go#build#appengine'', v:false), ''Autosave'': get(g:, ''go#build#autosave'', v:false), ''Force'': get(g:, ''go#build#force'', v:false), ''Flags'': get(g:, ''go#build#flags'', []), ''IsNotGb'': get(g:, ''go#build#is_not_gb'', v:false)}, ''Cover'': {''Flags'': get(g:, ''go#cover#flags'', []), ''Mode'': get(g:, ''go#cover#mode'', ''atomic'')}, ''Fmt'': {''Autosave'': get(g:, ''go#fmt#autosave'', v:false), ''Mode'': get(g:, ''go#fmt#mode'', ''goimports''), ''GoImportsLocal'': get(g:, ''go#fmt#goimports_local'', [])}, ''FillStruct'': {''Recursive'': get(g:, ''go#fillstruct#recursive'', v:false)}, ''Generate'': {''TestAllFuncs'': get(g:, ''go#generate#test#allfuncs'', v:true), ''TestExclFuncs'': get(g:, ''go#generate#test#exclude'', ''''), ''TestExportedFuncs'': get(g:, ''go#generate#test#exportedfuncs'', v:false), ''TestSubTest'': get(g:, ''go#generate#test#subtest'', v:true), ''TestParallel'': get(g:, ''go#generate#test#parallel'', v:true), ''TestTemplateDir'': get(g:, ''go#generate#test#template_dir'', ''''), ''TemplateParamsPath'': get(g:, ''go#generate#test#template_params_path'', '''')}, ''Guru'': {''Reflection'': get(g:, ''go#guru#reflection'', v:false), ''KeepCursor'': get(g:, ''go#guru#keep_cursor'', {''callees'':v:false,''callers'':v:false,''callstack'':v:false,''definition'':v:false,''describe'':v:false,''freevars'':v:false,''implements'':v:false,''peers'':v:false,''pointsto'':v:false,''referrers'':v:false,''whicherrs'':v:false}), ''JumpFirst'': get(g:, ''go#guru#jump_first'', v:false)}, ''Highlight'': {''Semantic'': get(g:, ''go#highlight#semantic'', v:false)}, ''Iferr'': {''Autosave'': get(g:, ''go#iferr#autosave'', v:false), ''Template'': get(g:, ''go#iferr#template'', '''')}, ''Lint'': {''GolintAutosave'': get(g:, ''go#lint#golint#autosave'', v:false), ''GolintIgnore'': get(g:, ''go#lint#golint#ignore'', []), ''GolintMinConfidence'': get(g:, ''go#lint#golint#min_confidence'', 0.8), ''GolintMode'': get(g:, ''go#lint#golint#mode'', ''current''), ''GoVetAutosave'': get(g:, ''go#lint#govet#autosave'', v:false), ''GoVetFlags'': get(g:, ''go#lint#govet#flags'', []), ''GoVetIgnore'': get(g:, ''go#lint#govet#ignore'', []), ''MetalinterAutosave'': get(g:, ''go#lint#metalinter#autosave'', v:false), ''MetalinterAutosaveTools'': get(g:, ''go#lint#metalinter#autosave#tools'', [''vet'', ''golint'']), ''MetalinterTools'': get(g:, ''go#lint#metalinter#tools'', [''vet'', ''golint'']), ''MetalinterDeadline'': get(g:, ''go#lint#metalinter#deadline'', ''5s''), ''MetalinterSkipDir'': get(g:, ''go#lint#metalinter#skip_dir'', [])}, ''Rename'': {''Prefill'': get(g:, ''go#rename#prefill'', v:false), ''Preview'': get(g:, ''go#rename#preview'', v:false)}, ''Signature'': {''Enable'': get(g:, ''go#signature#enable'', v:true)}, ''Symbols'': {''Deps'': get(g:, ''go#symbols#deps'', v:false), ''List'': get(g:, ''go#symbols#list'', ''quickfix'')}, ''Terminal'': {''Mode'': get(g:, ''go#terminal#mode'', ''vsplit''), ''Position'': get(g:, ''go#terminal#position'', ''belowright''), ''Height'': get(g:, ''go#terminal#height'', 0), ''Width'': get(g:, ''go#terminal#width'', 0), ''StopInsert'': get(g:, ''go#terminal#stop_insert'', v:true)}, ''Test'': {''AllPackage'': get(g:, ''go#test#all_package'', v:false), ''Autosave'': get(g:, ''go#test#autosave'', v:false), ''Flags'': get(g:, ''go#test#flags'', [])}, ''Debug'': {''Enable'': get(g:, ''go#debug'', v:false), ''Pprof'': get(g:, ''go#debug#pprof'', v:false)}}}', 'group': 'nvim-go', 'pattern': '*.go'}},
 \ {'type': 'autocmd', 'name': 'BufNewFile,BufReadPre', 'sync': 0, 'opts': {'eval': '{}', 'group': 'nvim-go', 'pattern': '*'}},
 \ {'type': 'autocmd', 'name': 'BufWritePost', 'sync': 0, 'opts': {'eval': '{''Cwd'': getcwd(), ''File'': expand(''%:p'')}', 'group': 'nvim-go', 'pattern': '*.go'}},
 \ {'type': 'autocmd', 'name': 'BufWritePre', 'sync': 0, 'opts': {'eval': '{''Cwd'': getcwd(), ''File'': expand(''%:p'')}', 'group': 'nvim-go', 'pattern': '*.go'}},
+\ {'type': 'autocmd', 'name': 'CompleteDone', 'sync': 0, 'opts': {'eval': '{''BufNr'': bufnr(''%''), ''UserData'': get(v:completed_item, ''user_data'', '''')}', 'group': 'nvim-go', 'pattern': '*.go'}},
+\ {'type': 'autocmd', 'name': 'CursorMoved', 'sync': 0, 'opts': {'eval': '{''BufNr'': bufnr(''%''), ''Line'': line(''.'')}', 'group': 'nvim-go', 'pattern': '*.go'}},
+\ {'type': 'autocmd', 'name': 'CursorMovedI,TextChangedI', 'sync': 0, 'opts': {'eval': '{''BufNr'': bufnr(''%''), ''File'': expand(''%:p''), ''Line'': line(''.''), ''Col'': col(''.'')}', 'group': 'nvim-go', 'pattern': '*.go'}},
+\ {'type': 'autocmd', 'name': 'TextChanged,InsertLeave', 'sync': 0, 'opts': {'eval': '{''BufNr'': bufnr(''%''), ''File'': expand(''%:p'')}', 'group': 'nvim-go', 'pattern': '*.go'}},
 \ {'type': 'autocmd', 'name': 'VimLeavePre', 'sync': 0, 'opts': {'group': 'nvim-go', 'pattern': '*.go'}},
+\ {'type': 'command', 'name': 'GoAddTags', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')', 'nargs': '*', 'range': ''}},
 \ {'type': 'command', 'name': 'GoBuffers', 'sync': 0, 'opts': {}},
 \ {'type': 'command', 'name': 'GoBuild', 'sync': 0, 'opts': {'bang': '', 'eval': '[getcwd(), expand(''%:p'')]', 'nargs': '*'}},
 \ {'type': 'command', 'name': 'GoByteOffset', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')'}},
+\ {'type': 'command', 'name': 'GoComment', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')'}},
+\ {'type': 'command', 'name': 'GoCommentMissing', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')'}},
+\ {'type': 'command', 'name': 'GoConfig', 'sync': 0, 'opts': {'eval': '{''Vars'': filter(copy(g:), {k -> k =~# ''^go#''})}'}},
+\ {'type': 'command', 'name': 'GoConfigReload', 'sync': 0, 'opts': {'eval': '{''Dir'': expand(''%:p:h''), ''Cfg'': {''Global'': {''ServerName'': v:servername, ''ErrorListType'': get(g:, ''go#global#errorlisttype'', ''locationlist'')}, ''Build'': {''Appengine'': get(g:, ''go#build#appengine'', v:false), ''Autosave'': get(g:, ''go#build#autosave'', v:false), ''Force'': get(g:, ''go#build#force'', v:false), ''Flags'': get(g:, ''go#build#flags'', []), ''IsNotGb'': get(g:, ''go#build#is_not_gb'', v:false)}, ''Cover'': {''Flags'': get(g:, ''go#cover#flags'', []), ''Mode'': get(g:, ''go#cover#mode'', ''atomic'')}, ''Fmt'': {''Autosave'': get(g:, ''go#fmt#autosave'', v:false), ''Mode'': get(g:, ''go#fmt#mode'', ''goimports''), ''GoImportsLocal'': get(g:, ''go#fmt#goimports_local'', [])}, ''FillStruct'': {''Recursive'': get(g:, ''go#fillstruct#recursive'', v:false)}, ''Generate'': {''TestAllFuncs'': get(g:, ''go#generate#test#allfuncs'', v:true), ''TestExclFuncs'': get(g:, ''go#generate#test#exclude'', ''''), ''TestExportedFuncs'': get(g:, ''go#generate#test#exportedfuncs'', v:false), ''TestSubTest'': get(g:, ''go#generate#test#subtest'', v:true), ''TestParallel'': get(g:, ''go#generate#test#parallel'', v:true), ''TestTemplateDir'': get(g:, ''go#generate#test#template_dir'', ''''), ''TemplateParamsPath'': get(g:, ''go#generate#test#template_params_path'', '''')}, ''Guru'': {''Reflection'': get(g:, ''go#guru#reflection'', v:false), ''KeepCursor'': get(g:, ''go#guru#keep_cursor'', {''callees'':v:false,''callers'':v:false,''callstack'':v:false,''definition'':v:false,''describe'':v:false,''freevars'':v:false,''implements'':v:false,''peers'':v:false,''pointsto'':v:false,''referrers'':v:false,''whicherrs'':v:false}), ''JumpFirst'': get(g:, ''go#guru#jump_first'', v:false)}, ''Highlight'': {''Semantic'': get(g:, ''go#highlight#semantic'', v:false)}, ''Iferr'': {''Autosave'': get(g:, ''go#iferr#autosave'', v:false), ''Template'': get(g:, ''go#iferr#template'', '''')}, ''Lint'': {''GolintAutosave'': get(g:, ''go#lint#golint#autosave'', v:false), ''GolintIgnore'': get(g:, ''go#lint#golint#ignore'', []), ''GolintMinConfidence'': get(g:, ''go#lint#golint#min_confidence'', 0.8), ''GolintMode'': get(g:, ''go#lint#golint#mode'', ''current''), ''GoVetAutosave'': get(g:, ''go#lint#govet#autosave'', v:false), ''GoVetFlags'': get(g:, ''go#lint#govet#flags'', []), ''GoVetIgnore'': get(g:, ''go#lint#govet#ignore'', []), ''MetalinterAutosave'': get(g:, ''go#lint#metalinter#autosave'', v:false), ''MetalinterAutosaveTools'': get(g:, ''go#lint#metalinter#autosave#tools'', [''vet'', ''golint'']), ''MetalinterTools'': get(g:, ''go#lint#metalinter#tools'', [''vet'', ''golint'']), ''MetalinterDeadline'': get(g:, ''go#lint#metalinter#deadline'', ''5s''), ''MetalinterSkipDir'': get(g:, ''go#lint#metalinter#skip_dir'', [])}, ''Rename'': {''Prefill'': get(g:, ''go#rename#prefill'', v:false), ''Preview'': get(g:, ''go#rename#preview'', v:false)}, ''Signature'': {''Enable'': get(g:, ''go#signature#enable'', v:true)}, ''Symbols'': {''Deps'': get(g:, ''go#symbols#deps'', v:false), ''List'': get(g:, ''go#symbols#list'', ''quickfix'')}, ''Terminal'': {''Mode'': get(g:, ''go#terminal#mode'', ''vsplit''), ''Position'': get(g:, ''go#terminal#position'', ''belowright''), ''Height'': get(g:, ''go#terminal#height'', 0), ''Width'': get(g:, ''go#terminal#width'', 0), ''StopInsert'': get(g:, ''go#terminal#stop_insert'', v:true)}, ''Test'': {''AllPackage'': get(g:, ''go#test#all_package'', v:false), ''Autosave'': get(g:, ''go#test#autosave'', v:false), ''Flags'': get(g:, ''go#test#flags'', [])}, ''Debug'': {''Enable'': get(g:, ''go#debug'', v:false), ''Pprof'': get(g:, ''go#debug#pprof'', v:false)}}}'}},
 \ {'type': 'command', 'name': 'GoCover', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p'')]', 'nargs': '?'}},
 \ {'type': 'command', 'name': 'GoCoverClear', 'sync': 0, 'opts': {}},
+\ {'type': 'command', 'name': 'GoDoc', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')', 'nargs': '?'}},
+\ {'type': 'command', 'name': 'GoDocBrowse', 'sync': 0, 'opts': {'complete': 'customlist,GoDocBrowseCompletion', 'eval': '[getcwd(), expand(''%:p'')]', 'nargs': '?'}},
+\ {'type': 'command', 'name': 'GoDocBrowseJump', 'sync': 0, 'opts': {'eval': '[expand(''%''), getline(''.''), line(''.''), col(''.'')]'}},
+\ {'type': 'command', 'name': 'GoDocFollow', 'sync': 0, 'opts': {'eval': '[getline(''.''), col(''.'')]'}},
+\ {'type': 'command', 'name': 'GoExtractFunc', 'sync': 0, 'opts': {'eval': '[expand(''%:p''), getpos("''<"), getpos("''>")]', 'nargs': '1', 'range': ''}},
+\ {'type': 'command', 'name': 'GoExtractVar', 'sync': 0, 'opts': {'eval': '[expand(''%:p''), getpos("''<"), getpos("''>")]', 'nargs': '1', 'range': ''}},
+\ {'type': 'command', 'name': 'GoFillStruct', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')'}},
 \ {'type': 'command', 'name': 'GoFmt', 'sync': 0, 'opts': {'eval': 'expand(''%:p:h'')'}},
 \ {'type': 'command', 'name': 'GoGenerateTest', 'sync': 0, 'opts': {'addr': 'line', 'bang': '', 'complete': 'file', 'eval': 'expand(''%:p:h'')', 'nargs': '*', 'range': '%'}},
+\ {'type': 'command', 'name': 'GoHighlight', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')'}},
 \ {'type': 'command', 'name': 'GoIferr', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')'}},
+\ {'type': 'command', 'name': 'GoImpl', 'sync': 0, 'opts': {'complete': 'customlist,GoImplCompletion', 'eval': '[getcwd(), expand(''%:p'')]', 'nargs': '+'}},
+\ {'type': 'command', 'name': 'GoImplementations', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p''), &modified, line2byte(line(''.'')) + (col(''.'')-2)]'}},
+\ {'type': 'command', 'name': 'GoImport', 'sync': 0, 'opts': {'complete': 'customlist,GoImportCompletion', 'eval': '[getcwd(), expand(''%:p'')]', 'nargs': '1'}},
+\ {'type': 'command', 'name': 'GoInline', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')'}},
+\ {'type': 'command', 'name': 'GoInterfaces', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p''), &modified, line2byte(line(''.'')) + (col(''.'')-2)]'}},
 \ {'type': 'command', 'name': 'GoLint', 'sync': 0, 'opts': {'complete': 'customlist,GoLintCompletion', 'eval': 'expand(''%:p'')', 'nargs': '?'}},
 \ {'type': 'command', 'name': 'GoMetalinter', 'sync': 0, 'opts': {'eval': 'getcwd()'}},
+\ {'type': 'command', 'name': 'GoMove', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p'')]', 'nargs': '1'}},
 \ {'type': 'command', 'name': 'GoNotify', 'sync': 0, 'opts': {'nargs': '*'}},
-\ {'type': 'command', 'name': 'GoRename', 'sync': 0, 'opts': {'bang': '', 'eval': '[getcwd(), expand(''%:p''), expand(''<cword>'')]', 'nargs': '?'}},
+\ {'type': 'command', 'name': 'GoOutline', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')'}},
+\ {'type': 'command', 'name': 'GoOutlineJump', 'sync': 0, 'opts': {'eval': 'line(''.'')'}},
+\ {'type': 'command', 'name': 'GoPickerJump', 'sync': 0, 'opts': {'eval': 'line(''.'')'}},
+\ {'type': 'command', 'name': 'GoRemoveTags', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')', 'nargs': '*', 'range': ''}},
+\ {'type': 'command', 'name': 'GoRename', 'sync': 0, 'opts': {'bang': '', 'eval': '[getcwd(), expand(''%:p''), expand(''<cword>'')]', 'nargs': '*'}},
+\ {'type': 'command', 'name': 'GoRenameApply', 'sync': 0, 'opts': {}},
+\ {'type': 'command', 'name': 'GoRenamePackage', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p'')]', 'nargs': '1'}},
 \ {'type': 'command', 'name': 'GoRun', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')', 'nargs': '*'}},
 \ {'type': 'command', 'name': 'GoRunLast', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')'}},
 \ {'type': 'command', 'name': 'GoSwitchTest', 'sync': 0, 'opts': {'eval': '{''Cwd'': getcwd(), ''File'': expand(''%:p''), ''Offset'': line2byte(line(''.'')) + (col(''.'')-2)}'}},
+\ {'type': 'command', 'name': 'GoSymbols', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p'')]', 'nargs': '1'}},
 \ {'type': 'command', 'name': 'GoTabpages', 'sync': 0, 'opts': {}},
 \ {'type': 'command', 'name': 'GoTest', 'sync': 0, 'opts': {'eval': 'expand(''%:p:h'')', 'nargs': '*'}},
 \ {'type': 'command', 'name': 'GoVet', 'sync': 0, 'opts': {'complete': 'customlist,GoVetCompletion', 'eval': '[getcwd(), expand(''%:p'')]', 'nargs': '*'}},
 \ {'type': 'command', 'name': 'GoWindows', 'sync': 0, 'opts': {}},
+\ {'type': 'function', 'name': 'GoComplete', 'sync': 1, 'opts': {'eval': '[bufnr(''%''), expand(''%:p''), getcwd(), line(''.''), col(''.''), getline(''.'')]'}},
+\ {'type': 'function', 'name': 'GoDocBrowseCompletion', 'sync': 1, 'opts': {'eval': 'getcwd()'}},
 \ {'type': 'function', 'name': 'GoGuru', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p''), &modified, line2byte(line(''.'')) + (col(''.'')-2)]'}},
+\ {'type': 'function', 'name': 'GoImplCompletion', 'sync': 1, 'opts': {'eval': 'getcwd()'}},
+\ {'type': 'function', 'name': 'GoImportCompletion', 'sync': 1, 'opts': {'eval': '[getcwd(), expand(''%:p'')]'}},
 \ {'type': 'function', 'name': 'GoLintCompletion', 'sync': 0, 'opts': {'eval': 'getcwd()'}},
 \ {'type': 'function', 'name': 'GoVetCompletion', 'sync': 0, 'opts': {'eval': 'getcwd()'}},
 \ ])
 
+" -----------------------------------------------------------------------------
+" config reload
+
+" s:ConfigChanged reloads the nvim-go config when the g:go#... variables are changed.
+" Debounce the GoConfigReload command because the variables are often changed at once.
+function! s:ConfigChanged(dict, key, value) abort
+  if exists('s:config_reload_timer')
+    call timer_stop(s:config_reload_timer)
+  endif
+  let s:config_reload_timer = timer_start(100, function('s:ConfigReload'))
+endfunction
+
+function! s:ConfigReload(timer) abort
+  unlet! s:config_reload_timer
+  " the config will be loaded on the first BufEnter if the plugin host is not started yet
+  if remote#host#IsRunning(s:plugin_name)
+    GoConfigReload
+  endif
+endfunction
+
+call dictwatcheradd(g:, 'go#*', function('s:ConfigChanged'))
+
 let &cpo = s:save_cpo
 unlet s:save_cpo
@@ -9,6 +9,13 @@ syn keyword     goAstViewKind     StarExpr    StructType    AssignStmt    Expr
 syn keyword     goAstViewKind     BinaryExpr    DeclStmt    ValueSpec    ArrayType    RangeStmt
 syn keyword     goAstViewKind     CaseClause    ReturnStmt    Scope
 
+syn match       goOutlineHeader   /^".*$/
+syn match       goOutlineSection  /^▼ \zs\w\+$/
+syn match       goOutlineReceiver /^\s\+(\zs[^)]\+\ze)/
+
 hi def link     goOperator        Operator
 hi def link     goFoldIcon        Statement
 hi def link     goAstViewKind     Identifier
+hi def link     goOutlineHeader   Comment
+hi def link     goOutlineSection  Statement
+hi def link     goOutlineReceiver Type
@@ -0,0 +1,28 @@
+" Copyright 2020 The nvim-go Authors. All rights reserved.
+" Use of this source code is governed by a BSD-style
+" license that can be found in the LICENSE file.
+
+" ----------------------------------------------------------------------------
+" initialize
+
+if exists("b:current_syntax")
+  finish
+endif
+
+" ----------------------------------------------------------------------------
+" set syntax highlight
+
+syn match GoConfigComment   /^".*$/ contains=GoConfigWarning
+syn match GoConfigWarning   /Warning:/ contained
+syn match GoConfigName      /^\%(g:go#\|NVIM_GO_\)\S\+/
+syn match GoConfigSource    /\[\%(g:\|env\|project\)\]$/
+syn match GoConfigDefault   /\[default\]$/
+
+hi def link GoConfigComment Comment
+hi def link GoConfigWarning WarningMsg
+hi def link GoConfigName    Identifier
+hi def link GoConfigSource  Statement
+hi def link GoConfigDefault NonText
+
+" ----------------------------------------------------------------------------
+let b:current_syntax = "goconfig"
@@ -0,0 +1,30 @@
+" Copyright 2020 The nvim-go Authors. All rights reserved.
+" Use of this source code is governed by a BSD-style
+" license that can be found in the LICENSE file.
+
+" ----------------------------------------------------------------------------
+" initialize
+
+if exists("b:current_syntax")
+  finish
+endif
+
+" ----------------------------------------------------------------------------
+" set syntax highlight
+
+syn match GoRenameComment    /^".*$/ contains=GoRenameWarning
+syn match GoRenameWarning    /Warning:/ contained
+syn match GoRenameFile       /^\S.*\.go$/
+syn match GoRenameSelected   /^  \[x\]/
+syn match GoRenameUnselected /^  \[ \].*$/
+syn match GoRenameConflict   /^  \[!\].*$/
+
+hi def link GoRenameComment    Comment
+hi def link GoRenameWarning    WarningMsg
+hi def link GoRenameFile       Directory
+hi def link GoRenameSelected   Statement
+hi def link GoRenameUnselected NonText
+hi def link GoRenameConflict   ErrorMsg
+
+" ----------------------------------------------------------------------------
+let b:current_syntax = "gorename"